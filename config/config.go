@@ -0,0 +1,466 @@
+// Package config loads the application settings once at startup. Values come
+// from the environment, falling back to env files, and are validated against
+// the rules of the active profile before anything else starts.
+//
+// Files are read in this order, earlier ones winning:
+//   - the file named by ZOG_CONFIG_FILE, if set
+//   - app.<profile>.env
+//   - app.env
+//   - .env
+//
+// Variables already set in the environment always win over the files.
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+const (
+	Development = "development"
+	Test        = "test"
+	Production  = "production"
+)
+
+type Config struct {
+	Profile   string
+	Port      string
+	Database  Database
+	JWT       JWT
+	Razorpay  Razorpay
+	PayPal    PayPal
+	Twilio    Twilio
+	OTP       OTP
+	RateLimit RateLimit
+	TwoFactor TwoFactor
+	Mail      Mail
+	Email     Email
+	Windows   Windows
+}
+
+type Database struct {
+	DSN string
+}
+
+// JWT holds the signing key and token lifetimes. Access tokens are short
+// lived; refresh tokens are kept server-side and replaced on every use.
+type JWT struct {
+	Key        string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+type Razorpay struct {
+	KeyId     string
+	KeySecret string
+}
+
+type PayPal struct {
+	ClientId     string
+	ClientSecret string
+	BaseURL      string
+}
+
+type Twilio struct {
+	AccountSid       string
+	AuthToken        string
+	VerifyServiceSid string
+	// From is the number the local driver sends its codes from.
+	From string
+}
+
+// OTP drivers.
+const (
+	OTPTwilio  = "twilio"
+	OTPLocal   = "local"
+	OTPConsole = "console"
+)
+
+// OTP picks how one-time passwords are sent and checked. The twilio driver
+// hands both to Twilio Verify; local keeps hashed codes in the database and
+// sends them by SMS; console keeps them the same way but prints them, or
+// appends them to File, for development.
+type OTP struct {
+	Driver      string
+	Expiry      time.Duration
+	MaxAttempts int
+	File        string
+	// CountryCode is put in front of phone numbers given without one.
+	CountryCode string
+}
+
+// Rate limit stores.
+const (
+	RateLimitMemory   = "memory"
+	RateLimitDatabase = "database"
+)
+
+// RateLimit throttles the login and OTP endpoints. Counters live in memory,
+// or in the database when several instances must share them. Failed logins
+// and OTP checks lock the account after MaxFailures, for Lockout, doubling
+// with every further failure up to MaxLockout. Client IPs are only taken
+// from X-Forwarded-For when the request comes through one of TrustedProxies.
+type RateLimit struct {
+	Store          string
+	TrustedProxies []string
+	IPLimit        int
+	IPWindow       time.Duration
+	OtpCooldown    time.Duration
+	OtpDailyCap    int
+	MaxFailures    int
+	Lockout        time.Duration
+	MaxLockout     time.Duration
+}
+
+// TwoFactor configures TOTP for admins. Admins in the Required roles must
+// enrol before they can finish logging in; others may opt in.
+type TwoFactor struct {
+	Issuer   string
+	Required []string
+}
+
+// Mail drivers.
+const (
+	MailSMTP = "smtp"
+	MailFile = "file"
+)
+
+// Mail picks how email is delivered: smtp sends it through Host, file writes
+// each message into the Outbox directory for development.
+type Mail struct {
+	Driver   string
+	From     string
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Outbox   string
+}
+
+// Email configures the links mailed for password resets and address
+// verification. BaseURL is where the site is served; reset links point at
+// its /resetpassword page.
+type Email struct {
+	BaseURL   string
+	ResetTTL  time.Duration
+	VerifyTTL time.Duration
+}
+
+// Windows are the timeouts the shop runs on.
+type Windows struct {
+	Reservation   time.Duration
+	SeatHold      time.Duration
+	Payment       time.Duration
+	SweepInterval time.Duration
+}
+
+// Load reads the configuration for the profile named by ZOG_PROFILE,
+// development when unset.
+func Load() (*Config, error) {
+	profile := strings.ToLower(os.Getenv("ZOG_PROFILE"))
+	if profile == "" {
+		profile = Development
+	}
+	if profile != Development && profile != Test && profile != Production {
+		return nil, fmt.Errorf("Unknown profile %q", profile)
+	}
+	var files []string
+	if file := os.Getenv("ZOG_CONFIG_FILE"); file != "" {
+		files = append(files, file)
+	}
+	files = append(files, "app."+profile+".env", "app.env", ".env")
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		err := godotenv.Load(file)
+		if err != nil {
+			return nil, fmt.Errorf("Reading %s failed: %w", file, err)
+		}
+	}
+
+	cfg := &Config{
+		Profile: profile,
+		Port:    lookup("8080", "PORT"),
+		Database: Database{
+			DSN: lookup("", "DB_DSN", "KEY5"),
+		},
+		JWT: JWT{
+			Key: lookup("", "JWT_KEY", "KEY4"),
+		},
+		Razorpay: Razorpay{
+			KeyId:     lookup("", "RAZORPAY_KEY_ID"),
+			KeySecret: lookup("", "RAZORPAY_KEY_SECRET"),
+		},
+		PayPal: PayPal{
+			ClientId:     lookup("", "PAYPAL_CLIENT_ID"),
+			ClientSecret: lookup("", "PAYPAL_CLIENT_SECRET"),
+			BaseURL:      lookup("https://api-m.sandbox.paypal.com", "PAYPAL_BASE_URL"),
+		},
+		Twilio: Twilio{
+			AccountSid:       lookup("", "TWILIO_ACCOUNT_SID", "KEY1"),
+			AuthToken:        lookup("", "TWILIO_AUTH_TOKEN", "KEY2"),
+			VerifyServiceSid: lookup("", "TWILIO_VERIFY_SERVICE_SID", "KEY3"),
+			From:             lookup("", "TWILIO_FROM"),
+		},
+		OTP: OTP{
+			File:        lookup("", "OTP_FILE"),
+			CountryCode: lookup("+91", "OTP_COUNTRY_CODE"),
+		},
+		RateLimit: RateLimit{
+			Store:          lookup(RateLimitMemory, "RATE_LIMIT_STORE"),
+			TrustedProxies: lookupList("TRUSTED_PROXIES"),
+		},
+		TwoFactor: TwoFactor{
+			Issuer:   lookup("Zog", "TOTP_ISSUER"),
+			Required: lookupList("TOTP_REQUIRED_ROLES"),
+		},
+		Mail: Mail{
+			From:     lookup("", "MAIL_FROM"),
+			Host:     lookup("", "SMTP_HOST"),
+			Port:     lookup("587", "SMTP_PORT"),
+			Username: lookup("", "SMTP_USERNAME"),
+			Password: lookup("", "SMTP_PASSWORD"),
+			Outbox:   lookup("outbox", "MAIL_OUTBOX"),
+		},
+	}
+	if profile == Production {
+		cfg.OTP.Driver = lookup(OTPTwilio, "OTP_DRIVER")
+		cfg.Mail.Driver = lookup(MailSMTP, "MAIL_DRIVER")
+	} else {
+		cfg.OTP.Driver = lookup(OTPConsole, "OTP_DRIVER")
+		cfg.Mail.Driver = lookup(MailFile, "MAIL_DRIVER")
+		cfg.Mail.From = lookup("zog@localhost", "MAIL_FROM")
+	}
+	cfg.Email.BaseURL = strings.TrimSuffix(lookup("http://localhost:"+cfg.Port, "APP_BASE_URL"), "/")
+	var err error
+	cfg.JWT.AccessTTL, err = lookupDuration(15*time.Minute, "JWT_ACCESS_TTL")
+	if err != nil {
+		return nil, err
+	}
+	cfg.JWT.RefreshTTL, err = lookupDuration(30*24*time.Hour, "JWT_REFRESH_TTL")
+	if err != nil {
+		return nil, err
+	}
+	cfg.OTP.Expiry, err = lookupDuration(5*time.Minute, "OTP_EXPIRY")
+	if err != nil {
+		return nil, err
+	}
+	cfg.OTP.MaxAttempts, err = lookupInt(5, "OTP_MAX_ATTEMPTS")
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.IPLimit, err = lookupInt(30, "RATE_LIMIT_IP")
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.IPWindow, err = lookupDuration(time.Minute, "RATE_LIMIT_IP_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.OtpCooldown, err = lookupDuration(time.Minute, "OTP_COOLDOWN")
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.OtpDailyCap, err = lookupInt(10, "OTP_DAILY_CAP")
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.MaxFailures, err = lookupInt(5, "LOGIN_MAX_FAILURES")
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.Lockout, err = lookupDuration(time.Minute, "LOGIN_LOCKOUT")
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit.MaxLockout, err = lookupDuration(24*time.Hour, "LOGIN_MAX_LOCKOUT")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Email.ResetTTL, err = lookupDuration(time.Hour, "PASSWORD_RESET_TTL")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Email.VerifyTTL, err = lookupDuration(48*time.Hour, "EMAIL_VERIFY_TTL")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Windows.Reservation, err = lookupDuration(30*time.Minute, "RESERVATION_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Windows.SeatHold, err = lookupDuration(10*time.Minute, "SEAT_HOLD_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Windows.Payment, err = lookupDuration(15*time.Minute, "PAYMENT_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Windows.SweepInterval, err = lookupDuration(time.Minute, "SWEEP_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.JWT.Key == "" && profile != Production {
+		cfg.JWT.Key, err = randomKey()
+		if err != nil {
+			return nil, err
+		}
+		log.Println("config: JWT_KEY not set, using a random key - sessions end on restart")
+	}
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the settings every profile needs and the credentials of
+// the chosen OTP driver, and in production also the payment credentials and
+// a signing key of a safe length.
+func (c *Config) Validate() error {
+	var problems []string
+	if c.Database.DSN == "" {
+		problems = append(problems, "DB_DSN is required")
+	}
+	if c.JWT.Key == "" {
+		problems = append(problems, "JWT_KEY is required")
+	}
+	if c.JWT.AccessTTL <= 0 || c.JWT.RefreshTTL < c.JWT.AccessTTL {
+		problems = append(problems, "JWT_ACCESS_TTL must be positive and JWT_REFRESH_TTL no shorter")
+	}
+	if _, err := strconv.Atoi(c.Port); err != nil {
+		problems = append(problems, "PORT must be a number")
+	}
+	if c.Windows.Reservation <= 0 || c.Windows.SeatHold <= 0 || c.Windows.Payment <= 0 || c.Windows.SweepInterval <= 0 {
+		problems = append(problems, "RESERVATION_WINDOW, SEAT_HOLD_WINDOW, PAYMENT_WINDOW and SWEEP_INTERVAL must be positive")
+	}
+	switch c.OTP.Driver {
+	case OTPTwilio:
+		if c.Twilio.AccountSid == "" || c.Twilio.AuthToken == "" || c.Twilio.VerifyServiceSid == "" {
+			problems = append(problems, "TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_VERIFY_SERVICE_SID are required by the twilio OTP driver")
+		}
+	case OTPLocal:
+		if c.Twilio.AccountSid == "" || c.Twilio.AuthToken == "" || c.Twilio.From == "" {
+			problems = append(problems, "TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM are required by the local OTP driver")
+		}
+	case OTPConsole:
+		if c.Profile == Production {
+			problems = append(problems, "the console OTP driver cannot be used in production")
+		}
+	default:
+		problems = append(problems, "OTP_DRIVER must be twilio, local or console")
+	}
+	if c.OTP.Expiry <= 0 || c.OTP.MaxAttempts <= 0 {
+		problems = append(problems, "OTP_EXPIRY and OTP_MAX_ATTEMPTS must be positive")
+	}
+	if c.RateLimit.Store != RateLimitMemory && c.RateLimit.Store != RateLimitDatabase {
+		problems = append(problems, "RATE_LIMIT_STORE must be memory or database")
+	}
+	r := c.RateLimit
+	if r.IPLimit <= 0 || r.IPWindow <= 0 || r.OtpCooldown <= 0 || r.OtpDailyCap <= 0 || r.MaxFailures <= 0 || r.Lockout <= 0 || r.MaxLockout < r.Lockout {
+		problems = append(problems, "rate limits must be positive and LOGIN_MAX_LOCKOUT no shorter than LOGIN_LOCKOUT")
+	}
+	if !strings.HasPrefix(c.OTP.CountryCode, "+") {
+		problems = append(problems, "OTP_COUNTRY_CODE must start with +")
+	}
+	switch c.Mail.Driver {
+	case MailSMTP:
+		if c.Mail.Host == "" || c.Mail.From == "" {
+			problems = append(problems, "SMTP_HOST and MAIL_FROM are required by the smtp mail driver")
+		}
+	case MailFile:
+		if c.Profile == Production {
+			problems = append(problems, "the file mail driver cannot be used in production")
+		}
+	default:
+		problems = append(problems, "MAIL_DRIVER must be smtp or file")
+	}
+	if c.Email.ResetTTL <= 0 || c.Email.VerifyTTL <= 0 {
+		problems = append(problems, "PASSWORD_RESET_TTL and EMAIL_VERIFY_TTL must be positive")
+	}
+	if c.TwoFactor.Issuer == "" || strings.Contains(c.TwoFactor.Issuer, ":") {
+		problems = append(problems, "TOTP_ISSUER must be set and cannot contain a colon")
+	}
+	if c.Profile == Production {
+		if len(c.JWT.Key) < 32 {
+			problems = append(problems, "JWT_KEY must be at least 32 characters in production")
+		}
+		if c.Razorpay.KeyId == "" || c.Razorpay.KeySecret == "" {
+			problems = append(problems, "RAZORPAY_KEY_ID and RAZORPAY_KEY_SECRET are required in production")
+		}
+		if c.PayPal.ClientId == "" || c.PayPal.ClientSecret == "" {
+			problems = append(problems, "PAYPAL_CLIENT_ID and PAYPAL_CLIENT_SECRET are required in production")
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New("Invalid configuration: " + strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// lookup returns the first of the named variables that is set, or def. Later
+// names are the older KEYn names kept for existing env files.
+func lookup(def string, names ...string) string {
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			return value
+		}
+	}
+	return def
+}
+
+// lookupList splits a comma separated variable, dropping empty entries.
+func lookupList(name string) []string {
+	var list []string
+	for _, item := range strings.Split(lookup("", name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func lookupInt(def int, name string) (int, error) {
+	value := lookup("", name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return n, nil
+}
+
+func lookupDuration(def time.Duration, name string) (time.Duration, error) {
+	value := lookup("", name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 10m", name)
+	}
+	return d, nil
+}
+
+func randomKey() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", errors.New("Generating JWT key failed")
+	}
+	return hex.EncodeToString(key), nil
+}
@@ -0,0 +1,192 @@
+// Package cli holds the operator commands run from the server binary, as in
+// "zog admin create". They use the same configuration and database as the
+// API.
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	adminusecase "zog/usecase/admin"
+	sessionusecase "zog/usecase/session"
+	twofactorusecase "zog/usecase/twofactor"
+)
+
+const minPasswordLength = 8
+
+const adminUsage = `usage: zog admin <command> [flags]
+
+commands:
+  create          create a super-admin: -name, -email, -phone
+  reset-password  set a new password: -phone
+  deactivate      deactivate an admin and end their sessions: -phone
+  reset-2fa       clear an admin's TOTP and recovery codes and end their sessions: -phone
+  list            list admins
+
+Passwords are read from ZOG_ADMIN_PASSWORD, or from the first line of stdin.`
+
+type AdminCommand struct {
+	admins    *adminusecase.AdminUsecase
+	sessions  *sessionusecase.SessionUsecase
+	twoFactor *twofactorusecase.TwoFactorUsecase
+	in        *bufio.Reader
+	out       io.Writer
+}
+
+func NewAdminCommand(admins *adminusecase.AdminUsecase, sessions *sessionusecase.SessionUsecase, twoFactor *twofactorusecase.TwoFactorUsecase, in io.Reader, out io.Writer) *AdminCommand {
+	return &AdminCommand{admins: admins, sessions: sessions, twoFactor: twoFactor, in: bufio.NewReader(in), out: out}
+}
+
+// Run runs the admin subcommand; args are what follows "admin".
+func (ac *AdminCommand) Run(args []string) error {
+	if len(args) == 0 {
+		return errors.New(adminUsage)
+	}
+	switch args[0] {
+	case "create":
+		return ac.create(args[1:])
+	case "reset-password":
+		return ac.resetPassword(args[1:])
+	case "deactivate":
+		return ac.deactivate(args[1:])
+	case "reset-2fa":
+		return ac.resetTwoFactor(args[1:])
+	case "list":
+		return ac.list()
+	}
+	return errors.New(adminUsage)
+}
+
+func (ac *AdminCommand) create(args []string) error {
+	flags := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	name := flags.String("name", "", "admin name")
+	email := flags.String("email", "", "email")
+	phone := flags.String("phone", "", "phone number")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *email == "" || *phone == "" {
+		return errors.New("-name, -email and -phone are required")
+	}
+	password, err := ac.password()
+	if err != nil {
+		return err
+	}
+	admin, err := ac.admins.ExecuteAdminCreate(entity.AdminInput{
+		AdminName: *name,
+		Email:     *email,
+		Phone:     *phone,
+		Password:  password,
+		Role:      utils.RoleSuperAdmin,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ac.out, "created super-admin %d (%s)\n", admin.ID, admin.Phone)
+	return nil
+}
+
+func (ac *AdminCommand) resetPassword(args []string) error {
+	phone, err := phoneFlag("admin reset-password", args)
+	if err != nil {
+		return err
+	}
+	password, err := ac.password()
+	if err != nil {
+		return err
+	}
+	admin, err := ac.admins.ExecuteResetPassword(phone, password)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ac.out, "password reset for admin %d (%s)\n", admin.ID, admin.Phone)
+	return nil
+}
+
+func (ac *AdminCommand) deactivate(args []string) error {
+	phone, err := phoneFlag("admin deactivate", args)
+	if err != nil {
+		return err
+	}
+	admin, err := ac.admins.ExecuteDeactivate(phone)
+	if err != nil {
+		return err
+	}
+	err = ac.sessions.ExecuteLogoutEverywhere(admin.ID, "admin")
+	if err != nil {
+		return fmt.Errorf("admin %d deactivated but ending their sessions failed: %w", admin.ID, err)
+	}
+	fmt.Fprintf(ac.out, "deactivated admin %d (%s)\n", admin.ID, admin.Phone)
+	return nil
+}
+
+func (ac *AdminCommand) resetTwoFactor(args []string) error {
+	phone, err := phoneFlag("admin reset-2fa", args)
+	if err != nil {
+		return err
+	}
+	admin, err := ac.twoFactor.ExecuteResetByPhone(phone)
+	if err != nil {
+		return err
+	}
+	err = ac.sessions.ExecuteLogoutEverywhere(admin.ID, "admin")
+	if err != nil {
+		return fmt.Errorf("two-factor reset for admin %d but ending their sessions failed: %w", admin.ID, err)
+	}
+	fmt.Fprintf(ac.out, "two-factor authentication reset for admin %d (%s)\n", admin.ID, admin.Phone)
+	return nil
+}
+
+func (ac *AdminCommand) list() error {
+	admins, err := ac.admins.ExecuteAdminList()
+	if err != nil {
+		return err
+	}
+	for _, admin := range admins {
+		status := "active"
+		if !admin.Active {
+			status = "deactivated"
+		}
+		if admin.TotpEnabled {
+			status += ", 2fa"
+		}
+		fmt.Fprintf(ac.out, "%d\t%s\t%s\t%s\t%s\t%s\n", admin.ID, admin.AdminName, admin.Phone, admin.Email, admin.Role, status)
+	}
+	return nil
+}
+
+func phoneFlag(name string, args []string) (string, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	phone := flags.String("phone", "", "phone number of the admin")
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if *phone == "" {
+		return "", errors.New("-phone is required")
+	}
+	return *phone, nil
+}
+
+// password reads the new password from ZOG_ADMIN_PASSWORD or stdin, so it
+// never appears in the process list or shell history.
+func (ac *AdminCommand) password() (string, error) {
+	password := os.Getenv("ZOG_ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Fprint(ac.out, "password: ")
+		line, err := ac.in.ReadString('\n')
+		if err != nil && line == "" {
+			return "", errors.New("reading password failed")
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	return password, nil
+}
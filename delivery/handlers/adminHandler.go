@@ -1,39 +1,61 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"errors"
 	"net/http"
 	"strconv"
 	middlewares "zog/delivery/middlewares"
 	_ "zog/docs"
 	"zog/domain/entity"
 	usecase "zog/usecase/admin"
+	audit "zog/usecase/audit"
+	cart "zog/usecase/cart"
+	giftcard "zog/usecase/giftcard"
+	loyalty "zog/usecase/loyalty"
 	product "zog/usecase/product"
+	referral "zog/usecase/referral"
+	seat "zog/usecase/seat"
+	segment "zog/usecase/segment"
+	twofactor "zog/usecase/twofactor"
+	waitlist "zog/usecase/waitlist"
 
 	"github.com/gin-gonic/gin"
 	_ "gorm.io/gorm"
 )
 
 type AdminHandler struct {
-	AdminUsecase   *usecase.AdminUsecase
-	ProductUsecase *product.ProductUsecase
+	AdminUsecase     *usecase.AdminUsecase
+	ProductUsecase   *product.ProductUsecase
+	WaitlistUsecase  *waitlist.WaitlistUsecase
+	SeatUsecase      *seat.SeatUsecase
+	CartUsecase      *cart.CartUsecase
+	SegmentUsecase   *segment.SegmentUsecase
+	ReferralUsecase  *referral.ReferralUsecase
+	LoyaltyUsecase   *loyalty.LoyaltyUsecase
+	GiftCardUsecase  *giftcard.GiftCardUsecase
+	AuditUsecase     *audit.AuditUsecase
+	TwoFactorUsecase *twofactor.TwoFactorUsecase
+	Auth             *middlewares.Auth
+	RateLimit        *middlewares.RateLimit
 }
 
-func NewAdminHandler(AdminUsecase *usecase.AdminUsecase, ProductUsecase *product.ProductUsecase) *AdminHandler {
-	return &AdminHandler{AdminUsecase, ProductUsecase}
+func NewAdminHandler(AdminUsecase *usecase.AdminUsecase, ProductUsecase *product.ProductUsecase, WaitlistUsecase *waitlist.WaitlistUsecase, SeatUsecase *seat.SeatUsecase, CartUsecase *cart.CartUsecase, SegmentUsecase *segment.SegmentUsecase, ReferralUsecase *referral.ReferralUsecase, LoyaltyUsecase *loyalty.LoyaltyUsecase, GiftCardUsecase *giftcard.GiftCardUsecase, AuditUsecase *audit.AuditUsecase, TwoFactorUsecase *twofactor.TwoFactorUsecase, Auth *middlewares.Auth, RateLimit *middlewares.RateLimit) *AdminHandler {
+	return &AdminHandler{AdminUsecase, ProductUsecase, WaitlistUsecase, SeatUsecase, CartUsecase, SegmentUsecase, ReferralUsecase, LoyaltyUsecase, GiftCardUsecase, AuditUsecase, TwoFactorUsecase, Auth, RateLimit}
 }
 
 // Admin Register  godoc
 //
 //	@Summary		registering new admin
-//	@Description	Adding new admin to the database
+//	@Description	Adding new admin to the database. The role defaults to support
 //	@Tags			Admin Authentication
 //	@Accept			json
 //	@Produce		json
-//	@Param			admin	body		entity.Admin	true	"Admin Data"
+//	@Param			admin	body		entity.AdminInput	true	"Admin Data"
 //	@Success		200		{object}	entity.Admin
 //	@Router			/registeradmin [post]
 func (ac *AdminHandler) RegisterAdmin(c *gin.Context) {
-	var admin entity.Admin
+	var admin entity.AdminInput
 	if err := c.ShouldBindJSON(&admin); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -43,10 +65,97 @@ func (ac *AdminHandler) RegisterAdmin(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	middlewares.AuditCreated(c, newUser.ID)
 
 	c.JSON(http.StatusCreated, newUser)
 }
 
+// Admin List  godoc
+//
+//	@Summary		Admin list
+//	@Description	Listing every admin with their role and status
+//	@Tags			Admin Authentication
+//	@Produce		json
+//	@Success		200	{array}	entity.Admin
+//	@Router			/adminlist [get]
+func (ah *AdminHandler) AdminList(c *gin.Context) {
+	admins, err := ah.AdminUsecase.ExecuteAdminList()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Admins": admins})
+}
+
+// Admin Roles  godoc
+//
+//	@Summary		Admin roles
+//	@Description	Listing the admin roles and the permissions each one has
+//	@Tags			Admin Authentication
+//	@Produce		json
+//	@Success		200	{array}	entity.AdminRole
+//	@Router			/adminroles [get]
+func (ah *AdminHandler) AdminRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"Roles": ah.AdminUsecase.ExecuteRoles()})
+}
+
+// Assign Role  godoc
+//
+//	@Summary		Assign admin role
+//	@Description	Giving an admin one of the roles super-admin, catalogue-manager, order-manager, finance or support
+//	@Tags			Admin Authentication
+//	@Produce		json
+//	@Param			id		path		string	true	"Admin ID"
+//	@Param			role	path		string	true	"Role"
+//	@Success		200		{object}	entity.Admin
+//	@Router			/assignrole/{id}/{role} [put]
+func (ah *AdminHandler) AssignRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	admin, err := ah.AdminUsecase.ExecuteAssignRole(id, c.Param("role"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Admin": admin})
+}
+
+// Toggle Admin  godoc
+//
+//	@Summary		Deactivate/reactivate admin
+//	@Description	Deactivating an admin ends their sessions and stops them logging in; toggling again reactivates them
+//	@Tags			Admin Authentication
+//	@Produce		json
+//	@Param			id	path		string	true	"Admin ID"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/toggleadmin/{id} [patch]
+func (ah *AdminHandler) ToggleAdmin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	adminID, _ := c.Get("userID")
+	active, err := ah.AdminUsecase.ExecuteToggleActive(adminID.(int), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !active {
+		err = ah.Auth.LogoutEverywhere(id, "admin")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "admin deactivated but ending their sessions failed"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": "admin deactivated"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "admin reactivated"})
+}
+
 // Admin Login With Password godoc
 //
 //	@Summary		Admin Login with password
@@ -66,13 +175,25 @@ func (uh *AdminHandler) AdminLoginWithPassword(c *gin.Context) {
 	phone, _ := payload["phone"].(string)
 	password, _ := payload["password"].(string)
 
+	account := "login:admin:" + phone
+	if uh.RateLimit.Locked(c, account) {
+		return
+	}
 	adminId, err := uh.AdminUsecase.ExecuteLoginWithPassword(phone, password)
 	if err != nil {
+		if uh.RateLimit.Failed(c, account) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	} else {
-		middlewares.CreateJwtCookie(adminId, phone, "admin", c)
-		c.JSON(http.StatusOK, gin.H{"massage": "admin loged in succesfully and cookie stored"})
+		uh.RateLimit.Succeeded(account)
+		admin, err := uh.AdminUsecase.ExecuteAdminAccess(adminId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		uh.login(c, admin)
 	}
 
 }
@@ -94,19 +215,22 @@ func (al *AdminHandler) Login(c *gin.Context) {
 		return
 	}
 	phone, _ := payload["phone"].(string)
-	err := al.AdminUsecase.ExecuteAdminLogin(phone)
+	if !al.RateLimit.OtpSend(c, phone) {
+		return
+	}
+	key, err := al.AdminUsecase.ExecuteAdminLogin(phone)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	} else {
-		c.JSON(http.StatusOK, gin.H{"Otp send succesfully to": phone})
+		c.JSON(http.StatusOK, gin.H{"Otp send succesfully to": phone, "Key": key})
 	}
 }
 
 // Admin Otp Validation  godoc
 //
 //	@Summary		Otp validation
-//	@Description	Otp Validation for admin login
+//	@Description	Otp Validation for admin login, posting the phone, the key returned by /adminlogin and the otp
 //	@Tags			Admin Authentication
 //	@Accept			json
 //	@Produce		json
@@ -120,24 +244,250 @@ func (ah *AdminHandler) LoginOtpValidation(c *gin.Context) {
 		return
 	}
 	phone, _ := payload["phone"].(string)
+	key, _ := payload["key"].(string)
 	otp, _ := payload["otp"].(string)
 	resend, _ := payload["resend"].(string)
 	if resend == "resend" {
-		err := ah.AdminUsecase.ExecuteAdminLogin(phone)
-		if err == nil {
-			c.JSON(http.StatusOK, gin.H{"massage": "otp resend successful"})
+		if !ah.RateLimit.OtpSend(c, phone) {
+			return
+		}
+		key, err := ah.AdminUsecase.ExecuteAdminLogin(phone)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
 		}
+		c.JSON(http.StatusOK, gin.H{"massage": "otp resend successful", "Key": key})
 	} else {
-		admin, err1 := ah.AdminUsecase.ExecuteOtpValidation(phone, otp)
+		account := "otp:admin:" + phone
+		if ah.RateLimit.Locked(c, account) {
+			return
+		}
+		admin, err1 := ah.AdminUsecase.ExecuteOtpValidation(phone, key, otp)
 		if err1 != nil {
+			if ah.RateLimit.Failed(c, account) {
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err1.Error()})
 			return
 		}
-		middlewares.CreateJwtCookie(int(admin.ID), admin.Phone, "admin", c)
-		c.JSON(http.StatusOK, gin.H{"massage": "admin loged in succesfully and cookie stored"})
+		ah.RateLimit.Succeeded(account)
+		ah.login(c, admin)
+	}
+
+}
+
+// login finishes a login once the password or OTP was right: straight away,
+// or with a two-factor challenge when the admin needs a TOTP code.
+func (ah *AdminHandler) login(c *gin.Context, admin *entity.Admin) {
+	if ah.TwoFactorUsecase.ExecuteRequired(admin) {
+		twoFactor, err := ah.TwoFactorUsecase.ExecuteChallenge(admin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"massage": "totp code required, post it with the challenge to /admintotpverify", "twofactor": twoFactor})
+		return
+	}
+	tokens, err := ah.Auth.CreateJwtCookie(admin.ID, admin.Phone, "admin", c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"massage": "admin loged in succesfully and cookie stored", "tokens": tokens})
+}
+
+// Admin Totp Verify  godoc
+//
+//	@Summary		Two-factor login
+//	@Description	Finishing an admin login with the challenge from the login response and a TOTP code, or a recovery code. An admin who was enrolling confirms enrolment with their first code and gets their recovery codes
+//	@Tags			Admin Two-Factor
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			challenge		formData	string	true	"Challenge"
+//	@Param			code			formData	string	false	"Totp code"
+//	@Param			recoverycode	formData	string	false	"Recovery code"
+//	@Success		200				{object}	entity.AuthTokens
+//	@Router			/admintotpverify [post]
+func (ah *AdminHandler) TotpVerify(c *gin.Context) {
+	challenge := c.PostForm("challenge")
+	account := "totp:challenge:" + challenge
+	if ah.RateLimit.Locked(c, account) {
+		return
+	}
+	admin, recoveryCodes, err := ah.TwoFactorUsecase.ExecuteVerify(challenge, c.PostForm("code"), c.PostForm("recoverycode"))
+	if err != nil {
+		if ah.RateLimit.Failed(c, account) {
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	ah.RateLimit.Succeeded(account)
+	tokens, err := ah.Auth.CreateJwtCookie(admin.ID, admin.Phone, "admin", c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response := gin.H{"massage": "admin loged in succesfully and cookie stored", "tokens": tokens}
+	if recoveryCodes != nil {
+		response["recoverycodes"] = recoveryCodes
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Admin Totp Enrol  godoc
+//
+//	@Summary		Enrol in two-factor
+//	@Description	Starting TOTP enrolment for the logged in admin. Add the secret or scan /admintotpqr in an authenticator app, then confirm with /admintotpconfirm
+//	@Tags			Admin Two-Factor
+//	@Produce		json
+//	@Success		200	{object}	entity.TotpEnrolment
+//	@Router			/admintotpenrol [post]
+func (ah *AdminHandler) TotpEnrol(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	enrolment, err := ah.TwoFactorUsecase.ExecuteEnrol(adminID.(int))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Enrolment": enrolment})
+}
+
+// Admin Totp QR  godoc
+//
+//	@Summary		Two-factor QR code
+//	@Description	The pending enrolment as a QR code PNG, for the logged in admin or, on /admintotpchallengeqr, for the holder of a login challenge
+//	@Tags			Admin Two-Factor
+//	@Produce		png
+//	@Param			challenge	query	string	false	"Challenge, on /admintotpchallengeqr"
+//	@Success		200			{file}	binary	"QR code PNG"
+//	@Router			/admintotpqr [get]
+//	@Router			/admintotpchallengeqr [get]
+func (ah *AdminHandler) TotpQR(c *gin.Context) {
+	var adminId int
+	if adminID, ok := c.Get("userID"); ok {
+		adminId = adminID.(int)
+	} else {
+		admin, _, err := ah.TwoFactorUsecase.ExecuteChallengeAdmin(c.Query("challenge"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		adminId = admin.ID
+	}
+	png, err := ah.TwoFactorUsecase.ExecuteQR(adminId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// Admin Totp Confirm  godoc
+//
+//	@Summary		Confirm two-factor enrolment
+//	@Description	Turning TOTP on with the first code from the authenticator. The recovery codes are shown only this once
+//	@Tags			Admin Two-Factor
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			code	formData	string	true	"Totp code"
+//	@Success		200		{string}	string	"Recovery codes"
+//	@Router			/admintotpconfirm [post]
+func (ah *AdminHandler) TotpConfirm(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	recoveryCodes, err := ah.TwoFactorUsecase.ExecuteConfirm(adminID.(int), c.PostForm("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"massage": "two-factor authentication enabled", "recoverycodes": recoveryCodes})
+}
+
+// Admin Totp Recovery Codes  godoc
+//
+//	@Summary		New recovery codes
+//	@Description	Replacing the admin's recovery codes, given a current TOTP code
+//	@Tags			Admin Two-Factor
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			code	formData	string	true	"Totp code"
+//	@Success		200		{string}	string	"Recovery codes"
+//	@Router			/admintotprecoverycodes [post]
+func (ah *AdminHandler) TotpRecoveryCodes(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	recoveryCodes, err := ah.TwoFactorUsecase.ExecuteRecoveryCodes(adminID.(int), c.PostForm("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoverycodes": recoveryCodes})
+}
+
+// Admin Totp Recovery Codes Left  godoc
+//
+//	@Summary		Recovery codes left
+//	@Description	How many of the admin's recovery codes are still unused, to know when to ask for new ones
+//	@Tags			Admin Two-Factor
+//	@Produce		json
+//	@Success		200	{string}	string	"Recovery codes left"
+//	@Router			/admintotprecoverycodes [get]
+func (ah *AdminHandler) TotpRecoveryCodesLeft(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	left, err := ah.TwoFactorUsecase.ExecuteRecoveryCodesLeft(adminID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoverycodesleft": left})
+}
 
+// Admin Totp Disable  godoc
+//
+//	@Summary		Disable two-factor
+//	@Description	Turning TOTP off, given a current code. Not allowed for roles that require it
+//	@Tags			Admin Two-Factor
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			code	formData	string	true	"Totp code"
+//	@Success		200		{string}	string	"Success message"
+//	@Router			/admintotpdisable [post]
+func (ah *AdminHandler) TotpDisable(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	err := ah.TwoFactorUsecase.ExecuteDisable(adminID.(int), c.PostForm("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"massage": "two-factor authentication disabled"})
+}
 
+// Reset Admin Totp  godoc
+//
+//	@Summary		Reset admin two-factor
+//	@Description	Clearing an admin's TOTP and recovery codes so they enrol again at their next login, and ending their sessions
+//	@Tags			Admin Two-Factor
+//	@Produce		json
+//	@Param			id	path		string	true	"Admin ID"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/resettotp/{id} [patch]
+func (ah *AdminHandler) ResetTotp(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	_, err = ah.TwoFactorUsecase.ExecuteReset(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = ah.Auth.LogoutEverywhere(id, "admin")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "two-factor reset but ending their sessions failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "two-factor authentication reset"})
 }
 
 // Admin Home  godoc
@@ -158,6 +508,63 @@ func (ah *AdminHandler) Home(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"Dashboard": dashboardResponse})
 }
 
+// Admin Refresh  godoc
+//
+//	@Summary		Refresh admin session
+//	@Description	Exchanging the admin refresh token, from the cookie or the refreshtoken field, for new tokens
+//	@Tags			Admin Authentication
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			refreshtoken	formData	string	false	"Refresh token when not sent as a cookie"
+//	@Success		200				{object}	entity.AuthTokens
+//	@Router			/adminrefresh [post]
+func (ah *AdminHandler) Refresh(c *gin.Context) {
+	tokens, err := ah.Auth.Refresh("admin", c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session refreshed", "tokens": tokens})
+}
+
+// Admin Logout  godoc
+//
+//	@Summary		Admin logout
+//	@Description	Revoking the admin session's tokens and deleting the cookies
+//	@Tags			Admin Authentication
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			refreshtoken	formData	string	false	"Refresh token when not sent as a cookie"
+//	@Success		200				{string}	string	"Success message"
+//	@Router			/adminlogout [post]
+func (ah *AdminHandler) Logout(c *gin.Context) {
+	err := ah.Auth.Logout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "admin cookie deletion failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+// Admin Logout Everywhere  godoc
+//
+//	@Summary		Admin logout everywhere
+//	@Description	Ending every session of the admin on every device
+//	@Tags			Admin Authentication
+//	@Produce		json
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/adminlogouteverywhere [post]
+func (ah *AdminHandler) LogoutEverywhere(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	err := ah.Auth.LogoutEverywhere(adminID.(int), "admin")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	middlewares.DeleteCookie(c)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out from all devices"})
+}
+
 // User Management  godoc
 //
 //	@Summary		User list
@@ -277,10 +684,19 @@ func (tp *AdminHandler) TogglePermission(c *gin.Context) {
 	Id, err := strconv.Atoi(id)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
 	}
-	err1 := tp.AdminUsecase.ExecuteTogglePermission(Id)
+	permission, err1 := tp.AdminUsecase.ExecuteTogglePermission(Id)
 	if err1 != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if !permission {
+		err = tp.Auth.LogoutEverywhere(Id, "user")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "user blocked but ending their sessions failed"})
+			return
+		}
 	}
 	c.JSON(http.StatusOK, gin.H{"success": "user permission toggled"})
 }
@@ -318,6 +734,7 @@ func (ct *AdminHandler) CreateTicket(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	} else {
+		middlewares.AuditCreated(c, ticketId)
 		ticketDetails := entity.TicketDetails{
 			TicketId:    ticketId,
 			Description: input.Description,
@@ -394,88 +811,347 @@ func (dt *AdminHandler) DeleteTicket(c *gin.Context) {
 
 }
 
-// Add Apparel  godoc
+// Ticket Transfer Settings  godoc
 //
-//	@Summary		Adding new product
-//	@Description	Adding new product of category apparel in database
+//	@Summary		Ticket transfer settings
+//	@Description	Enabling or disabling ticket transfers for an event and setting the cutoff before the event date
 //	@Tags			Admin Product&Offer Management
-//	@Accept			json
+//	@Accept			multipart/form-data
 //	@Produce		json
-//	@Param			admin	body		entity.ApparelInput	true	"Apparel Data"
-//	@Success		200		{object}	entity.Apparel
-//	@Router			/addapparel [post]
-func (aa *AdminHandler) CreateApparel(c *gin.Context) {
-	var input entity.ApparelInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	}
-	apparelId, err := aa.ProductUsecase.ExecuteCreateApparel(input.Apparel)
+//	@Param			id			path		string	true	"Ticket ID"
+//	@Param			disabled	formData	string	false	"true/false"
+//	@Param			cutoff		formData	string	false	"cutoff in hours before the event"
+//	@Success		200			{string}	string	"Success message"
+//	@Router			/transfersettings/{id} [put]
+func (ah *AdminHandler) TransferSettings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
 	}
-	err = aa.ProductUsecase.ExecuteCreateApparelDetails(input.ApparelDetails)
+	disabled := c.PostForm("disabled") == "true"
+	cutoff, err := strconv.Atoi(c.DefaultPostForm("cutoff", "0"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cutoff parameter"})
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": "Apparel added succesfully"})
-	input.Inventory.ProductId = apparelId
-	err = aa.ProductUsecase.ExecuteCreateInventory(input.Inventory)
+	err = ah.ProductUsecase.ExecuteTransferSettings(id, disabled, cutoff)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	} else {
-		c.JSON(http.StatusOK, gin.H{"success": "apparel inventory created succesfully"})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"success": "transfer settings updated"})
 }
 
-// Edit Apparel  godoc
+// Add Seat Map  godoc
 //
-//	@Summary		Edit existing product data
-//	@Description	Edit data of a product in category apparel
+//	@Summary		Adding seat map
+//	@Description	Adding sections, rows and seats for a reserved-seating event, ticket stock is set to the seat count
 //	@Tags			Admin Product&Offer Management
 //	@Accept			json
 //	@Produce		json
-//	@Param			admin	body		entity.Apparel	true	"Apparel Data"
-//	@Success		200		{object}	entity.Apparel
-//	@Router			/editapparel/{id} [put]
-func (ea *AdminHandler) EditApparel(c *gin.Context) {
-	var apparel entity.Apparel
-	id := c.Param("id")
-	Id, err := strconv.Atoi(id)
+//	@Param			ticketid	path		string				true	"Ticket ID"
+//	@Param			seatmap		body		entity.SeatMapInput	true	"Seat map"
+//	@Success		200			{string}	string				"Success message"
+//	@Router			/addseatmap/{ticketid} [post]
+func (ah *AdminHandler) AddSeatMap(c *gin.Context) {
+	ticketId, err := strconv.Atoi(c.Param("ticketid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
 	}
-	if err := c.ShouldBindJSON(&apparel); err != nil {
+	var input entity.SeatMapInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	err1 := ea.ProductUsecase.ExecuteEditApparel(apparel, Id)
-	if err1 != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
+	total, err := ah.SeatUsecase.ExecuteCreateSeatMap(ticketId, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": "apparel edited succesfully"})
+	c.JSON(http.StatusOK, gin.H{"success": "seat map added", "Seats": total})
 }
 
-// Delete Apparel  godoc
+// Ticket Pricing  godoc
 //
-//	@Summary		Delete existing product from database
-//	@Description	Soft deleting the data of a product from database in category apparel
+//	@Summary		Ticket pricing
+//	@Description	Showing the price schedules, demand rules and current price of a ticket
 //	@Tags			Admin Product&Offer Management
 //	@Accept			json
 //	@Produce		json
-//	@param			ProductId	query		int	true	"product id"
-//	@Success		200			{object}	entity.Apparel
-//	@Router			/deleteapparel/{id} [delete]
-func (da *AdminHandler) DeleteApparel(c *gin.Context) {
-	id := c.Param("id")
-	Id, err := strconv.Atoi(id)
+//	@Param			ticketid	path		string	true	"Ticket ID"
+//	@Success		200			{object}	entity.TicketPricing
+//	@Router			/ticketpricing/{ticketid} [get]
+func (ah *AdminHandler) TicketPricing(c *gin.Context) {
+	ticketId, err := strconv.Atoi(c.Param("ticketid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
 	}
-	err1 := da.ProductUsecase.ExecuteDeleteApparel(Id)
-	if err1 != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Apparel not found"})
-	} else {
-		c.JSON(http.StatusOK, gin.H{"success": "apparel deleted succesfully"})
-	}
+	pricing, err := ah.ProductUsecase.ExecuteTicketPricing(ticketId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Pricing": pricing})
+}
+
+// Add Price Schedule  godoc
+//
+//	@Summary		Adding price schedule
+//	@Description	Adding a time window with its own ticket price, like early bird or last minute
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			ticketid	path		string					true	"Ticket ID"
+//	@Param			schedule	body		entity.PriceSchedule	true	"Price schedule"
+//	@Success		200			{string}	string					"Success message"
+//	@Router			/addpriceschedule/{ticketid} [post]
+func (ah *AdminHandler) AddPriceSchedule(c *gin.Context) {
+	ticketId, err := strconv.Atoi(c.Param("ticketid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	var schedule entity.PriceSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = ah.ProductUsecase.ExecuteAddPriceSchedule(ticketId, schedule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "price schedule added"})
+}
+
+// Delete Price Schedule  godoc
+//
+//	@Summary		Deleting price schedule
+//	@Description	Deleting a price schedule of a ticket
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Price schedule ID"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/deletepriceschedule/{id} [delete]
+func (ah *AdminHandler) DeletePriceSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = ah.ProductUsecase.ExecuteDeletePriceSchedule(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "price schedule deleted"})
+}
+
+// Add Demand Rule  godoc
+//
+//	@Summary		Adding demand rule
+//	@Description	Raising the ticket price by a percentage once remaining stock drops to the threshold
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			ticketid	path		string				true	"Ticket ID"
+//	@Param			rule		body		entity.DemandRule	true	"Demand rule"
+//	@Success		200			{string}	string				"Success message"
+//	@Router			/adddemandrule/{ticketid} [post]
+func (ah *AdminHandler) AddDemandRule(c *gin.Context) {
+	ticketId, err := strconv.Atoi(c.Param("ticketid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	var rule entity.DemandRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = ah.ProductUsecase.ExecuteAddDemandRule(ticketId, rule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "demand rule added"})
+}
+
+// Delete Demand Rule  godoc
+//
+//	@Summary		Deleting demand rule
+//	@Description	Deleting a demand rule of a ticket
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Demand rule ID"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/deletedemandrule/{id} [delete]
+func (ah *AdminHandler) DeleteDemandRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = ah.ProductUsecase.ExecuteDeleteDemandRule(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "demand rule deleted"})
+}
+
+// Add Apparel  godoc
+//
+//	@Summary		Adding new product
+//	@Description	Adding new product of category apparel in database
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			admin	body		entity.ApparelInput	true	"Apparel Data"
+//	@Success		200		{object}	entity.Apparel
+//	@Router			/addapparel [post]
+func (aa *AdminHandler) CreateApparel(c *gin.Context) {
+	var input entity.ApparelInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	apparelId, err := aa.ProductUsecase.ExecuteCreateApparel(input.Apparel)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	middlewares.AuditCreated(c, apparelId)
+	err = aa.ProductUsecase.ExecuteCreateApparelDetails(input.ApparelDetails)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "Apparel added succesfully"})
+	input.Inventory.ProductId = apparelId
+	input.Inventory.ProductCategory = "apparel"
+	err = aa.ProductUsecase.ExecuteCreateInventory(input.Inventory)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	} else {
+		c.JSON(http.StatusOK, gin.H{"success": "apparel inventory created succesfully"})
+	}
+}
+
+// Edit Apparel  godoc
+//
+//	@Summary		Edit existing product data
+//	@Description	Edit data of a product in category apparel
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			admin	body		entity.Apparel	true	"Apparel Data"
+//	@Success		200		{object}	entity.Apparel
+//	@Router			/editapparel/{id} [put]
+func (ea *AdminHandler) EditApparel(c *gin.Context) {
+	var apparel entity.Apparel
+	id := c.Param("id")
+	Id, err := strconv.Atoi(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+	}
+	if err := c.ShouldBindJSON(&apparel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	err1 := ea.ProductUsecase.ExecuteEditApparel(apparel, Id)
+	if err1 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "apparel edited succesfully"})
+}
+
+// Delete Apparel  godoc
+//
+//	@Summary		Delete existing product from database
+//	@Description	Soft deleting the data of a product from database in category apparel
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@param			ProductId	query		int	true	"product id"
+//	@Success		200			{object}	entity.Apparel
+//	@Router			/deleteapparel/{id} [delete]
+func (da *AdminHandler) DeleteApparel(c *gin.Context) {
+	id := c.Param("id")
+	Id, err := strconv.Atoi(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+	}
+	err1 := da.ProductUsecase.ExecuteDeleteApparel(Id)
+	if err1 != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Apparel not found"})
+	} else {
+		c.JSON(http.StatusOK, gin.H{"success": "apparel deleted succesfully"})
+	}
+}
+
+// Restock   godoc
+//
+//	@Summary		Restocking a product
+//	@Description	Increasing the inventory of a product, waitlisted users get reservations for the new stock
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			category	path		string	true	"Ticket/Apparel"
+//	@Param			productid	path		string	true	"Product ID"
+//	@Param			quantity	path		string	true	"Quantity"
+//	@Success		200			{string}	string	"Success message"
+//	@Router			/restock/{category}/{productid}/{quantity} [put]
+func (ah *AdminHandler) Restock(c *gin.Context) {
+	category := c.Param("category")
+	productId, err := strconv.Atoi(c.Param("productid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	quantity, err := strconv.Atoi(c.Param("quantity"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	if quantity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity must be greater than zero"})
+		return
+	}
+	inventory := entity.Inventory{
+		ProductId:       productId,
+		ProductCategory: category,
+	}
+	err = ah.ProductUsecase.ExecuteQuantityUpdate(inventory, "increase", quantity)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = ah.WaitlistUsecase.ExecuteProcessWaitlist()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": "product restocked succesfully", "waitlist": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "product restocked succesfully"})
+}
+
+// Waitlist Report   godoc
+//
+//	@Summary		Waitlist length per product
+//	@Description	Showing the number of users waiting and holding reservations for each product
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	entity.WaitlistReport	"Waitlist report"
+//	@Router			/waitlistreport [get]
+func (ah *AdminHandler) WaitlistReport(c *gin.Context) {
+	report, err := ah.WaitlistUsecase.ExecuteWaitlistReport()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Waitlist": report})
 }
 
 // Add Coupon   godoc
@@ -492,11 +1168,13 @@ func (ah *AdminHandler) AddCoupon(c *gin.Context) {
 	var coupon entity.Coupon
 	if err := c.ShouldBindJSON(&coupon); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	err := ah.ProductUsecase.ExecuteAddCoupon(&coupon)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 	} else {
+		middlewares.AuditCreated(c, coupon.Id)
 		c.JSON(http.StatusOK, gin.H{"success": "coupon created succesfully"})
 	}
 }
@@ -514,11 +1192,786 @@ func (ah *AdminHandler) AddOffer(c *gin.Context) {
 	var offer entity.Offer
 	if err := c.ShouldBindJSON(&offer); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	err := ah.ProductUsecase.ExecuteAddOffer(&offer)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 	} else {
+		middlewares.AuditCreated(c, offer.Id)
 		c.JSON(http.StatusOK, gin.H{"success": "offer created succesfully"})
 	}
 }
+
+// Coupon List  godoc
+//
+//	@Summary		Coupon list
+//	@Description	Listing coupons with filters for management by admin
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			page		query		string	false	"page no"
+//	@Param			limit		query		string	false	"limit no"
+//	@Param			status		query		string	false	"active/inactive/expired/scheduled"
+//	@Param			category	query		string	false	"ticket/apparel"
+//	@Param			search		query		string	false	"Coupon code"
+//	@Success		200			{object}	[]entity.Coupon
+//	@Router			/couponlist [get]
+func (ah *AdminHandler) CouponList(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+	coupons, err := ah.ProductUsecase.ExecuteCouponList(page, limit, c.Query("status"), c.Query("category"), c.Query("search"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Coupons": coupons})
+}
+
+// Edit Coupon  godoc
+//
+//	@Summary		Editing coupon
+//	@Description	Updating the terms of a coupon, carts holding it are re-priced at checkout
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Coupon ID"
+//	@Param			coupon	body		entity.Coupon	true	"coupon"
+//	@Success		200		{object}	entity.Coupon
+//	@Router			/editcoupon/{id} [put]
+func (ah *AdminHandler) EditCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	var input entity.Coupon
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	coupon, err := ah.ProductUsecase.ExecuteEditCoupon(id, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	carts, err := ah.CartUsecase.ExecuteCouponCarts(coupon.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "coupon updated, carts holding it are re-priced at checkout", "Coupon": coupon, "CartsAffected": carts})
+}
+
+// Toggle Coupon  godoc
+//
+//	@Summary		Activating or deactivating coupon
+//	@Description	Pausing or resuming a coupon, a paused coupon is removed from the carts it was applied to
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Coupon ID"
+//	@Success		200	{object}	entity.Coupon
+//	@Router			/togglecoupon/{id} [put]
+func (ah *AdminHandler) ToggleCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	coupon, err := ah.ProductUsecase.ExecuteToggleCoupon(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if coupon.Active {
+		c.JSON(http.StatusOK, gin.H{"success": "coupon activated", "Coupon": coupon})
+		return
+	}
+	carts, err := ah.CartUsecase.ExecuteDetachCoupon(coupon.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "coupon deactivated", "Coupon": coupon, "CartsCleared": carts})
+}
+
+// Delete Coupon  godoc
+//
+//	@Summary		Deleting coupon
+//	@Description	Deleting a coupon that was never used and removing it from carts
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Coupon ID"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/deletecoupon/{id} [delete]
+func (ah *AdminHandler) DeleteCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	coupon, err := ah.ProductUsecase.ExecuteDeleteCoupon(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	carts, err := ah.CartUsecase.ExecuteDetachCoupon(coupon.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "coupon deleted", "CartsCleared": carts})
+}
+
+// Coupon Usage  godoc
+//
+//	@Summary		Coupon usage
+//	@Description	Showing who used a coupon and on which order
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Coupon ID"
+//	@Success		200	{object}	entity.CouponUsage
+//	@Router			/couponusage/{id} [get]
+func (ah *AdminHandler) CouponUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	usage, err := ah.ProductUsecase.ExecuteCouponUsage(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	carts, err := ah.CartUsecase.ExecuteCouponCarts(usage.Coupon.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Usage": usage, "AppliedInCarts": carts})
+}
+
+// Offer List  godoc
+//
+//	@Summary		Offer list
+//	@Description	Listing offers with filters for management by admin
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			page		query		string	false	"page no"
+//	@Param			limit		query		string	false	"limit no"
+//	@Param			status		query		string	false	"active/inactive/expired/scheduled"
+//	@Param			category	query		string	false	"ticket/apparel"
+//	@Success		200			{object}	[]entity.Offer
+//	@Router			/offerlist [get]
+func (ah *AdminHandler) OfferList(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+	offers, err := ah.ProductUsecase.ExecuteOfferList(page, limit, c.Query("status"), c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Offers": offers})
+}
+
+// Edit Offer  godoc
+//
+//	@Summary		Editing offer
+//	@Description	Updating the terms of an offer and re-pricing the carts holding it
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Offer ID"
+//	@Param			offer	body		entity.Offer	true	"offer"
+//	@Success		200		{object}	entity.Offer
+//	@Router			/editoffer/{id} [put]
+func (ah *AdminHandler) EditOffer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	var input entity.Offer
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	offer, err := ah.ProductUsecase.ExecuteEditOffer(id, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = ah.CartUsecase.ExecuteRepriceOffer(id)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": "offer updated", "Offer": offer, "carts": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "offer updated", "Offer": offer})
+}
+
+// Toggle Offer  godoc
+//
+//	@Summary		Activating or deactivating offer
+//	@Description	Pausing or resuming an offer and re-pricing the carts holding it
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Offer ID"
+//	@Success		200	{object}	entity.Offer
+//	@Router			/toggleoffer/{id} [put]
+func (ah *AdminHandler) ToggleOffer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	offer, err := ah.ProductUsecase.ExecuteToggleOffer(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = ah.CartUsecase.ExecuteRepriceOffer(id)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": "offer updated", "Offer": offer, "carts": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "offer updated", "Offer": offer})
+}
+
+// Delete Offer  godoc
+//
+//	@Summary		Deleting offer
+//	@Description	Deleting an offer and re-pricing the carts holding it
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Offer ID"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/deleteoffer/{id} [delete]
+func (ah *AdminHandler) DeleteOffer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = ah.ProductUsecase.ExecuteDeleteOffer(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = ah.CartUsecase.ExecuteRepriceOffer(id)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": "offer deleted", "carts": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "offer deleted"})
+}
+
+// Promotion Rules  godoc
+//
+//	@Summary		Promotion stacking rules
+//	@Description	Showing which promotions stack, the order they apply in and the overall discount cap
+//	@Tags			Admin Product&Offer Management
+//	@Produce		json
+//	@Success		200	{object}	entity.PromotionRule
+//	@Router			/promotionrules [get]
+func (ah *AdminHandler) PromotionRules(c *gin.Context) {
+	rule, err := ah.ProductUsecase.ExecutePromotionRule()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Rules": rule})
+}
+
+// Update Promotion Rules  godoc
+//
+//	@Summary		Updating promotion stacking rules
+//	@Description	Choosing whether a coupon stacks with offers, whether offers stack with each other, which applies first and the overall discount cap (0 means no cap)
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			rules	body		entity.PromotionRule	true	"rules"
+//	@Success		200		{object}	entity.PromotionRule
+//	@Router			/promotionrules [put]
+func (ah *AdminHandler) UpdatePromotionRules(c *gin.Context) {
+	var input entity.PromotionRule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := ah.ProductUsecase.ExecuteUpdatePromotionRule(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "promotion rules updated", "Rules": rule})
+}
+
+// Add Coupon Campaign  godoc
+//
+//	@Summary		Generating campaign coupon codes
+//	@Description	Generating count unique single-use codes from a prefix, length and alphabet, all sharing the campaign rules
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			campaign	body		entity.CouponCampaign	true	"campaign"
+//	@Success		200			{object}	entity.CouponCampaign
+//	@Router			/addcampaign [post]
+func (ah *AdminHandler) AddCampaign(c *gin.Context) {
+	var campaign entity.CouponCampaign
+	if err := c.ShouldBindJSON(&campaign); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := ah.ProductUsecase.ExecuteCreateCampaign(&campaign)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	middlewares.AuditCreated(c, campaign.ID)
+	c.JSON(http.StatusOK, gin.H{"success": "campaign codes generated", "Campaign": campaign})
+}
+
+// Coupon Campaign List  godoc
+//
+//	@Summary		Coupon campaign list
+//	@Description	Listing coupon campaigns
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			page	query		string	false	"page no"
+//	@Param			limit	query		string	false	"limit no"
+//	@Param			status	query		string	false	"active/inactive/expired/scheduled"
+//	@Success		200		{object}	[]entity.CouponCampaign
+//	@Router			/campaignlist [get]
+func (ah *AdminHandler) CampaignList(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+	campaigns, err := ah.ProductUsecase.ExecuteCampaignList(page, limit, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Campaigns": campaigns})
+}
+
+// Coupon Campaign Stats  godoc
+//
+//	@Summary		Coupon campaign stats
+//	@Description	Showing how many campaign codes were generated, redeemed and turned into orders, and the discount given
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Campaign ID"
+//	@Success		200	{object}	entity.CampaignStats
+//	@Router			/campaignstats/{id} [get]
+func (ah *AdminHandler) CampaignStats(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	stats, err := ah.ProductUsecase.ExecuteCampaignStats(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Stats": stats})
+}
+
+// Deactivate Coupon Campaign  godoc
+//
+//	@Summary		Deactivating coupon campaign
+//	@Description	Stopping every unused code of a campaign from being redeemed
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Campaign ID"
+//	@Success		200	{object}	entity.CouponCampaign
+//	@Router			/deactivatecampaign/{id} [put]
+func (ah *AdminHandler) DeactivateCampaign(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	campaign, err := ah.ProductUsecase.ExecuteDeactivateCampaign(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "campaign deactivated", "Campaign": campaign})
+}
+
+// Export Coupon Campaign  godoc
+//
+//	@Summary		Exporting campaign codes
+//	@Description	Downloading every code of a campaign with its redemption as CSV
+//	@Tags			Admin Product&Offer Management
+//	@Produce		text/csv
+//	@Param			id	path	string	true	"Campaign ID"
+//	@Success		200	{file}	file
+//	@Router			/exportcampaign/{id} [get]
+func (ah *AdminHandler) ExportCampaign(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	campaign, codes, err := ah.ProductUsecase.ExecuteCampaignCodes(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename=campaign-"+strconv.Itoa(campaign.ID)+".csv")
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"code", "status", "used_by", "order_id", "used_at"})
+	for _, code := range codes {
+		status, usedBy, orderId, usedAt := "unused", "", "", ""
+		if code.UsedBy != 0 {
+			status = "redeemed"
+			usedBy = strconv.Itoa(code.UsedBy)
+		}
+		if code.OrderId != 0 {
+			orderId = strconv.Itoa(code.OrderId)
+		}
+		if code.UsedAt != nil {
+			usedAt = code.UsedAt.Format("2006-01-02 15:04:05")
+		}
+		writer.Write([]string{code.Code, status, usedBy, orderId, usedAt})
+	}
+	writer.Flush()
+}
+
+// Add Segment  godoc
+//
+//	@Summary		Adding customer segment
+//	@Description	Adding a segment by rule: new (no orders, optionally joined in the last days), lapsed (ordered before but not in days, 90 by default), event (bought tickets for ticketid) or wallet (balance between walletmin and walletmax)
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			segment	body		entity.Segment	true	"segment"
+//	@Success		200		{object}	entity.Segment
+//	@Router			/addsegment [post]
+func (ah *AdminHandler) AddSegment(c *gin.Context) {
+	var segment entity.Segment
+	if err := c.ShouldBindJSON(&segment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := ah.SegmentUsecase.ExecuteCreateSegment(&segment)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	middlewares.AuditCreated(c, segment.ID)
+	c.JSON(http.StatusOK, gin.H{"success": "segment created", "Segment": segment})
+}
+
+// Segment List  godoc
+//
+//	@Summary		Customer segment list
+//	@Description	Listing the customer segments coupons and offers can be limited to
+//	@Tags			Admin Product&Offer Management
+//	@Produce		json
+//	@Success		200	{object}	[]entity.Segment
+//	@Router			/segmentlist [get]
+func (ah *AdminHandler) SegmentList(c *gin.Context) {
+	segments, err := ah.SegmentUsecase.ExecuteSegmentList()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Segments": segments})
+}
+
+// Delete Segment  godoc
+//
+//	@Summary		Deleting customer segment
+//	@Description	Deleting a segment no coupon or offer is limited to
+//	@Tags			Admin Product&Offer Management
+//	@Produce		json
+//	@Param			id	path		string	true	"Segment ID"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/deletesegment/{id} [delete]
+func (ah *AdminHandler) DeleteSegment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = ah.SegmentUsecase.ExecuteDeleteSegment(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "segment deleted"})
+}
+
+// Referral Report  godoc
+//
+//	@Summary		Referral report
+//	@Description	Showing how many invited users converted, were rejected as abuse or are still waiting on their first order, and the credit paid out
+//	@Tags			Admin User Management
+//	@Produce		json
+//	@Param			page	query		string	false	"page no"
+//	@Param			limit	query		string	false	"limit no"
+//	@Param			status	query		string	false	"rewarded/rejected"
+//	@Success		200		{object}	entity.ReferralReport
+//	@Router			/referralreport [get]
+func (ah *AdminHandler) ReferralReport(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+	report, err := ah.ReferralUsecase.ExecuteReferralReport(page, limit, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Referrals": report})
+}
+
+// Referral Settings  godoc
+//
+//	@Summary		Referral settings
+//	@Description	Showing the wallet credit given to both users when a referral converts
+//	@Tags			Admin User Management
+//	@Produce		json
+//	@Success		200	{object}	entity.ReferralSetting
+//	@Router			/referralsettings [get]
+func (ah *AdminHandler) ReferralSettings(c *gin.Context) {
+	setting, err := ah.ReferralUsecase.ExecuteSetting()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Settings": setting})
+}
+
+// Update Referral Settings  godoc
+//
+//	@Summary		Updating referral settings
+//	@Description	Changing the wallet credit for the referrer and the referred user, or pausing the programme
+//	@Tags			Admin User Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			settings	body		entity.ReferralSetting	true	"settings"
+//	@Success		200			{object}	entity.ReferralSetting
+//	@Router			/referralsettings [put]
+func (ah *AdminHandler) UpdateReferralSettings(c *gin.Context) {
+	var input entity.ReferralSetting
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	setting, err := ah.ReferralUsecase.ExecuteUpdateSetting(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "referral settings updated", "Settings": setting})
+}
+
+// Loyalty Settings  godoc
+//
+//	@Summary		Loyalty settings
+//	@Description	Showing the points earned per 100 spent in each category, the value of a point, the return window, point expiry and the redemption cap
+//	@Tags			Admin Product&Offer Management
+//	@Produce		json
+//	@Success		200	{object}	entity.LoyaltySetting
+//	@Router			/loyaltysettings [get]
+func (ah *AdminHandler) LoyaltySettings(c *gin.Context) {
+	setting, err := ah.LoyaltyUsecase.ExecuteSetting()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Settings": setting})
+}
+
+// Update Loyalty Settings  godoc
+//
+//	@Summary		Updating loyalty settings
+//	@Description	Changing how points are earned, how long they stay pending and valid, what they are worth and how much of a cart they may cover
+//	@Tags			Admin Product&Offer Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			settings	body		entity.LoyaltySetting	true	"settings"
+//	@Success		200			{object}	entity.LoyaltySetting
+//	@Router			/loyaltysettings [put]
+func (ah *AdminHandler) UpdateLoyaltySettings(c *gin.Context) {
+	var input entity.LoyaltySetting
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	setting, err := ah.LoyaltyUsecase.ExecuteUpdateSetting(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "loyalty settings updated", "Settings": setting})
+}
+
+// Issue Gift Card  godoc
+//
+//	@Summary		Issuing a gift card
+//	@Description	Issuing an active gift card of any amount, optionally addressed to a recipient and valid for validitydays (365 by default). The code is only shown in this response and emailed to the recipient
+//	@Tags			Admin Order Management
+//	@Accept			json
+//	@Produce		json
+//	@Param			giftcard	body		entity.GiftCardInput	true	"gift card"
+//	@Success		200			{object}	entity.GiftCard
+//	@Router			/issuegiftcard [post]
+func (ah *AdminHandler) IssueGiftCard(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	adminId := adminID.(int)
+	var input entity.GiftCardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	card, err := ah.GiftCardUsecase.ExecuteIssue(adminId, input)
+	if card != nil {
+		middlewares.AuditCreated(c, card.ID)
+	}
+	if errors.Is(err, giftcard.ErrMailFailed) {
+		c.JSON(http.StatusOK, gin.H{"success": "gift card issued", "GiftCard": card, "email": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "gift card issued", "GiftCard": card})
+}
+
+// Void Gift Card  godoc
+//
+//	@Summary		Voiding a gift card
+//	@Description	Stopping a gift card from being used and writing off its balance
+//	@Tags			Admin Order Management
+//	@Produce		json
+//	@Param			id	path		string	true	"gift card id"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/voidgiftcard/{id} [patch]
+func (ah *AdminHandler) VoidGiftCard(c *gin.Context) {
+	adminID, _ := c.Get("userID")
+	adminId := adminID.(int)
+	cardId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = ah.GiftCardUsecase.ExecuteVoid(adminId, cardId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "gift card voided"})
+}
+
+// Gift Card Lookup  godoc
+//
+//	@Summary		Gift card lookup
+//	@Description	Showing a gift card with its balance and every movement on it
+//	@Tags			Admin Order Management
+//	@Produce		json
+//	@Param			code	path		string	true	"gift card code"
+//	@Success		200		{object}	entity.GiftCardDetail
+//	@Router			/giftcardlookup/{code} [get]
+func (ah *AdminHandler) GiftCardLookup(c *gin.Context) {
+	detail, err := ah.GiftCardUsecase.ExecuteLookup(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"GiftCard": detail})
+}
+
+// Audit Log  godoc
+//
+//	@Summary		Audit log
+//	@Description	Searching the log of admin changes, newest first. Dates are YYYY-MM-DD and include both ends
+//	@Tags			Admin User Management
+//	@Produce		json
+//	@Param			adminid		query		string	false	"Admin ID"
+//	@Param			entity		query		string	false	"user/admin/ticket/apparel/coupon/offer/order/return/..."
+//	@Param			entityid	query		string	false	"Entity ID"
+//	@Param			action		query		string	false	"Action such as order.refund"
+//	@Param			from		query		string	false	"From date"
+//	@Param			to			query		string	false	"To date"
+//	@Param			page		query		string	false	"page no"
+//	@Param			limit		query		string	false	"limit no"
+//	@Success		200			{object}	entity.AuditLog
+//	@Router			/auditlog [get]
+func (ah *AdminHandler) AuditLog(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+	filter := entity.AuditFilter{Entity: c.Query("entity"), Action: c.Query("action")}
+	if adminId := c.Query("adminid"); adminId != "" {
+		filter.AdminId, err = strconv.Atoi(adminId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+			return
+		}
+	}
+	if entityId := c.Query("entityid"); entityId != "" {
+		filter.EntityId, err = strconv.Atoi(entityId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+			return
+		}
+	}
+	auditLog, err := ah.AuditUsecase.ExecuteSearch(filter, c.Query("from"), c.Query("to"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"AuditLog": auditLog})
+}
@@ -1,33 +1,39 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 	"zog/domain/entity"
+	cartusecase "zog/usecase/cart"
 	usecase "zog/usecase/order"
+	waitlistusecase "zog/usecase/waitlist"
 
 	"github.com/gin-gonic/gin"
 )
 
 type OrderHandler struct {
-	OrderUsecase *usecase.OrderUsecase
+	OrderUsecase    *usecase.OrderUsecase
+	WaitlistUsecase *waitlistusecase.WaitlistUsecase
+	CartUsecase     *cartusecase.CartUsecase
 }
 
-func NewOrderHandler(OrderUsecase *usecase.OrderUsecase) *OrderHandler {
-	return &OrderHandler{OrderUsecase}
+func NewOrderHandler(OrderUsecase *usecase.OrderUsecase, WaitlistUsecase *waitlistusecase.WaitlistUsecase, CartUsecase *cartusecase.CartUsecase) *OrderHandler {
+	return &OrderHandler{OrderUsecase, WaitlistUsecase, CartUsecase}
 }
 
 // Place Order   godoc
 //
 //	@Summary		Place Order
-//	@Description	Placing order from user side with respect to the payment method
+//	@Description	Placing order from user side with respect to the payment method: cod, paypal, razorpay, wallet, or split to pay the wallet balance first and the rest with razorpay
 //	@Tags			User Order
 //	@Accept			json
 //	@Produce		json
-//	@Param			addressid	path		string	true	"address id"
-//	@Param			payment		path		string	true	"payment method"
-//	@Success		200			{string}	string	"Success message"
+//	@Param			addressid	path		string				true	"address id"
+//	@Param			payment		path		string				true	"payment method"
+//	@Success		200			{string}	string				"Success message"
+//	@Failure		409			{object}	entity.CartReview	"Cart changed since it was filled"
 //	@Router			/placeorder/{addressid}/{payment} [post]
 func (oh *OrderHandler) PlaceOrder(c *gin.Context) {
 	userID, _ := c.Get("userID")
@@ -39,6 +45,15 @@ func (oh *OrderHandler) PlaceOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
 		return
 	}
+	review, err := oh.CartUsecase.ExecuteCheckoutCart(userId)
+	if errors.Is(err, cartusecase.ErrCartChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "Cart": review.Cart, "Changes": review.Changes})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if paymentMethod == "cod" {
 		invoice, err1 := oh.OrderUsecase.ExecutePurchaseCod(userId, addressId)
 		if err1 != nil {
@@ -67,14 +82,25 @@ func (oh *OrderHandler) PlaceOrder(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"massage": "Complete your transaction with razorpay", "Link": "http://127.0.0.1:5500/app.html", "RazorId": razorId, "OrderId": orderId})
 		}
 
+	} else if paymentMethod == "split" {
+		razorId, orderId, err := oh.OrderUsecase.ExecutePurchaseSplit(userId, addressId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"massage": "Wallet balance held - complete the rest of your transaction with razorpay", "Link": "http://127.0.0.1:5500/app.html", "RazorId": razorId, "OrderId": orderId})
+
 	} else if paymentMethod == "wallet" {
 		invoice, err := oh.OrderUsecase.ExecutePurchaseWallet(userId, addressId)
+		if errors.Is(err, usecase.ErrGiftCardsFailed) {
+			c.JSON(http.StatusOK, gin.H{"massage": "Order placed successfully", "Invoice": invoice, "giftcard": err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
-		} else {
-			c.JSON(http.StatusOK, gin.H{"massage": "Order placed successfully", "Invoice": invoice})
 		}
+		c.JSON(http.StatusOK, gin.H{"massage": "Order placed successfully", "Invoice": invoice})
 	}
 
 }
@@ -92,22 +118,29 @@ func (oh *OrderHandler) PlaceOrder(c *gin.Context) {
 //	@Param			payid	path		string	true	"Razor Payment Id"
 //	@Router			/paymentverification/{sign}/{razorid}/{payid} [post]
 func (oh *OrderHandler) PaymentVerification(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
 	Signature := c.Param("sign")
 	razorId := c.Param("razorid")
 	paymentId := c.Param("payid")
-	invoice, err := oh.OrderUsecase.ExecuteRazorPaymentVerification(Signature, razorId, paymentId)
+	invoice, err := oh.OrderUsecase.ExecuteRazorPaymentVerification(Signature, razorId, paymentId, userId)
+	if errors.Is(err, usecase.ErrGiftCardsFailed) {
+		invoice.PaymentId = paymentId
+		c.JSON(http.StatusAccepted, gin.H{"massage": "Payment successful", "invoice": invoice, "giftcard": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	} else {
-		invoice.PaymentId = paymentId
-		c.JSON(http.StatusAccepted, gin.H{"massage": "Payment successful", "invoice": invoice})
+		return
 	}
+	invoice.PaymentId = paymentId
+	c.JSON(http.StatusAccepted, gin.H{"massage": "Payment successful", "invoice": invoice})
 }
 
 // Order Cancelation   godoc
 //
 //	@Summary		Order Cancelation
-//	@Description	canceling the order from user side and admin side
+//	@Description	canceling the user's own order
 //	@Tags			User Order
 //	@Accept			json
 //	@Produce		json
@@ -115,15 +148,22 @@ func (oh *OrderHandler) PaymentVerification(c *gin.Context) {
 //	@Success		200		{string}	string	"Success message"
 //	@Router			/cancelorder/{orderid} [put]
 func (oh *OrderHandler) CancelOrder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
 	strOrderId := c.Param("orderid")
 	orderId, err := strconv.Atoi(strOrderId)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
 		return
 	}
-	err1 := oh.OrderUsecase.ExecuteCancelOrder(orderId)
+	err1 := oh.OrderUsecase.ExecuteCancelOrder(userId, orderId)
 	if err1 != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Order not found"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
+		return
+	}
+	err = oh.WaitlistUsecase.ExecuteProcessWaitlist()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": "Order canceled", "waitlist": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": "Order canceled"})
@@ -165,7 +205,7 @@ func (oh *OrderHandler) OrderHistory(c *gin.Context) {
 // Order return godoc
 //
 //	@Summary		Return delivered order
-//	@Description	Returning the orders which are delivered to the user
+//	@Description	Returning the orders which are delivered to the user, within the return window of the loyalty settings
 //	@Tags			User Order
 //	@Accept			json
 //	@Produce		json
@@ -200,7 +240,7 @@ func (oh *OrderHandler) OrderReturn(c *gin.Context) {
 // Order Update  godoc
 //
 //	@Summary		Update order status
-//	@Description	Updating the order status by admin
+//	@Description	Updating the order status by admin. Orders move forward through pending, confirmed, shipped, delivered and completed, online orders only once paid. A delivered or completed order earns loyalty points, and a first such order pays out the user's referral, once per order
 //	@Tags			Admin Order Management
 //	@Accept			json
 //	@Produce		json
@@ -217,6 +257,10 @@ func (oh *OrderHandler) AdminOrderUpdate(c *gin.Context) {
 	}
 	strStatus := c.Param("status")
 	err1 := oh.OrderUsecase.ExecuteOrderUpdate(orderId, strStatus)
+	if errors.Is(err1, usecase.ErrRewardsFailed) {
+		c.JSON(http.StatusOK, gin.H{"success": "Order Updated", "rewards": err1.Error()})
+		return
+	}
 	if err1 != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err1.Error()})
 		return
@@ -227,12 +271,12 @@ func (oh *OrderHandler) AdminOrderUpdate(c *gin.Context) {
 // Update Return    godoc
 //
 //	@Summary		Updating return status and refund
-//	@Description	Updating the retunr status by admin and implimenting refund
+//	@Description	Approving or rejecting a return that has not been decided yet. Rejecting puts the order back as it was. Approving a return takes back the loyalty points and referral reward the order earned and gives back points spent on it
 //	@Tags			Admin Order Management
 //	@Accept			json
 //	@Produce		json
 //	@Param			returnid	path		string	true	"Return Id"
-//	@Param			status		path		string	true	"status"
+//	@Param			status		path		string	true	"approved or rejected"
 //	@Param			refund		path		string	true	"refund method -wallet -account"
 //	@Success		200			{string}	string	"updated succesfuly"
 //	@Router			/updatereturn/{returnid}/{status}/{refund} [post]
@@ -250,14 +294,16 @@ func (oh *OrderHandler) AdminReturnUpdate(c *gin.Context) {
 		err = oh.OrderUsecase.ExecuteReturnUpdate(status, returnId)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
+		c.JSON(http.StatusOK, gin.H{"success": "Return " + status})
 	}
 }
 
 // Aproving Refund godoc
 //
 //	@Summary		Aproving refund by admin
-//	@Description	Transfering the total amount of order to wallet or other methods
+//	@Description	Refunding an approved return once, or cancelling an order that has not shipped and refunding what was paid. A refund that failed can be retried; one that went through cannot be paid again
 //	@Tags			Admin Order Management
 //	@Accept			json
 //	@Produce		json
@@ -1,15 +1,24 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	middlewares "zog/delivery/middlewares"
 	"zog/delivery/models"
 	_ "zog/docs"
 	"zog/domain/entity"
 	cartusecase "zog/usecase/cart"
+	emailusecase "zog/usecase/email"
+	giftcardusecase "zog/usecase/giftcard"
+	loyaltyusecase "zog/usecase/loyalty"
 	productusecase "zog/usecase/product"
+	referralusecase "zog/usecase/referral"
+	seatusecase "zog/usecase/seat"
+	transferusecase "zog/usecase/transfer"
 	usecase "zog/usecase/user"
+	waitlistusecase "zog/usecase/waitlist"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
@@ -17,19 +26,28 @@ import (
 )
 
 type UserHandler struct {
-	UserUsecase    *usecase.UserUsecase
-	ProductUsecase *productusecase.ProductUsecase
-	CartUsecase    *cartusecase.CartUsecase
+	UserUsecase     *usecase.UserUsecase
+	ProductUsecase  *productusecase.ProductUsecase
+	CartUsecase     *cartusecase.CartUsecase
+	WaitlistUsecase *waitlistusecase.WaitlistUsecase
+	TransferUsecase *transferusecase.TransferUsecase
+	SeatUsecase     *seatusecase.SeatUsecase
+	ReferralUsecase *referralusecase.ReferralUsecase
+	LoyaltyUsecase  *loyaltyusecase.LoyaltyUsecase
+	GiftCardUsecase *giftcardusecase.GiftCardUsecase
+	EmailUsecase    *emailusecase.EmailUsecase
+	Auth            *middlewares.Auth
+	RateLimit       *middlewares.RateLimit
 }
 
-func NewUserHandler(UserUsecase *usecase.UserUsecase, ProductUsecase *productusecase.ProductUsecase, CartUsecase *cartusecase.CartUsecase) *UserHandler {
-	return &UserHandler{UserUsecase, ProductUsecase, CartUsecase}
+func NewUserHandler(UserUsecase *usecase.UserUsecase, ProductUsecase *productusecase.ProductUsecase, CartUsecase *cartusecase.CartUsecase, WaitlistUsecase *waitlistusecase.WaitlistUsecase, TransferUsecase *transferusecase.TransferUsecase, SeatUsecase *seatusecase.SeatUsecase, ReferralUsecase *referralusecase.ReferralUsecase, LoyaltyUsecase *loyaltyusecase.LoyaltyUsecase, GiftCardUsecase *giftcardusecase.GiftCardUsecase, EmailUsecase *emailusecase.EmailUsecase, Auth *middlewares.Auth, RateLimit *middlewares.RateLimit) *UserHandler {
+	return &UserHandler{UserUsecase, ProductUsecase, CartUsecase, WaitlistUsecase, TransferUsecase, SeatUsecase, ReferralUsecase, LoyaltyUsecase, GiftCardUsecase, EmailUsecase, Auth, RateLimit}
 }
 
 // UserSignup  godoc
 //
 //	@Summary		signup
-//	@Description	Adding new user to the database
+//	@Description	Adding new user to the database, optionally with the referral code of the user who invited them
 //	@Tags			User Authentication
 //	@Accept			json
 //	@Produce		json
@@ -44,11 +62,12 @@ func (uh *UserHandler) Signup(c *gin.Context) {
 	}
 	var user entity.User
 	copier.Copy(&user, &userInput)
-	newUser, err := uh.UserUsecase.ExecuteSignup(user)
+	newUser, err := uh.UserUsecase.ExecuteSignup(user, userInput.ReferredBy)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	uh.sendVerification(newUser.ID)
 
 	c.JSON(http.StatusCreated, newUser)
 }
@@ -56,7 +75,7 @@ func (uh *UserHandler) Signup(c *gin.Context) {
 // UserSignup Otp  godoc
 //
 //	@Summary		signup with opt validation
-//	@Description	Adding new user to the database
+//	@Description	Adding new user to the database, optionally with the referral code of the user who invited them
 //	@Tags			User Authentication
 //	@Accept			json
 //	@Produce		json
@@ -69,6 +88,9 @@ func (uh *UserHandler) SignupWithOtp(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !uh.RateLimit.OtpSend(c, user.Phone) {
+		return
+	}
 	key, err := uh.UserUsecase.ExecuteSignupWithOtp(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -92,11 +114,20 @@ func (uh *UserHandler) SignupWithOtp(c *gin.Context) {
 func (uh *UserHandler) SignupOtpValidation(c *gin.Context) {
 	key := c.PostForm("key")
 	otp := c.PostForm("otp")
-	err := uh.UserUsecase.ExecuteSignupOtpValidation(key, otp)
+	account := uh.otpAccount(key)
+	if uh.RateLimit.Locked(c, account) {
+		return
+	}
+	newUser, err := uh.UserUsecase.ExecuteSignupOtpValidation(key, otp)
 	if err != nil {
+		if uh.RateLimit.Failed(c, account) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	} else {
+		uh.RateLimit.Succeeded(account)
+		uh.sendVerification(newUser.ID)
 		c.JSON(http.StatusOK, gin.H{"massage": "user signup succesfull"})
 	}
 
@@ -118,13 +149,25 @@ func (uh *UserHandler) LoginWithPassword(c *gin.Context) {
 	phone := c.PostForm("phone")
 	password := c.PostForm("password")
 
+	account := "login:user:" + phone
+	if uh.RateLimit.Locked(c, account) {
+		return
+	}
 	userId, err := uh.UserUsecase.ExecuteLoginWithPassword(phone, password)
 	if err != nil {
+		if uh.RateLimit.Failed(c, account) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	} else {
-		middlewares.CreateJwtCookie(userId, phone, "user", c)
-		c.JSON(http.StatusOK, gin.H{"massage": "user loged in succesfully and cookie stored"})
+		uh.RateLimit.Succeeded(account)
+		tokens, err := uh.Auth.CreateJwtCookie(userId, phone, "user", c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"massage": "user loged in succesfully and cookie stored", "tokens": tokens})
 	}
 
 }
@@ -142,6 +185,9 @@ func (uh *UserHandler) LoginWithPassword(c *gin.Context) {
 func (uh *UserHandler) LoginWithOtp(c *gin.Context) {
 
 	phone := c.PostForm("phone")
+	if !uh.RateLimit.OtpSend(c, phone) {
+		return
+	}
 	key, err := uh.UserUsecase.ExecuteLogin(phone)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
@@ -172,18 +218,35 @@ func (uh *UserHandler) LoginOtpValidation(c *gin.Context) {
 	resend := c.PostForm("resend")
 
 	if resend == "resend" {
+		if !uh.RateLimit.OtpSend(c, phone) {
+			return
+		}
 		key, err := uh.UserUsecase.ExecuteLogin(phone)
-		if err == nil {
-			c.JSON(http.StatusOK, gin.H{"massage": "otp resend successful", "Key": key})
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
 		}
+		c.JSON(http.StatusOK, gin.H{"massage": "otp resend successful", "Key": key})
 	} else {
+		account := uh.otpAccount(key)
+		if uh.RateLimit.Locked(c, account) {
+			return
+		}
 		user, err1 := uh.UserUsecase.ExecuteOtpValidation(key, otp)
 		if err1 != nil {
+			if uh.RateLimit.Failed(c, account) {
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err1.Error()})
 			return
 		}
-		middlewares.CreateJwtCookie(user.ID, user.Phone, "user", c)
-		c.JSON(http.StatusOK, gin.H{"massage": "user loged in succesfully and cookie stored"})
+		uh.RateLimit.Succeeded(account)
+		tokens, err := uh.Auth.CreateJwtCookie(user.ID, user.Phone, "user", c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"massage": "user loged in succesfully and cookie stored", "tokens": tokens})
 
 	}
 
@@ -270,11 +333,14 @@ func (uh *UserHandler) EditProfile(c *gin.Context) {
 	}
 	var user entity.User
 	copier.Copy(&user, &userInput)
-	err1 := uh.UserUsecase.ExecuteEditProfile(user, userId)
+	emailChanged, err1 := uh.UserUsecase.ExecuteEditProfile(user, userId)
 	if err1 != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
 		return
 	}
+	if emailChanged {
+		uh.sendVerification(userId)
+	}
 	c.JSON(http.StatusOK, gin.H{"massage": "user details updated succesfully"})
 }
 
@@ -290,6 +356,9 @@ func (uh *UserHandler) EditProfile(c *gin.Context) {
 func (uh *UserHandler) ChangePassword(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	userId := userID.(int)
+	if !uh.RateLimit.OtpSend(c, "user:"+strconv.Itoa(userId)) {
+		return
+	}
 	key, err := uh.UserUsecase.ExecuteChangePassword(userId)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
@@ -308,6 +377,7 @@ func (uh *UserHandler) ChangePassword(c *gin.Context) {
 //	@Accept			multipart/form-data
 //	@Produce		json
 //	@Param			password	formData	string	true	"New Password"
+//	@Param			key			formData	string	true	"Key"
 //	@Param			otp			formData	string	true	"Otp"
 //	@Success		200			{string}	string	"Success message"
 //	@Router			/otpvalidationpassword [post]
@@ -315,16 +385,169 @@ func (uh *UserHandler) OtpValidationPassword(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	userId := userID.(int)
 	password := c.PostForm("password")
+	key := c.PostForm("key")
 	otp := c.PostForm("otp")
-	err := uh.UserUsecase.ExecuteOtpValidationPassword(password, otp, userId)
+	account := "otp:user:" + strconv.Itoa(userId)
+	if uh.RateLimit.Locked(c, account) {
+		return
+	}
+	err := uh.UserUsecase.ExecuteOtpValidationPassword(password, key, otp, userId)
 	if err != nil {
+		if uh.RateLimit.Failed(c, account) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
+	uh.RateLimit.Succeeded(account)
 	c.JSON(http.StatusOK, gin.H{"massage": "password changed succesfuly"})
 
 }
 
+// otpAccount names the account failed otp checks are counted against: the
+// phone the key was sent to, so asking for a new key does not reset the
+// count.
+func (uh *UserHandler) otpAccount(key string) string {
+	phone, err := uh.UserUsecase.ExecuteOtpPhone(key)
+	if err != nil || phone == "" {
+		return "otp:key:" + key
+	}
+	return "otp:phone:" + phone
+}
+
+// sendVerification mails a new user, or one who changed their email, the
+// link to verify it. A failed mail does not fail the request; the user can
+// ask for another with /resendverification.
+func (uh *UserHandler) sendVerification(userId int) {
+	err := uh.EmailUsecase.ExecuteSendVerification(userId)
+	if err != nil {
+		log.Println("verification mail for user", userId, err)
+	}
+}
+
+// UserLogin Email  godoc
+//
+//	@Summary		Login with email
+//	@Description	Login for user with a verified email address and password
+//	@Tags			User Authentication
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			email		formData	string	true	"Email"
+//	@Param			password	formData	string	true	"Password"
+//	@Success		200			{object}	entity.AuthTokens
+//	@Router			/loginwithemail [post]
+func (uh *UserHandler) LoginWithEmail(c *gin.Context) {
+	email := c.PostForm("email")
+	password := c.PostForm("password")
+	account := "login:email:" + strings.ToLower(strings.TrimSpace(email))
+	if uh.RateLimit.Locked(c, account) {
+		return
+	}
+	user, err := uh.UserUsecase.ExecuteLoginWithEmail(email, password)
+	if err != nil {
+		if uh.RateLimit.Failed(c, account) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uh.RateLimit.Succeeded(account)
+	tokens, err := uh.Auth.CreateJwtCookie(user.ID, user.Phone, "user", c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"massage": "user loged in succesfully and cookie stored", "tokens": tokens})
+}
+
+// Password Reset Request  godoc
+//
+//	@Summary		Request password reset
+//	@Description	Mailing a password reset link to the account with this verified email. The answer is the same whether or not there is one
+//	@Tags			User Authentication
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			email	formData	string	true	"Email"
+//	@Success		200		{string}	string	"Success message"
+//	@Router			/requestpasswordreset [post]
+func (uh *UserHandler) RequestPasswordReset(c *gin.Context) {
+	email := c.PostForm("email")
+	if !uh.RateLimit.OtpSend(c, "email:"+strings.ToLower(strings.TrimSpace(email))) {
+		return
+	}
+	err := uh.EmailUsecase.ExecuteRequestReset(email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"massage": "if an account has this verified email, a reset link has been sent to it"})
+}
+
+// Reset Password  godoc
+//
+//	@Summary		Reset password
+//	@Description	Setting a new password with the token from a reset link. Every session of the user is ended
+//	@Tags			User Authentication
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			token		formData	string	true	"Token from the reset link"
+//	@Param			password	formData	string	true	"New Password"
+//	@Success		200			{string}	string	"Success message"
+//	@Router			/resetpassword [post]
+func (uh *UserHandler) ResetPassword(c *gin.Context) {
+	userId, err := uh.EmailUsecase.ExecuteResetPassword(c.PostForm("token"), c.PostForm("password"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = uh.Auth.LogoutEverywhere(userId, "user")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "password changed but ending your sessions failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"massage": "password changed succesfuly, please login again"})
+}
+
+// Verify Email  godoc
+//
+//	@Summary		Verify email
+//	@Description	Verifying the user's email address with the token from the link mailed to it
+//	@Tags			User Authentication
+//	@Produce		json
+//	@Param			token	query		string	true	"Token from the verification link"
+//	@Success		200		{string}	string	"Success message"
+//	@Router			/verifyemail [get]
+func (uh *UserHandler) VerifyEmail(c *gin.Context) {
+	err := uh.EmailUsecase.ExecuteVerify(c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"massage": "email verified succesfully"})
+}
+
+// Resend Verification  godoc
+//
+//	@Summary		Resend verification email
+//	@Description	Mailing the logged in user a new link to verify their email address
+//	@Tags			User Authentication
+//	@Produce		json
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/resendverification [post]
+func (uh *UserHandler) ResendVerification(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	if !uh.RateLimit.OtpSend(c, "email:user:"+strconv.Itoa(userId)) {
+		return
+	}
+	err := uh.EmailUsecase.ExecuteSendVerification(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"massage": "verification email sent"})
+}
+
 // Tickets       godoc
 //
 //	@Summary		Tickets List
@@ -557,6 +780,94 @@ func (uh *UserHandler) ApparelDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"Apparel": apparel})
 }
 
+// Seat Map  godoc
+//
+//	@Summary		Seat map
+//	@Description	Showing sections and seats of a reserved-seating event with their availability
+//	@Tags			User Shopping
+//	@Accept			json
+//	@Produce		json
+//	@Param			ticketid	path		string	true	"Ticket ID"
+//	@Success		200			{object}	[]entity.SectionAvailability
+//	@Router			/seatmap/{ticketid} [get]
+func (uh *UserHandler) SeatMap(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	ticketId, err := strconv.Atoi(c.Param("ticketid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	seatMap, err := uh.SeatUsecase.ExecuteSeatMap(ticketId, userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Sections": seatMap})
+}
+
+// Add Seat To Cart  godoc
+//
+//	@Summary		Add seat to cart
+//	@Description	Holding a seat for a short while and adding it to cart
+//	@Tags			User Shopping
+//	@Accept			json
+//	@Produce		json
+//	@Param			seatid	path		string	true	"Seat ID"
+//	@Success		200		{string}	string	"Success message"
+//	@Router			/addseattocart/{seatid} [post]
+func (uh *UserHandler) AddSeatToCart(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	seatId, err := strconv.Atoi(c.Param("seatid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = uh.SeatUsecase.ExecuteAddSeatToCart(userId, seatId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = uh.CartUsecase.ExecuteRefreshDiscount(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Seat held and added to cart"})
+}
+
+// Remove Seat  godoc
+//
+//	@Summary		Remove seat from cart
+//	@Description	Removing a held seat from cart and releasing it
+//	@Tags			User Shopping
+//	@Accept			json
+//	@Produce		json
+//	@Param			seatid	path		string	true	"Seat ID"
+//	@Success		200		{string}	string	"Success message"
+//	@Router			/removeseat/{seatid} [delete]
+func (uh *UserHandler) RemoveSeat(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	seatId, err := strconv.Atoi(c.Param("seatid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = uh.SeatUsecase.ExecuteRemoveSeatFromCart(userId, seatId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = uh.CartUsecase.ExecuteRefreshDiscount(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Seat removed from cart"})
+}
+
 // Add to cart  godoc
 //
 //	@Summary		Add product to cart
@@ -658,23 +969,23 @@ func (uh *UserHandler) IncreaseQuantity(c *gin.Context) {
 // Cart     godoc
 //
 //	@Summary		User Cart
-//	@Description	Showing user cart
+//	@Description	Showing user cart re-priced against the current catalogue, with the lines that changed and the offer applied. The stored cart is only updated at checkout
 //	@Tags			User Shopping
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	entity.Cart	"User Cart"
+//	@Success		200	{object}	entity.CartReview	"User Cart"
 //	@Router			/usercart [get]
 func (uh *UserHandler) Cart(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	userId := userID.(int)
 	var userCartResponse entity.Cart
-	userCart, err1 := uh.CartUsecase.ExecuteCart(userId)
+	review, err1 := uh.CartUsecase.ExecuteReviewCart(userId)
 	if err1 != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
 		return
 	}
-	copier.Copy(&userCartResponse, &userCart)
-	c.JSON(http.StatusOK, gin.H{"User Cart": userCartResponse})
+	copier.Copy(&userCartResponse, &review.Cart)
+	c.JSON(http.StatusOK, gin.H{"User Cart": userCartResponse, "Changes": review.Changes})
 }
 
 // Cart List    godoc
@@ -778,15 +1089,16 @@ func (u *UserHandler) ViewWishlist(c *gin.Context) {
 // Available Coupon  godoc
 //
 //	@Summary		checking coupon availability
-//	@Description	showing the available coupons and eligibility
+//	@Description	showing the coupons available to the user, including those for the user's segments
 //	@Tags			User Shopping
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	entity.Coupon	"Available coupons"
 //	@Router			/coupons [get]
 func (u *UserHandler) AvailableCoupons(c *gin.Context) {
-
-	couponList, err := u.ProductUsecase.ExecuteAvailableCoupons()
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	couponList, err := u.ProductUsecase.ExecuteAvailableCoupons(userId)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -818,6 +1130,26 @@ func (u *UserHandler) ApplyCoupon(c *gin.Context) {
 	}
 }
 
+// Remove Coupon  godoc
+//
+//	@Summary		Remove coupon
+//	@Description	Removing the coupon applied to user cart
+//	@Tags			User Shopping
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/removecoupon [delete]
+func (u *UserHandler) RemoveCoupon(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	err := u.CartUsecase.ExecuteRemoveCoupon(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon removed from cart"})
+}
+
 // Offer Check godoc
 //
 //	@Summary		checking offer availability
@@ -841,20 +1173,532 @@ func (u *UserHandler) OfferCheck(c *gin.Context) {
 	}
 }
 
+// Join Waitlist godoc
+//
+//	@Summary		Join waitlist
+//	@Description	Joining the waitlist of a sold out product, a time limited reservation is given when stock is back
+//	@Tags			User Shopping
+//	@Accept			json
+//	@Produce		json
+//	@Param			category	path		string	true	"Ticket/Apparel"
+//	@Param			productid	path		string	true	"Product ID"
+//	@Param			quantity	path		string	true	"Product Quantity"
+//	@Success		200			{string}	string	"Success message"
+//	@Router			/joinwaitlist/{category}/{productid}/{quantity} [post]
+func (u *UserHandler) JoinWaitlist(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	category := c.Param("category")
+	productId, err := strconv.Atoi(c.Param("productid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	quantity, err := strconv.Atoi(c.Param("quantity"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = u.WaitlistUsecase.ExecuteJoinWaitlist(userId, category, productId, quantity)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Added to waitlist, you will be notified when stock is back"})
+}
+
+// Leave Waitlist godoc
+//
+//	@Summary		Leave waitlist
+//	@Description	Leaving the waitlist of a product, an active reservation moves to the next user
+//	@Tags			User Shopping
+//	@Accept			json
+//	@Produce		json
+//	@Param			category	path		string	true	"Ticket/Apparel"
+//	@Param			productid	path		string	true	"Product ID"
+//	@Success		200			{string}	string	"Success message"
+//	@Router			/leavewaitlist/{category}/{productid} [delete]
+func (u *UserHandler) LeaveWaitlist(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	category := c.Param("category")
+	productId, err := strconv.Atoi(c.Param("productid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = u.WaitlistUsecase.ExecuteLeaveWaitlist(userId, category, productId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Removed from waitlist"})
+}
+
+// User Waitlist godoc
+//
+//	@Summary		User waitlist
+//	@Description	Showing the waitlists joined by the user with reservation status
+//	@Tags			User Shopping
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	entity.WaitlistEntry	"Waitlist"
+//	@Router			/userwaitlist [get]
+func (u *UserHandler) ViewWaitlist(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	waitlist, err := u.WaitlistUsecase.ExecuteUserWaitlist(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Waitlist": waitlist})
+}
+
+// My Tickets godoc
+//
+//	@Summary		Purchased tickets
+//	@Description	Showing the tickets owned by the user with their entry codes
+//	@Tags			User Order
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	entity.TicketPass	"Tickets"
+//	@Router			/mytickets [get]
+func (u *UserHandler) MyTickets(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	passes, err := u.TransferUsecase.ExecuteMyTickets(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Tickets": passes})
+}
+
+// Transfer Ticket godoc
+//
+//	@Summary		Transfer ticket
+//	@Description	Sending a purchased ticket to another registered user by phone or email
+//	@Tags			User Order
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			passid		path		string	true	"Ticket pass ID"
+//	@Param			recipient	formData	string	true	"Recipient phone or email"
+//	@Success		200			{object}	entity.TicketTransfer
+//	@Router			/transferticket/{passid} [post]
+func (u *UserHandler) TransferTicket(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	passId, err := strconv.Atoi(c.Param("passid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	recipient := c.PostForm("recipient")
+	transfer, err := u.TransferUsecase.ExecuteTransferTicket(userId, passId, recipient)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer sent, waiting for the recipient to accept", "Transfer": transfer})
+}
+
+// Accept Transfer godoc
+//
+//	@Summary		Accept ticket transfer
+//	@Description	Accepting a ticket sent by another user, a new entry code is issued
+//	@Tags			User Order
+//	@Accept			json
+//	@Produce		json
+//	@Param			transferid	path		string	true	"Transfer ID"
+//	@Success		200			{object}	entity.TicketPass
+//	@Router			/accepttransfer/{transferid} [post]
+func (u *UserHandler) AcceptTransfer(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	transferId, err := strconv.Atoi(c.Param("transferid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	pass, err := u.TransferUsecase.ExecuteAcceptTransfer(userId, transferId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Ticket received", "Ticket": pass})
+}
+
+// Reject Transfer godoc
+//
+//	@Summary		Reject or cancel ticket transfer
+//	@Description	Rejecting a transfer as the recipient or cancelling it as the sender
+//	@Tags			User Order
+//	@Accept			json
+//	@Produce		json
+//	@Param			transferid	path		string	true	"Transfer ID"
+//	@Success		200			{string}	string	"Success message"
+//	@Router			/rejecttransfer/{transferid} [post]
+func (u *UserHandler) RejectTransfer(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	transferId, err := strconv.Atoi(c.Param("transferid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = u.TransferUsecase.ExecuteRejectTransfer(userId, transferId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer closed"})
+}
+
+// Transfer History godoc
+//
+//	@Summary		Ticket transfer history
+//	@Description	Showing the tickets sent and received by the user
+//	@Tags			User Order
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	entity.TicketTransfer	"Transfers"
+//	@Router			/transferhistory [get]
+func (u *UserHandler) TransferHistory(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	transfers, err := u.TransferUsecase.ExecuteTransferHistory(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Transfers": transfers})
+}
+
+// Notifications godoc
+//
+//	@Summary		User notifications
+//	@Description	Showing the notifications sent to the user
+//	@Tags			User Authentication
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	entity.Notification	"Notifications"
+//	@Router			/notifications [get]
+func (u *UserHandler) Notifications(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	notifications, err := u.UserUsecase.ExecuteNotifications(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Notifications": notifications})
+}
+
 // LogOut     godoc
 //
 //	@Summary		logout
-//	@Description	Deleting cookie from the browser while logout
+//	@Description	Revoking the session's tokens and deleting the cookies from the browser while logout
 //	@Tags			User Authentication
-//	@Accept			json
+//	@Accept			multipart/form-data
 //	@Produce		json
-//	@Success		200	{string}	string	"Success message"
+//	@Param			refreshtoken	formData	string	false	"Refresh token when not sent as a cookie"
+//	@Success		200				{string}	string	"Success message"
 //	@Router			/logout [post]
 func (uh *UserHandler) Logout(c *gin.Context) {
-	err := middlewares.DeleteCookie(c)
+	err := uh.Auth.Logout(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user cookie deletion failed"})
 	} else {
 		c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
 	}
 }
+
+// LogOut Everywhere  godoc
+//
+//	@Summary		logout everywhere
+//	@Description	Ending every session of the user on every device
+//	@Tags			User Authentication
+//	@Produce		json
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/logouteverywhere [post]
+func (uh *UserHandler) LogoutEverywhere(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	err := uh.Auth.LogoutEverywhere(userID.(int), "user")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	middlewares.DeleteCookie(c)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out from all devices"})
+}
+
+// Refresh Session  godoc
+//
+//	@Summary		refresh session
+//	@Description	Exchanging the refresh token, from the cookie or the refreshtoken field, for new tokens
+//	@Tags			User Authentication
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			refreshtoken	formData	string	false	"Refresh token when not sent as a cookie"
+//	@Success		200				{object}	entity.AuthTokens
+//	@Router			/refresh [post]
+func (uh *UserHandler) Refresh(c *gin.Context) {
+	tokens, err := uh.Auth.Refresh("user", c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session refreshed", "tokens": tokens})
+}
+
+// Referrals  godoc
+//
+//	@Summary		Referral code and rewards
+//	@Description	Showing the user's referral code, the users they invited and the wallet credit earned
+//	@Tags			User Authentication
+//	@Produce		json
+//	@Success		200	{object}	entity.ReferralSummary
+//	@Router			/referral [get]
+func (u *UserHandler) Referrals(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	summary, err := u.ReferralUsecase.ExecuteMyReferrals(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Referral": summary})
+}
+
+// Loyalty Points  godoc
+//
+//	@Summary		Loyalty points balance
+//	@Description	Showing the points available to spend, those pending until the return window closes and the points history
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Success		200	{object}	entity.LoyaltyBalance
+//	@Router			/loyalty [get]
+func (u *UserHandler) LoyaltyPoints(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	balance, err := u.LoyaltyUsecase.ExecuteBalance(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Loyalty": balance})
+}
+
+// Apply Points  godoc
+//
+//	@Summary		Spending loyalty points
+//	@Description	Using loyalty points as a discount on the cart, fewer points are used when the balance or the redemption cap does not allow all of them
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Param			points	path		string	true	"points to spend"
+//	@Success		200		{string}	string	"discount"
+//	@Router			/applypoints/{points} [post]
+func (u *UserHandler) ApplyPoints(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	points, err := strconv.Atoi(c.Param("points"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	used, discount, err := u.CartUsecase.ExecuteApplyPoints(userId, points)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Points": used, "Discount": discount})
+}
+
+// Remove Points  godoc
+//
+//	@Summary		Remove loyalty points
+//	@Description	Removing the loyalty points applied to user cart
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/removepoints [delete]
+func (u *UserHandler) RemovePoints(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	err := u.CartUsecase.ExecuteRemovePoints(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "loyalty points removed"})
+}
+
+// Gift Card Options  godoc
+//
+//	@Summary		Gift card amounts
+//	@Description	Listing the fixed gift card amounts, the range allowed for a custom amount and how long a card stays valid
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Success		200	{object}	entity.GiftCardOptions
+//	@Router			/giftcardoptions [get]
+func (u *UserHandler) GiftCardOptions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"Options": u.GiftCardUsecase.ExecuteOptions()})
+}
+
+// Add Gift Card  godoc
+//
+//	@Summary		Buying a gift card
+//	@Description	Adding a gift card of a fixed or custom amount to the cart, optionally addressed to a recipient's email or phone. The code is generated once the order is paid, shown once on the invoice and emailed to the recipient
+//	@Tags			User Shopping
+//	@Accept			json
+//	@Produce		json
+//	@Param			giftcard	body		entity.GiftCardInput	true	"gift card"
+//	@Success		200			{object}	entity.GiftCard
+//	@Router			/addgiftcard [post]
+func (u *UserHandler) AddGiftCard(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	var input entity.GiftCardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	card, err := u.GiftCardUsecase.ExecuteAddToCart(userId, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = u.CartUsecase.ExecuteRefreshDiscount(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "gift card added to cart", "GiftCard": card})
+}
+
+// Remove Gift Card  godoc
+//
+//	@Summary		Removing a gift card from cart
+//	@Description	Removing a gift card the user has not paid for yet from the cart
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Param			id	path		string	true	"gift card id"
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/removegiftcard/{id} [delete]
+func (u *UserHandler) RemoveGiftCard(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	cardId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "str conversion failed"})
+		return
+	}
+	err = u.GiftCardUsecase.ExecuteRemoveFromCart(userId, cardId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = u.CartUsecase.ExecuteRefreshDiscount(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "gift card removed from cart"})
+}
+
+// My Gift Cards  godoc
+//
+//	@Summary		User gift cards
+//	@Description	Listing the gift cards the user bought and those sent to their email or phone, with the last four characters of their codes
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Success		200	{object}	[]entity.GiftCard
+//	@Router			/mygiftcards [get]
+func (u *UserHandler) MyGiftCards(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	cards, err := u.GiftCardUsecase.ExecuteMyGiftCards(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"GiftCards": cards})
+}
+
+// Gift Card Balance  godoc
+//
+//	@Summary		Gift card balance
+//	@Description	Checking the balance, status and expiry of a gift card by its code
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Param			code	path		string	true	"gift card code"
+//	@Success		200		{object}	entity.GiftCardBalance
+//	@Router			/giftcardbalance/{code} [get]
+func (u *UserHandler) GiftCardBalance(c *gin.Context) {
+	balance, err := u.GiftCardUsecase.ExecuteBalance(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"GiftCard": balance})
+}
+
+// Redeem Gift Card  godoc
+//
+//	@Summary		Redeeming a gift card to wallet
+//	@Description	Moving the whole balance of a gift card into the user's wallet
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Param			code	path		string	true	"gift card code"
+//	@Success		200		{string}	string	"amount added"
+//	@Router			/redeemgiftcard/{code} [post]
+func (u *UserHandler) RedeemGiftCard(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	amount, err := u.GiftCardUsecase.ExecuteRedeem(userId, c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "gift card redeemed to wallet", "Amount": amount})
+}
+
+// Apply Gift Card  godoc
+//
+//	@Summary		Paying with a gift card
+//	@Description	Using a gift card to pay for part or all of the cart at checkout, the rest is paid with the chosen payment method
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Param			code	path		string	true	"gift card code"
+//	@Success		200		{string}	string	"amount covered"
+//	@Router			/applygiftcard/{code} [post]
+func (u *UserHandler) ApplyGiftCard(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	covered, err := u.GiftCardUsecase.ExecuteApplyGiftCard(userId, c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "gift card applied", "Covered": covered})
+}
+
+// Remove Gift Card Payment  godoc
+//
+//	@Summary		Removing gift card payment
+//	@Description	Removing the gift card applied to pay for the user cart
+//	@Tags			User Shopping
+//	@Produce		json
+//	@Success		200	{string}	string	"Success message"
+//	@Router			/removegiftcardpayment [delete]
+func (u *UserHandler) RemoveGiftCardPayment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userId := userID.(int)
+	err := u.GiftCardUsecase.ExecuteRemoveGiftCardPayment(userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": "gift card payment removed"})
+}
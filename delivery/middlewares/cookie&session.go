@@ -3,130 +3,268 @@ package delivery
 import (
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
+	"strings"
+	"time"
+	"zog/config"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	adminusecase "zog/usecase/admin"
+	"zog/usecase/session"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
-	"github.com/joho/godotenv"
 )
 
-var JWT_KEY string
+const (
+	accessCookie  = "Authorise"
+	refreshCookie = "Refresh"
+)
 
-func init() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-	JWT_KEY = os.Getenv("KEY4")
+// Auth issues and checks login tokens. The access token is a short lived JWT
+// signed with the configured key; the refresh token is an opaque token kept
+// by the session usecase and replaced on every refresh. Both are set as
+// cookies and returned to the client, which may send the access token as an
+// Authorization: Bearer header instead of the cookie.
+type Auth struct {
+	key        []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	sessions   *session.SessionUsecase
+	admins     *adminusecase.AdminUsecase
+}
 
+func NewAuth(cfg config.JWT, sessions *session.SessionUsecase, admins *adminusecase.AdminUsecase) *Auth {
+	return &Auth{key: []byte(cfg.Key), accessTTL: cfg.AccessTTL, refreshTTL: cfg.RefreshTTL, sessions: sessions, admins: admins}
 }
 
-func UserRetriveCookie(c *gin.Context) {
+// claims is what an access token says about its holder.
+type claims struct {
+	userId    int
+	phone     string
+	role      string
+	jti       string
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+// UserRetriveCookie lets the request through only with a valid user access
+// token, from the Authorization header or the cookie.
+func (a *Auth) UserRetriveCookie(c *gin.Context) {
+	a.authorise("user", c)
+}
 
-	valid := ValidateCookie(c)
-	if valid == false {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not logged in"})
-		c.Abort()
-	} else {
-		userId, Phone, role, err := RetriveJwtToken(c)
+// AdminRetriveCookie is UserRetriveCookie for admin tokens.
+func (a *Auth) AdminRetriveCookie(c *gin.Context) {
+	a.authorise("admin", c)
+}
+
+// Permission lets an admin through only if they are active and their role
+// has the permission. It goes after AdminRetriveCookie; the admin is read
+// on every request so role changes and deactivation apply at once.
+func (a *Auth) Permission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, _ := c.Get("userID")
+		adminId, ok := adminID.(int)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not logged in"})
+			return
+		}
+		admin, err := a.admins.ExecuteAdminAccess(adminId)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "cookie retriving failed"})
-			c.Abort()
-		} else {
-			c.Set("userID", userId)
-			c.Set("phoneNumber", Phone)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
 		}
-		if role != "user" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "role mismatching"})
-			c.Abort()
+		if !admin.Active {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin account is deactivated"})
+			return
 		}
+		if !utils.RoleHas(admin.Role, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+			return
+		}
+		c.Set("adminRole", admin.Role)
+		c.Next()
 	}
+}
+
+func (a *Auth) authorise(role string, c *gin.Context) {
+	if accessToken(c) == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not logged in"})
+		return
+	}
+	userId, phone, tokenRole, err := a.RetriveJwtToken(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "cookie retriving failed"})
+		return
+	}
+	if tokenRole != role {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "role mismatching"})
+		return
+	}
+	c.Set("userID", userId)
+	c.Set("phoneNumber", phone)
 	c.Next()
 }
-func AdminRetriveCookie(c *gin.Context) {
 
-	valid := ValidateCookie(c)
-	if valid == false {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not logged in"})
-		c.Abort()
-	} else {
-		userId, Phone, role, err := RetriveJwtToken(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "cookie retriving failed"})
-			c.Abort()
-		} else {
-			c.Set("userID", userId)
-			c.Set("phoneNumber", Phone)
-		}
-		if role != "admin" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "role mismatching"})
-			c.Abort()
-		} else {
-			c.Next()
+// accessToken returns the bearer token from the Authorization header, or the
+// access cookie when there is no header. A header that is not a bearer token
+// yields no token.
+func accessToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return ""
 		}
+		return strings.TrimSpace(token)
 	}
+	cookie, _ := c.Cookie(accessCookie)
+	return cookie
+}
 
+// refreshToken returns the refresh token from the refreshtoken form field, or
+// the refresh cookie.
+func refreshToken(c *gin.Context) string {
+	if token := c.PostForm("refreshtoken"); token != "" {
+		return token
+	}
+	cookie, _ := c.Cookie(refreshCookie)
+	return cookie
 }
 
-func CreateJwtCookie(userId int, userPhone string, role string, c *gin.Context) {
+// CreateJwtCookie starts a new session: it sets an access cookie and a
+// refresh cookie for a fresh refresh token family, and returns the same
+// tokens for clients that send them as bearer tokens instead.
+func (a *Auth) CreateJwtCookie(userId int, userPhone string, role string, c *gin.Context) (*entity.AuthTokens, error) {
+	refresh, err := a.sessions.ExecuteIssueRefresh(userId, role, userPhone)
+	if err != nil {
+		return nil, err
+	}
+	return a.setCookies(userId, userPhone, role, refresh, c)
+}
 
+func (a *Auth) setCookies(userId int, userPhone, role, refresh string, c *gin.Context) (*entity.AuthTokens, error) {
+	jti, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"userid": userId,
 		"phone":  userPhone,
 		"role":   role,
+		"jti":    jti,
+		"iat":    now.Unix(),
+		"exp":    now.Add(a.accessTTL).Unix(),
 	})
-	tokenString, err := token.SignedString([]byte("qwertyacid12345acidqwerty"))
-
-	if err == nil {
-		fmt.Println("token created")
+	tokenString, err := token.SignedString(a.key)
+	if err != nil {
+		return nil, errors.New("Token signing failed")
 	}
 	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("Authorise", tokenString, 3600, "", "", false, true)
+	c.SetCookie(accessCookie, tokenString, int(a.accessTTL.Seconds()), "", "", false, true)
+	c.SetCookie(refreshCookie, refresh, int(a.refreshTTL.Seconds()), "", "", false, true)
+	return &entity.AuthTokens{
+		AccessToken:  tokenString,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.accessTTL.Seconds()),
+	}, nil
 }
 
-func ValidateCookie(c *gin.Context) bool {
-	cookie, _ := c.Cookie("Authorise")
-	if cookie == "" {
-		fmt.Println("cookie not found")
-		return false
-	} else {
-		return true
+// Refresh exchanges a refresh token for new tokens, setting them as cookies
+// too. The refresh token must belong to the given role.
+func (a *Auth) Refresh(role string, c *gin.Context) (*entity.AuthTokens, error) {
+	current, next, err := a.sessions.ExecuteRotate(refreshToken(c), role)
+	if err != nil {
+		return nil, err
 	}
+	return a.setCookies(current.SubjectId, current.Phone, current.Role, next, c)
+}
 
+// Logout revokes the tokens the request carries and clears the cookies. A
+// missing or expired access token is not an error.
+func (a *Auth) Logout(c *gin.Context) error {
+	var jti, role string
+	var userId int
+	var expiresAt time.Time
+	if access := accessToken(c); access != "" {
+		if token, err := a.parse(access); err == nil {
+			jti, userId, role, expiresAt = token.jti, token.userId, token.role, token.expiresAt
+		}
+	}
+	err := a.sessions.ExecuteLogout(jti, userId, role, expiresAt, refreshToken(c))
+	if err != nil {
+		return err
+	}
+	return DeleteCookie(c)
 }
 
-func RetriveJwtToken(c *gin.Context) (int, string, string, error) {
-	cookie, _ := c.Cookie("Authorise")
-	if cookie == "" {
-		return 0, "", "", errors.New("cookie not found")
-	} else {
-		token, err := jwt.Parse(cookie, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte("qwertyacid12345acidqwerty"), nil
-		})
+// LogoutEverywhere ends every session of the user or admin, on every device.
+func (a *Auth) LogoutEverywhere(userId int, role string) error {
+	return a.sessions.ExecuteLogoutEverywhere(userId, role)
+}
 
-		if err != nil {
-			return 0, "", "", err
-		}
+// RetriveJwtToken reads the access token and returns the user ID, phone and
+// role it was issued for. Expired and revoked tokens are refused.
+func (a *Auth) RetriveJwtToken(c *gin.Context) (int, string, string, error) {
+	access := accessToken(c)
+	if access == "" {
+		return 0, "", "", errors.New("token not found")
+	}
+	token, err := a.parse(access)
+	if err != nil {
+		return 0, "", "", err
+	}
+	revoked, err := a.sessions.ExecuteIsRevoked(token.jti, token.userId, token.role, token.issuedAt)
+	if err != nil {
+		return 0, "", "", err
+	}
+	if revoked {
+		return 0, "", "", errors.New("token revoked")
+	}
+	return token.userId, token.phone, token.role, nil
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userId := claims["userid"].(float64)
-			userPhone := claims["phone"].(string)
-			role := claims["role"].(string)
-			return int(userId), userPhone, role, nil
-		} else {
-			return 0, "", "", fmt.Errorf("invalid token")
+// parse checks the signature and expiry of an access token and reads its
+// claims. Tokens without an expiry or a jti are refused.
+func (a *Auth) parse(tokenString string) (*claims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-
+		return a.key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	userId, ok1 := mapClaims["userid"].(float64)
+	phone, ok2 := mapClaims["phone"].(string)
+	role, ok3 := mapClaims["role"].(string)
+	jti, ok4 := mapClaims["jti"].(string)
+	iat, ok5 := mapClaims["iat"].(float64)
+	exp, ok6 := mapClaims["exp"].(float64)
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || !ok6 || jti == "" {
+		return nil, fmt.Errorf("invalid token")
 	}
+	return &claims{
+		userId:    int(userId),
+		phone:     phone,
+		role:      role,
+		jti:       jti,
+		issuedAt:  time.Unix(int64(iat), 0),
+		expiresAt: time.Unix(int64(exp), 0),
+	}, nil
 }
 
 func DeleteCookie(c *gin.Context) error {
-	c.SetCookie("Authorise", "", 0, "", "", true, true)
+	c.SetCookie(accessCookie, "", -1, "", "", true, true)
+	c.SetCookie(refreshCookie, "", -1, "", "", true, true)
 	fmt.Println("cookie deleted")
 	return nil
 }
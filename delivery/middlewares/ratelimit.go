@@ -0,0 +1,64 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	ratelimitusecase "zog/usecase/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit throttles the login and OTP endpoints. PerIP goes on the routes;
+// the other methods are called by handlers once they know the phone or
+// account, and answer 429 with Retry-After when a limit is hit.
+type RateLimit struct {
+	limiter *ratelimitusecase.RateLimitUsecase
+}
+
+func NewRateLimit(limiter *ratelimitusecase.RateLimitUsecase) *RateLimit {
+	return &RateLimit{limiter: limiter}
+}
+
+// PerIP limits how often one client IP may call the routes in scope.
+func (rl *RateLimit) PerIP(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rl.tooMany(c, rl.limiter.ExecuteAllowIP(scope, c.ClientIP())) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// OtpSend reports whether an OTP may be sent to recipient, answering 429 if
+// not.
+func (rl *RateLimit) OtpSend(c *gin.Context, recipient string) bool {
+	return !rl.tooMany(c, rl.limiter.ExecuteOtpSend(recipient))
+}
+
+// Locked reports whether account is locked out, answering 429 if so.
+func (rl *RateLimit) Locked(c *gin.Context, account string) bool {
+	return rl.tooMany(c, rl.limiter.ExecuteCheckLock(account))
+}
+
+// Failed records a failed attempt on account. It reports whether that locked
+// the account, in which case it has answered 429 and the handler must not
+// write its own error.
+func (rl *RateLimit) Failed(c *gin.Context, account string) bool {
+	return rl.tooMany(c, rl.limiter.ExecuteFailed(account))
+}
+
+// Succeeded clears the failed attempts of account.
+func (rl *RateLimit) Succeeded(account string) {
+	rl.limiter.ExecuteSucceeded(account)
+}
+
+func (rl *RateLimit) tooMany(c *gin.Context, err error) bool {
+	var limitErr *ratelimitusecase.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(limitErr.Seconds()))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": limitErr.Error(), "retry_after": limitErr.Seconds()})
+	return true
+}
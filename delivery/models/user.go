@@ -12,4 +12,6 @@ type Signup struct {
 	Email     string `json:"email"`
 	Phone     string `json:"phone"`
 	Password  string `json:"password"`
+	// ReferredBy is the referral code of the user who invited them, if any.
+	ReferredBy string `json:"referredby"`
 }
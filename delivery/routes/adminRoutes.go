@@ -2,34 +2,89 @@ package routes
 
 import (
 	"zog/delivery/handlers"
-	m "zog/delivery/middlewares"
+	middlewares "zog/delivery/middlewares"
+	"zog/domain/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AdminRouter(r *gin.Engine, adminHandler *handlers.AdminHandler) *gin.Engine {
+func AdminRouter(r *gin.Engine, adminHandler *handlers.AdminHandler, m *middlewares.Auth, a *middlewares.Audit, rl *middlewares.RateLimit) *gin.Engine {
 
-	r.POST("/registeradmin", m.AdminRetriveCookie, adminHandler.RegisterAdmin)
-	r.POST("/adminloginpassword", adminHandler.AdminLoginWithPassword)
-	r.POST("/adminlogin", adminHandler.Login)
-	r.POST("/adminotpvalidation", adminHandler.LoginOtpValidation)
+	r.POST("/registeradmin", m.AdminRetriveCookie, m.Permission(utils.PermAdmins), a.Record("admin.create", ""), adminHandler.RegisterAdmin)
+	r.POST("/adminloginpassword", rl.PerIP("login"), adminHandler.AdminLoginWithPassword)
+	r.POST("/adminlogin", rl.PerIP("otp"), adminHandler.Login)
+	r.POST("/adminotpvalidation", rl.PerIP("otp"), adminHandler.LoginOtpValidation)
 	r.GET("/adminhome", m.AdminRetriveCookie, adminHandler.Home)
+	r.POST("/adminrefresh", adminHandler.Refresh)
+	r.POST("/adminlogout", adminHandler.Logout)
+	r.POST("/adminlogouteverywhere", m.AdminRetriveCookie, adminHandler.LogoutEverywhere)
+	r.POST("/admintotpverify", rl.PerIP("login"), adminHandler.TotpVerify)
+	r.GET("/admintotpchallengeqr", rl.PerIP("login"), adminHandler.TotpQR)
+	r.POST("/admintotpenrol", m.AdminRetriveCookie, a.RecordSelf("admin.totpenrol"), adminHandler.TotpEnrol)
+	r.GET("/admintotpqr", m.AdminRetriveCookie, adminHandler.TotpQR)
+	r.POST("/admintotpconfirm", rl.PerIP("login"), m.AdminRetriveCookie, a.RecordSelf("admin.totpconfirm"), adminHandler.TotpConfirm)
+	r.POST("/admintotprecoverycodes", rl.PerIP("login"), m.AdminRetriveCookie, a.RecordSelf("admin.totprecoverycodes"), adminHandler.TotpRecoveryCodes)
+	r.GET("/admintotprecoverycodes", m.AdminRetriveCookie, adminHandler.TotpRecoveryCodesLeft)
+	r.POST("/admintotpdisable", rl.PerIP("login"), m.AdminRetriveCookie, a.RecordSelf("admin.totpdisable"), adminHandler.TotpDisable)
+	r.PATCH("/resettotp/:id", m.AdminRetriveCookie, m.Permission(utils.PermAdmins), a.Record("admin.resettotp", "id"), adminHandler.ResetTotp)
+	r.GET("/adminlist", m.AdminRetriveCookie, m.Permission(utils.PermAdmins), adminHandler.AdminList)
+	r.GET("/adminroles", m.AdminRetriveCookie, m.Permission(utils.PermAdmins), adminHandler.AdminRoles)
+	r.PUT("/assignrole/:id/:role", m.AdminRetriveCookie, m.Permission(utils.PermAdmins), a.Record("admin.assignrole", "id"), adminHandler.AssignRole)
+	r.GET("/auditlog", m.AdminRetriveCookie, m.Permission(utils.PermAudit), adminHandler.AuditLog)
+	r.PATCH("/toggleadmin/:id", m.AdminRetriveCookie, m.Permission(utils.PermAdmins), a.Record("admin.toggle", "id"), adminHandler.ToggleAdmin)
 
-	r.GET("/usermanagement", m.AdminRetriveCookie, adminHandler.UserList)
-	r.GET("/sortuser", m.AdminRetriveCookie, adminHandler.SortUserByPermission)
-	r.GET("/searchuser", m.AdminRetriveCookie, adminHandler.SearchUser)
-	r.POST("/userpermission/:id", m.AdminRetriveCookie, adminHandler.TogglePermission)
+	r.GET("/usermanagement", m.AdminRetriveCookie, m.Permission(utils.PermUsersRead), adminHandler.UserList)
+	r.GET("/sortuser", m.AdminRetriveCookie, m.Permission(utils.PermUsersRead), adminHandler.SortUserByPermission)
+	r.GET("/searchuser", m.AdminRetriveCookie, m.Permission(utils.PermUsersRead), adminHandler.SearchUser)
+	r.POST("/userpermission/:id", m.AdminRetriveCookie, m.Permission(utils.PermUsersManage), a.Record("user.togglepermission", "id"), adminHandler.TogglePermission)
+	r.GET("/referralreport", m.AdminRetriveCookie, m.Permission(utils.PermReports), adminHandler.ReferralReport)
+	r.GET("/referralsettings", m.AdminRetriveCookie, m.Permission(utils.PermSettings), adminHandler.ReferralSettings)
+	r.PUT("/referralsettings", m.AdminRetriveCookie, m.Permission(utils.PermSettings), a.Record("settings.referral", ""), adminHandler.UpdateReferralSettings)
 
-	r.POST("/addticket", m.AdminRetriveCookie, adminHandler.CreateTicket)
-	r.PUT("/editticket/:id", m.AdminRetriveCookie, adminHandler.EditTicket)
-	r.DELETE("/deleteticket/:id", m.AdminRetriveCookie, adminHandler.DeleteTicket)
+	r.POST("/addticket", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("ticket.create", ""), adminHandler.CreateTicket)
+	r.PUT("/editticket/:id", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("ticket.edit", "id"), adminHandler.EditTicket)
+	r.DELETE("/deleteticket/:id", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("ticket.delete", "id"), adminHandler.DeleteTicket)
+	r.PUT("/transfersettings/:id", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("ticket.transfersettings", "id"), adminHandler.TransferSettings)
+	r.POST("/addseatmap/:ticketid", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("ticket.addseatmap", "ticketid"), adminHandler.AddSeatMap)
+	r.GET("/ticketpricing/:ticketid", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), adminHandler.TicketPricing)
+	r.POST("/addpriceschedule/:ticketid", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("ticket.addpriceschedule", "ticketid"), adminHandler.AddPriceSchedule)
+	r.DELETE("/deletepriceschedule/:id", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("priceschedule.delete", "id"), adminHandler.DeletePriceSchedule)
+	r.POST("/adddemandrule/:ticketid", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("ticket.adddemandrule", "ticketid"), adminHandler.AddDemandRule)
+	r.DELETE("/deletedemandrule/:id", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("demandrule.delete", "id"), adminHandler.DeleteDemandRule)
 
-	r.POST("/addapparel", m.AdminRetriveCookie, adminHandler.CreateApparel)
-	r.PUT("/editappaerl/:id", m.AdminRetriveCookie, adminHandler.EditApparel)
-	r.DELETE("/deleteapparel/:id", m.AdminRetriveCookie, adminHandler.DeleteApparel)
+	r.POST("/addapparel", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("apparel.create", ""), adminHandler.CreateApparel)
+	r.PUT("/editappaerl/:id", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("apparel.edit", "id"), adminHandler.EditApparel)
+	r.DELETE("/deleteapparel/:id", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("apparel.delete", "id"), adminHandler.DeleteApparel)
 
-	r.POST("/addcoupon", m.AdminRetriveCookie, adminHandler.AddCoupon)
-	r.POST("/addoffer", m.AdminRetriveCookie, adminHandler.AddOffer)
+	r.PUT("/restock/:category/:productid/:quantity", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), a.Record("inventory.restock", "productid"), adminHandler.Restock)
+	r.GET("/waitlistreport", m.AdminRetriveCookie, m.Permission(utils.PermCatalogue), adminHandler.WaitlistReport)
+
+	r.POST("/addcoupon", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("coupon.create", ""), adminHandler.AddCoupon)
+	r.POST("/addoffer", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("offer.create", ""), adminHandler.AddOffer)
+	r.GET("/couponlist", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), adminHandler.CouponList)
+	r.PUT("/editcoupon/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("coupon.edit", "id"), adminHandler.EditCoupon)
+	r.PUT("/togglecoupon/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("coupon.toggle", "id"), adminHandler.ToggleCoupon)
+	r.DELETE("/deletecoupon/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("coupon.delete", "id"), adminHandler.DeleteCoupon)
+	r.GET("/couponusage/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), adminHandler.CouponUsage)
+	r.POST("/addcampaign", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("campaign.create", ""), adminHandler.AddCampaign)
+	r.GET("/campaignlist", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), adminHandler.CampaignList)
+	r.GET("/campaignstats/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), adminHandler.CampaignStats)
+	r.PUT("/deactivatecampaign/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("campaign.deactivate", "id"), adminHandler.DeactivateCampaign)
+	r.GET("/exportcampaign/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), adminHandler.ExportCampaign)
+	r.GET("/offerlist", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), adminHandler.OfferList)
+	r.PUT("/editoffer/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("offer.edit", "id"), adminHandler.EditOffer)
+	r.PUT("/toggleoffer/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("offer.toggle", "id"), adminHandler.ToggleOffer)
+	r.DELETE("/deleteoffer/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("offer.delete", "id"), adminHandler.DeleteOffer)
+	r.GET("/promotionrules", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), adminHandler.PromotionRules)
+	r.POST("/addsegment", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("segment.create", ""), adminHandler.AddSegment)
+	r.GET("/segmentlist", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), adminHandler.SegmentList)
+	r.DELETE("/deletesegment/:id", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("segment.delete", "id"), adminHandler.DeleteSegment)
+	r.PUT("/promotionrules", m.AdminRetriveCookie, m.Permission(utils.PermPromotions), a.Record("settings.promotion", ""), adminHandler.UpdatePromotionRules)
+	r.GET("/loyaltysettings", m.AdminRetriveCookie, m.Permission(utils.PermSettings), adminHandler.LoyaltySettings)
+	r.PUT("/loyaltysettings", m.AdminRetriveCookie, m.Permission(utils.PermSettings), a.Record("settings.loyalty", ""), adminHandler.UpdateLoyaltySettings)
+	r.POST("/issuegiftcard", m.AdminRetriveCookie, m.Permission(utils.PermGiftCards), a.Record("giftcard.issue", ""), adminHandler.IssueGiftCard)
+	r.PATCH("/voidgiftcard/:id", m.AdminRetriveCookie, m.Permission(utils.PermGiftCards), a.Record("giftcard.void", "id"), adminHandler.VoidGiftCard)
+	r.GET("/giftcardlookup/:code", m.AdminRetriveCookie, m.Permission(utils.PermOrdersRead), adminHandler.GiftCardLookup)
 
 	return r
 }
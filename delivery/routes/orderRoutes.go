@@ -2,12 +2,13 @@ package routes
 
 import (
 	"zog/delivery/handlers"
-	m "zog/delivery/middlewares"
+	middlewares "zog/delivery/middlewares"
+	"zog/domain/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrderRouter(r *gin.Engine, orderHandler *handlers.OrderHandler) *gin.Engine {
+func OrderRouter(r *gin.Engine, orderHandler *handlers.OrderHandler, m *middlewares.Auth, a *middlewares.Audit) *gin.Engine {
 
 	r.POST("/placeorder/:addressid/:payment", m.UserRetriveCookie, orderHandler.PlaceOrder)
 	r.POST("/paymentverification/:sign/:razorid/:payid", m.UserRetriveCookie, orderHandler.PaymentVerification)
@@ -16,13 +17,13 @@ func OrderRouter(r *gin.Engine, orderHandler *handlers.OrderHandler) *gin.Engine
 	r.POST("/orderreturn/:orderid", m.UserRetriveCookie, orderHandler.OrderReturn)
 
 	// admin
-	r.PUT("/updateorder/:orderid/:status", m.AdminRetriveCookie, orderHandler.AdminOrderUpdate)
-	r.POST("/updatereturn/:returnid/:status/:refund", m.AdminRetriveCookie, orderHandler.AdminReturnUpdate)
-	r.POST("/refund/:orderid", m.AdminRetriveCookie, orderHandler.AdminRefund)
-	r.GET("/salesreportbydate/:start/:end", m.AdminRetriveCookie, orderHandler.SalesReportByDate)
-	r.GET("/salesreportbyperiod/:period", m.AdminRetriveCookie, orderHandler.SalesReportByPeriod)
-	r.GET("/salesreportbycategory/:category/:period", m.AdminRetriveCookie, orderHandler.SalesReportByCategory)
-	r.POST("/sortorders", m.AdminRetriveCookie, orderHandler.SortOrderByStatus)
+	r.PUT("/updateorder/:orderid/:status", m.AdminRetriveCookie, m.Permission(utils.PermOrders), a.Record("order.updatestatus", "orderid"), orderHandler.AdminOrderUpdate)
+	r.POST("/updatereturn/:returnid/:status/:refund", m.AdminRetriveCookie, m.Permission(utils.PermRefunds), a.Record("return.update", "returnid"), orderHandler.AdminReturnUpdate)
+	r.POST("/refund/:orderid", m.AdminRetriveCookie, m.Permission(utils.PermRefunds), a.Record("order.refund", "orderid"), orderHandler.AdminRefund)
+	r.GET("/salesreportbydate/:start/:end", m.AdminRetriveCookie, m.Permission(utils.PermReports), orderHandler.SalesReportByDate)
+	r.GET("/salesreportbyperiod/:period", m.AdminRetriveCookie, m.Permission(utils.PermReports), orderHandler.SalesReportByPeriod)
+	r.GET("/salesreportbycategory/:category/:period", m.AdminRetriveCookie, m.Permission(utils.PermReports), orderHandler.SalesReportByCategory)
+	r.POST("/sortorders", m.AdminRetriveCookie, m.Permission(utils.PermOrdersRead), orderHandler.SortOrderByStatus)
 
 	return r
 }
@@ -2,26 +2,31 @@ package routes
 
 import (
 	"zog/delivery/handlers"
-	m "zog/delivery/middlewares"
+	middlewares "zog/delivery/middlewares"
 
 	"github.com/gin-gonic/gin"
 )
 
-func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler) *gin.Engine {
+func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler, m *middlewares.Auth, rl *middlewares.RateLimit) *gin.Engine {
 
 	r.POST("/signup", userHandler.Signup)
-	r.POST("/signupwithotp", userHandler.SignupWithOtp)
-	r.POST("/signupotpvalidation", userHandler.SignupOtpValidation)
-	r.POST("/loginwithotp", userHandler.LoginWithOtp)
-	r.POST("/otpvalidation", userHandler.LoginOtpValidation)
-	r.POST("/loginwithpassword", userHandler.LoginWithPassword)
+	r.POST("/signupwithotp", rl.PerIP("otp"), userHandler.SignupWithOtp)
+	r.POST("/signupotpvalidation", rl.PerIP("otp"), userHandler.SignupOtpValidation)
+	r.POST("/loginwithotp", rl.PerIP("otp"), userHandler.LoginWithOtp)
+	r.POST("/otpvalidation", rl.PerIP("otp"), userHandler.LoginOtpValidation)
+	r.POST("/loginwithpassword", rl.PerIP("login"), userHandler.LoginWithPassword)
+	r.POST("/loginwithemail", rl.PerIP("login"), userHandler.LoginWithEmail)
+	r.POST("/requestpasswordreset", rl.PerIP("otp"), userHandler.RequestPasswordReset)
+	r.POST("/resetpassword", rl.PerIP("login"), userHandler.ResetPassword)
+	r.GET("/verifyemail", rl.PerIP("login"), userHandler.VerifyEmail)
+	r.POST("/resendverification", rl.PerIP("otp"), m.UserRetriveCookie, userHandler.ResendVerification)
 
 	r.GET("/home", m.UserRetriveCookie, userHandler.Home)
 	r.POST("/addaddress", m.UserRetriveCookie, userHandler.AddAddress)
 	r.GET("/userdetails", m.UserRetriveCookie, userHandler.ShowUserDetails)
 	r.PUT("/editprofile", m.UserRetriveCookie, userHandler.EditProfile)
-	r.POST("/forgotpassword", m.UserRetriveCookie, userHandler.ChangePassword)
-	r.POST("/otpvalidationpassword", m.UserRetriveCookie, userHandler.OtpValidationPassword)
+	r.POST("/forgotpassword", rl.PerIP("otp"), m.UserRetriveCookie, userHandler.ChangePassword)
+	r.POST("/otpvalidationpassword", rl.PerIP("otp"), m.UserRetriveCookie, userHandler.OtpValidationPassword)
 	r.GET("/tickets", m.UserRetriveCookie, userHandler.Tickets)
 	r.GET("/searchticket", m.UserRetriveCookie, userHandler.SearchTicket)
 	r.GET("/ticketdetails/:ticketid", m.UserRetriveCookie, userHandler.TicketDetails)
@@ -30,6 +35,9 @@ func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler) *gin.Engine {
 	r.GET("/apparelsdetails/:apparelid", m.UserRetriveCookie, userHandler.ApparelDetails)
 
 	r.POST("/addtocart/:category/:productid/:quantity", m.UserRetriveCookie, userHandler.AddToCart)
+	r.GET("/seatmap/:ticketid", m.UserRetriveCookie, userHandler.SeatMap)
+	r.POST("/addseattocart/:seatid", m.UserRetriveCookie, userHandler.AddSeatToCart)
+	r.DELETE("/removeseat/:seatid", m.UserRetriveCookie, userHandler.RemoveSeat)
 	r.POST("/addtowishlist/:category/:productid", m.UserRetriveCookie, userHandler.AddToWishlist)
 	r.PUT("/increasequantity/:category/:productid", m.UserRetriveCookie, userHandler.IncreaseQuantity)
 	r.GET("/usercartlist", m.UserRetriveCookie, userHandler.CartList)
@@ -39,8 +47,32 @@ func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler) *gin.Engine {
 	r.GET("/userwishlist", m.UserRetriveCookie, userHandler.ViewWishlist)
 	r.GET("/coupons", m.UserRetriveCookie, userHandler.AvailableCoupons)
 	r.POST("/applycoupon/:code", m.UserRetriveCookie, userHandler.ApplyCoupon)
+	r.DELETE("/removecoupon", m.UserRetriveCookie, userHandler.RemoveCoupon)
+	r.GET("/loyalty", m.UserRetriveCookie, userHandler.LoyaltyPoints)
+	r.POST("/applypoints/:points", m.UserRetriveCookie, userHandler.ApplyPoints)
+	r.DELETE("/removepoints", m.UserRetriveCookie, userHandler.RemovePoints)
+	r.GET("/giftcardoptions", m.UserRetriveCookie, userHandler.GiftCardOptions)
+	r.POST("/addgiftcard", m.UserRetriveCookie, userHandler.AddGiftCard)
+	r.DELETE("/removegiftcard/:id", m.UserRetriveCookie, userHandler.RemoveGiftCard)
+	r.GET("/mygiftcards", m.UserRetriveCookie, userHandler.MyGiftCards)
+	r.GET("/giftcardbalance/:code", m.UserRetriveCookie, userHandler.GiftCardBalance)
+	r.POST("/redeemgiftcard/:code", m.UserRetriveCookie, userHandler.RedeemGiftCard)
+	r.POST("/applygiftcard/:code", m.UserRetriveCookie, userHandler.ApplyGiftCard)
+	r.DELETE("/removegiftcardpayment", m.UserRetriveCookie, userHandler.RemoveGiftCardPayment)
 	r.GET("/offer", m.UserRetriveCookie, userHandler.OfferCheck)
+	r.POST("/joinwaitlist/:category/:productid/:quantity", m.UserRetriveCookie, userHandler.JoinWaitlist)
+	r.DELETE("/leavewaitlist/:category/:productid", m.UserRetriveCookie, userHandler.LeaveWaitlist)
+	r.GET("/userwaitlist", m.UserRetriveCookie, userHandler.ViewWaitlist)
+	r.GET("/notifications", m.UserRetriveCookie, userHandler.Notifications)
+	r.GET("/referral", m.UserRetriveCookie, userHandler.Referrals)
+	r.GET("/mytickets", m.UserRetriveCookie, userHandler.MyTickets)
+	r.POST("/transferticket/:passid", m.UserRetriveCookie, userHandler.TransferTicket)
+	r.POST("/accepttransfer/:transferid", m.UserRetriveCookie, userHandler.AcceptTransfer)
+	r.POST("/rejecttransfer/:transferid", m.UserRetriveCookie, userHandler.RejectTransfer)
+	r.GET("/transferhistory", m.UserRetriveCookie, userHandler.TransferHistory)
 	r.POST("/logout", userHandler.Logout)
+	r.POST("/logouteverywhere", m.UserRetriveCookie, userHandler.LogoutEverywhere)
+	r.POST("/refresh", userHandler.Refresh)
 
 	return r
 }
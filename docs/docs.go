@@ -20,6 +20,38 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/accepttransfer/{transferid}": {
+            "post": {
+                "description": "Accepting a ticket sent by another user, a new entry code is issued",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Accept ticket transfer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transfer ID",
+                        "name": "transferid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TicketPass"
+                        }
+                    }
+                }
+            }
+        },
         "/addaddress": {
             "post": {
                 "description": "Add new address to the database with user id",
@@ -88,6 +120,40 @@ const docTemplate = `{
                 }
             }
         },
+        "/addcampaign": {
+            "post": {
+                "description": "Generating count unique single-use codes from a prefix, length and alphabet, all sharing the campaign rules",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Generating campaign coupon codes",
+                "parameters": [
+                    {
+                        "description": "campaign",
+                        "name": "campaign",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.CouponCampaign"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.CouponCampaign"
+                        }
+                    }
+                }
+            }
+        },
         "/addcoupon": {
             "post": {
                 "description": "Addig coupon for users, with a unique code",
@@ -119,6 +185,81 @@ const docTemplate = `{
                 }
             }
         },
+        "/adddemandrule/{ticketid}": {
+            "post": {
+                "description": "Raising the ticket price by a percentage once remaining stock drops to the threshold",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Adding demand rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "ticketid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Demand rule",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.DemandRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/addgiftcard": {
+            "post": {
+                "description": "Adding a gift card of a fixed or custom amount to the cart, optionally addressed to a recipient's email or phone. The code is generated once the order is paid, shown once on the invoice and emailed to the recipient",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Buying a gift card",
+                "parameters": [
+                    {
+                        "description": "gift card",
+                        "name": "giftcard",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.GiftCardInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.GiftCard"
+                        }
+                    }
+                }
+            }
+        },
         "/addoffer": {
             "post": {
                 "description": "Addig coupon for users, with a unique code",
@@ -150,6 +291,154 @@ const docTemplate = `{
                 }
             }
         },
+        "/addpriceschedule/{ticketid}": {
+            "post": {
+                "description": "Adding a time window with its own ticket price, like early bird or last minute",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Adding price schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "ticketid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Price schedule",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.PriceSchedule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/addseatmap/{ticketid}": {
+            "post": {
+                "description": "Adding sections, rows and seats for a reserved-seating event, ticket stock is set to the seat count",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Adding seat map",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "ticketid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Seat map",
+                        "name": "seatmap",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.SeatMapInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/addseattocart/{seatid}": {
+            "post": {
+                "description": "Holding a seat for a short while and adding it to cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Add seat to cart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Seat ID",
+                        "name": "seatid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/addsegment": {
+            "post": {
+                "description": "Adding a segment by rule: new (no orders, optionally joined in the last days), lapsed (ordered before but not in days, 90 by default), event (bought tickets for ticketid) or wallet (balance between walletmin and walletmax)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Adding customer segment",
+                "parameters": [
+                    {
+                        "description": "segment",
+                        "name": "segment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Segment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Segment"
+                        }
+                    }
+                }
+            }
+        },
         "/addticket": {
             "post": {
                 "description": "Adding new product of category ticket in database",
@@ -292,6 +581,29 @@ const docTemplate = `{
                 }
             }
         },
+        "/adminlist": {
+            "get": {
+                "description": "Listing every admin with their role and status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Authentication"
+                ],
+                "summary": "Admin list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Admin"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/adminlogin": {
             "post": {
                 "description": "Admin login with otp",
@@ -360,9 +672,60 @@ const docTemplate = `{
                 }
             }
         },
-        "/adminotpvalidation": {
+        "/adminlogout": {
             "post": {
-                "description": "Otp Validation for admin login",
+                "description": "Revoking the admin session's tokens and deleting the cookies",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Authentication"
+                ],
+                "summary": "Admin logout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Refresh token when not sent as a cookie",
+                        "name": "refreshtoken",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/adminlogouteverywhere": {
+            "post": {
+                "description": "Ending every session of the admin on every device",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Authentication"
+                ],
+                "summary": "Admin logout everywhere",
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/adminotpvalidation": {
+            "post": {
+                "description": "Otp Validation for admin login, posting the phone, the key returned by /adminlogin and the otp",
                 "consumes": [
                     "application/json"
                 ],
@@ -394,106 +757,113 @@ const docTemplate = `{
                 }
             }
         },
-        "/appareldetails/{apparelid}": {
-            "get": {
-                "description": "Showing details of a single product and option to adding cart",
+        "/adminrefresh": {
+            "post": {
+                "description": "Exchanging the admin refresh token, from the cookie or the refreshtoken field, for new tokens",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Authentication"
                 ],
-                "summary": "Details of a Apparel",
+                "summary": "Refresh admin session",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Apparel ID",
-                        "name": "apparelid",
-                        "in": "path",
-                        "required": true
+                        "description": "Refresh token when not sent as a cookie",
+                        "name": "refreshtoken",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Apparel Details",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.ApparelDetails"
+                            "$ref": "#/definitions/entity.AuthTokens"
                         }
                     }
                 }
             }
         },
-        "/apparels": {
+        "/adminroles": {
             "get": {
-                "description": "Showing the available Apparels in the site",
-                "consumes": [
+                "description": "Listing the admin roles and the permissions each one has",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Admin Authentication"
+                ],
+                "summary": "Admin roles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.AdminRole"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admintotpchallengeqr": {
+            "get": {
+                "description": "The pending enrolment as a QR code PNG, for the logged in admin or, on /admintotpchallengeqr, for the holder of a login challenge",
                 "produces": [
-                    "application/json"
+                    "image/png"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Two-Factor"
                 ],
-                "summary": "Apparel List",
+                "summary": "Two-factor QR code",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "page no",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit no",
-                        "name": "limit",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "Sort by Category",
-                        "name": "sort",
+                        "description": "Challenge, on /admintotpchallengeqr",
+                        "name": "challenge",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Apparel List",
+                        "description": "QR code PNG",
                         "schema": {
-                            "$ref": "#/definitions/entity.Apparel"
+                            "type": "file"
                         }
                     }
                 }
             }
         },
-        "/applycoupon/{code}": {
+        "/admintotpconfirm": {
             "post": {
-                "description": "applying coupon offer for user cart amount",
+                "description": "Turning TOTP on with the first code from the authenticator. The recovery codes are shown only this once",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Two-Factor"
                 ],
-                "summary": "checking coupon availability and adding offer amount",
+                "summary": "Confirm two-factor enrolment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "coupon code",
+                        "description": "Totp code",
                         "name": "code",
-                        "in": "path",
+                        "in": "formData",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "total amount",
+                        "description": "Recovery codes",
                         "schema": {
                             "type": "string"
                         }
@@ -501,25 +871,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/cancelorder/{orderid}": {
-            "put": {
-                "description": "canceling the order from user side and admin side",
+        "/admintotpdisable": {
+            "post": {
+                "description": "Turning TOTP off, given a current code. Not allowed for roles that require it",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Order"
+                    "Admin Two-Factor"
                 ],
-                "summary": "Order Cancelation",
+                "summary": "Disable two-factor",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order Id",
-                        "name": "orderid",
-                        "in": "path",
+                        "description": "Totp code",
+                        "name": "code",
+                        "in": "formData",
                         "required": true
                     }
                 ],
@@ -533,164 +903,151 @@ const docTemplate = `{
                 }
             }
         },
-        "/coupons": {
-            "get": {
-                "description": "showing the available coupons and eligibility",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admintotpenrol": {
+            "post": {
+                "description": "Starting TOTP enrolment for the logged in admin. Add the secret or scan /admintotpqr in an authenticator app, then confirm with /admintotpconfirm",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Two-Factor"
                 ],
-                "summary": "checking coupon availability",
+                "summary": "Enrol in two-factor",
                 "responses": {
                     "200": {
-                        "description": "Available coupons",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Coupon"
+                            "$ref": "#/definitions/entity.TotpEnrolment"
                         }
                     }
                 }
             }
         },
-        "/deleteapparel/{id}": {
-            "delete": {
-                "description": "Soft deleting the data of a product from database in category apparel",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admintotpqr": {
+            "get": {
+                "description": "The pending enrolment as a QR code PNG, for the logged in admin or, on /admintotpchallengeqr, for the holder of a login challenge",
                 "produces": [
-                    "application/json"
+                    "image/png"
                 ],
                 "tags": [
-                    "Admin Product\u0026Offer Management"
+                    "Admin Two-Factor"
                 ],
-                "summary": "Delete existing product from database",
+                "summary": "Two-factor QR code",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "product id",
-                        "name": "ProductId",
-                        "in": "query",
-                        "required": true
+                        "type": "string",
+                        "description": "Challenge, on /admintotpchallengeqr",
+                        "name": "challenge",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "QR code PNG",
                         "schema": {
-                            "$ref": "#/definitions/entity.Apparel"
+                            "type": "file"
                         }
                     }
                 }
             }
         },
-        "/deleteticket/{id}": {
-            "delete": {
-                "description": "Soft deleting the data of a product from database in category ticket",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admintotprecoverycodes": {
+            "get": {
+                "description": "How many of the admin's recovery codes are still unused, to know when to ask for new ones",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Product\u0026Offer Management"
-                ],
-                "summary": "Delete existing product from database",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "product id",
-                        "name": "ProductId",
-                        "in": "query",
-                        "required": true
-                    }
+                    "Admin Two-Factor"
                 ],
+                "summary": "Recovery codes left",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Recovery codes left",
                         "schema": {
-                            "$ref": "#/definitions/entity.Ticket"
+                            "type": "string"
                         }
                     }
                 }
-            }
-        },
-        "/editapparel/{id}": {
-            "put": {
-                "description": "Edit data of a product in category apparel",
+            },
+            "post": {
+                "description": "Replacing the admin's recovery codes, given a current TOTP code",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Product\u0026Offer Management"
+                    "Admin Two-Factor"
                 ],
-                "summary": "Edit existing product data",
+                "summary": "New recovery codes",
                 "parameters": [
                     {
-                        "description": "Apparel Data",
-                        "name": "admin",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.Apparel"
-                        }
+                        "type": "string",
+                        "description": "Totp code",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Recovery codes",
                         "schema": {
-                            "$ref": "#/definitions/entity.Apparel"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/editprofile": {
-            "put": {
-                "description": "Edit User details including address",
+        "/admintotpverify": {
+            "post": {
+                "description": "Finishing an admin login with the challenge from the login response and a TOTP code, or a recovery code. An admin who was enrolling confirms enrolment with their first code and gets their recovery codes",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Authentication"
+                    "Admin Two-Factor"
                 ],
-                "summary": "Edit User Details",
+                "summary": "Two-factor login",
                 "parameters": [
                     {
-                        "description": "User Data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.EditUser"
-                        }
+                        "type": "string",
+                        "description": "Challenge",
+                        "name": "challenge",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Totp code",
+                        "name": "code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recovery code",
+                        "name": "recoverycode",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.AuthTokens"
                         }
                     }
                 }
             }
         },
-        "/editticket/{id}": {
-            "put": {
-                "description": "Edit data of a product in category ticket",
+        "/appareldetails/{apparelid}": {
+            "get": {
+                "description": "Showing details of a single product and option to adding cart",
                 "consumes": [
                     "application/json"
                 ],
@@ -698,33 +1055,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Product\u0026Offer Management"
+                    "User Shopping"
                 ],
-                "summary": "Edit existing product data",
+                "summary": "Details of a Apparel",
                 "parameters": [
                     {
-                        "description": "Ticket Data",
-                        "name": "admin",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.Ticket"
-                        }
+                        "type": "string",
+                        "description": "Apparel ID",
+                        "name": "apparelid",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Apparel Details",
                         "schema": {
-                            "$ref": "#/definitions/entity.Ticket"
+                            "$ref": "#/definitions/entity.ApparelDetails"
                         }
                     }
                 }
             }
         },
-        "/forgotpassword": {
-            "post": {
-                "description": "Option for changing password from user side",
+        "/apparels": {
+            "get": {
+                "description": "Showing the available Apparels in the site",
                 "consumes": [
                     "application/json"
                 ],
@@ -732,22 +1087,42 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Authentication"
+                    "User Shopping"
+                ],
+                "summary": "Apparel List",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by Category",
+                        "name": "sort",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Change password",
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "Apparel List",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.Apparel"
                         }
                     }
                 }
             }
         },
-        "/home": {
-            "get": {
-                "description": "User home with the next navigations",
+        "/applycoupon/{code}": {
+            "post": {
+                "description": "applying coupon offer for user cart amount",
                 "consumes": [
                     "application/json"
                 ],
@@ -757,10 +1132,19 @@ const docTemplate = `{
                 "tags": [
                     "User Shopping"
                 ],
-                "summary": "User Home",
+                "summary": "checking coupon availability and adding offer amount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "coupon code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "total amount",
                         "schema": {
                             "type": "string"
                         }
@@ -768,38 +1152,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/increasequantity/{category}/{productid}": {
-            "put": {
-                "description": "Increasing quantity one by one in the cart",
-                "consumes": [
-                    "application/json"
-                ],
+        "/applygiftcard/{code}": {
+            "post": {
+                "description": "Using a gift card to pay for part or all of the cart at checkout, the rest is paid with the chosen payment method",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "User Shopping"
                 ],
-                "summary": "Increase quantity of existing product in cart",
+                "summary": "Paying with a gift card",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product Category",
-                        "name": "category",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Product Id",
-                        "name": "productid",
+                        "description": "gift card code",
+                        "name": "code",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Added",
+                        "description": "amount covered",
                         "schema": {
                             "type": "string"
                         }
@@ -807,64 +1181,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/loginwithotp": {
+        "/applypoints/{points}": {
             "post": {
-                "description": "Login for user with otp",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "Using loyalty points as a discount on the cart, fewer points are used when the balance or the redemption cap does not allow all of them",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Authentication"
+                    "User Shopping"
                 ],
-                "summary": "Login",
+                "summary": "Spending loyalty points",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Phone No",
-                        "name": "phone",
-                        "in": "formData",
+                        "description": "points to spend",
+                        "name": "points",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "discount",
                         "schema": {
-                            "$ref": "#/definitions/entity.Login"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/loginwithpassword": {
-            "post": {
-                "description": "Login for user with password",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+        "/assignrole/{id}/{role}": {
+            "put": {
+                "description": "Giving an admin one of the roles super-admin, catalogue-manager, order-manager, finance or support",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Authentication"
+                    "Admin Authentication"
                 ],
-                "summary": "Login",
+                "summary": "Assign admin role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Phone No",
-                        "name": "phone",
-                        "in": "formData",
+                        "description": "Admin ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Password",
-                        "name": "password",
-                        "in": "formData",
+                        "description": "Role",
+                        "name": "role",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -872,38 +1240,85 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Login"
+                            "$ref": "#/definitions/entity.Admin"
                         }
                     }
                 }
             }
         },
-        "/logout": {
-            "post": {
-                "description": "Deleting cookie from the browser while logout",
-                "consumes": [
-                    "application/json"
-                ],
+        "/auditlog": {
+            "get": {
+                "description": "Searching the log of admin changes, newest first. Dates are YYYY-MM-DD and include both ends",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Authentication"
+                    "Admin User Management"
+                ],
+                "summary": "Audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin ID",
+                        "name": "adminid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user/admin/ticket/apparel/coupon/offer/order/return/...",
+                        "name": "entity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Entity ID",
+                        "name": "entityid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action such as order.refund",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To date",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "logout",
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.AuditLog"
                         }
                     }
                 }
             }
         },
-        "/offer": {
+        "/campaignlist": {
             "get": {
-                "description": "finding and showing offer for user with respect to user cart",
+                "description": "Listing coupon campaigns",
                 "consumes": [
                     "application/json"
                 ],
@@ -911,22 +1326,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Coupon campaign list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "active/inactive/expired/scheduled",
+                        "name": "status",
+                        "in": "query"
+                    }
                 ],
-                "summary": "checking offer availability",
                 "responses": {
                     "200": {
-                        "description": "offers",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.CouponCampaign"
+                            }
                         }
                     }
                 }
             }
         },
-        "/orderhistory": {
+        "/campaignstats/{id}": {
             "get": {
-                "description": "showing the history of orders to the user",
+                "description": "Showing how many campaign codes were generated, redeemed and turned into orders, and the discount given",
                 "consumes": [
                     "application/json"
                 ],
@@ -934,22 +1372,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Order"
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Coupon campaign stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Order History",
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.CampaignStats"
                         }
                     }
                 }
             }
         },
-        "/orderreturn/{orderid}": {
-            "post": {
-                "description": "Returning the orders which are delivered to the user",
+        "/cancelorder/{orderid}": {
+            "put": {
+                "description": "canceling the user's own order",
                 "consumes": [
                     "application/json"
                 ],
@@ -959,26 +1406,19 @@ const docTemplate = `{
                 "tags": [
                     "User Order"
                 ],
-                "summary": "Return delivered order",
+                "summary": "Order Cancelation",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "orderid",
+                        "description": "Order Id",
                         "name": "orderid",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "wrong product-product quality-late delivery-other",
-                        "name": "reason",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success massage",
+                        "description": "Success message",
                         "schema": {
                             "type": "string"
                         }
@@ -986,99 +1426,90 @@ const docTemplate = `{
                 }
             }
         },
-        "/otpvalidation": {
-            "post": {
-                "description": "Validating user otp for login validation",
+        "/couponlist": {
+            "get": {
+                "description": "Listing coupons with filters for management by admin",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Authentication"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Otp Validation",
+                "summary": "Coupon list",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Otp",
-                        "name": "otp",
-                        "in": "formData",
-                        "required": true
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Key",
-                        "name": "key",
-                        "in": "formData",
-                        "required": true
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "phone",
-                        "name": "phone",
-                        "in": "formData"
+                        "description": "active/inactive/expired/scheduled",
+                        "name": "status",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "resend",
-                        "name": "resend",
-                        "in": "formData"
+                        "description": "ticket/apparel",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Coupon code",
+                        "name": "search",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Login"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Coupon"
+                            }
                         }
                     }
                 }
             }
         },
-        "/otpvalidationpassword": {
-            "post": {
-                "description": "Otp validation for changing password",
-                "consumes": [
-                    "multipart/form-data"
+        "/coupons": {
+            "get": {
+                "description": "showing the coupons available to the user, including those for the user's segments",
+                "consumes": [
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Authentication"
-                ],
-                "summary": "Otp validation for password",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "New Password",
-                        "name": "password",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Otp",
-                        "name": "otp",
-                        "in": "formData",
-                        "required": true
-                    }
+                    "User Shopping"
                 ],
+                "summary": "checking coupon availability",
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "Available coupons",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.Coupon"
                         }
                     }
                 }
             }
         },
-        "/paymentverification/{sign}/{razorid}/{payid}": {
-            "post": {
-                "description": "After placing order - checking the status of online payment",
+        "/couponusage/{id}": {
+            "get": {
+                "description": "Showing who used a coupon and on which order",
                 "consumes": [
                     "application/json"
                 ],
@@ -1086,45 +1517,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Order"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Payment Verification",
+                "summary": "Coupon usage",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Payment signature",
-                        "name": "sign",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Razor Order Id",
-                        "name": "razorid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Razor Payment Id",
-                        "name": "payid",
+                        "description": "Coupon ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.CouponUsage"
                         }
                     }
                 }
             }
         },
-        "/placeorder/{addressid}/{payment}": {
-            "post": {
-                "description": "Placing order from user side with respect to the payment method",
+        "/deactivatecampaign/{id}": {
+            "put": {
+                "description": "Stopping every unused code of a campaign from being redeemed",
                 "consumes": [
                     "application/json"
                 ],
@@ -1132,38 +1549,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Order"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Place Order",
+                "summary": "Deactivating coupon campaign",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "address id",
-                        "name": "addressid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "payment method",
-                        "name": "payment",
+                        "description": "Campaign ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.CouponCampaign"
                         }
                     }
                 }
             }
         },
-        "/refund/{orderid}": {
-            "post": {
-                "description": "Transfering the total amount of order to wallet or other methods",
+        "/deleteapparel/{id}": {
+            "delete": {
+                "description": "Soft deleting the data of a product from database in category apparel",
                 "consumes": [
                     "application/json"
                 ],
@@ -1171,31 +1581,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Order Management"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Aproving refund by admin",
+                "summary": "Delete existing product from database",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "order return id",
-                        "name": "orderid",
-                        "in": "path",
+                        "type": "integer",
+                        "description": "product id",
+                        "name": "ProductId",
+                        "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success massage",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.Apparel"
                         }
                     }
                 }
             }
         },
-        "/registeradmin": {
-            "post": {
-                "description": "Adding new admin to the database",
+        "/deletecoupon/{id}": {
+            "delete": {
+                "description": "Deleting a coupon that was never used and removing it from carts",
                 "consumes": [
                     "application/json"
                 ],
@@ -1203,33 +1613,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Authentication"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "registering new admin",
+                "summary": "Deleting coupon",
                 "parameters": [
                     {
-                        "description": "Admin Data",
-                        "name": "admin",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.Admin"
-                        }
+                        "type": "string",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Success message",
                         "schema": {
-                            "$ref": "#/definitions/entity.Admin"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/removefromcart/{product}/{id}": {
+        "/deletedemandrule/{id}": {
             "delete": {
-                "description": "Removing product from the cart for unique and decrese quantity for existing product",
+                "description": "Deleting a demand rule of a ticket",
                 "consumes": [
                     "application/json"
                 ],
@@ -1237,20 +1645,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Remove Product from cart",
+                "summary": "Deleting demand rule",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product Name",
-                        "name": "product",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Product ID",
+                        "description": "Demand rule ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1266,9 +1667,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/removefromwishlist/{product}/{id}": {
+        "/deleteoffer/{id}": {
             "delete": {
-                "description": "Removing product from the user wishlist",
+                "description": "Deleting an offer and re-pricing the carts holding it",
                 "consumes": [
                     "application/json"
                 ],
@@ -1276,20 +1677,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Remove Product from wishlist",
+                "summary": "Deleting offer",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ticket/apparel",
-                        "name": "product",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Product ID",
+                        "description": "Offer ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1305,9 +1699,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/salesreportbycategory/{category}/{period}": {
-            "get": {
-                "description": "Showing the report of sales with respect to product category",
+        "/deletepriceschedule/{id}": {
+            "delete": {
+                "description": "Deleting a price schedule of a ticket",
                 "consumes": [
                     "application/json"
                 ],
@@ -1315,77 +1709,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Order Management"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Sales report by product category",
+                "summary": "Deleting price schedule",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Category",
-                        "name": "category",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "period",
-                        "name": "period",
+                        "description": "Price schedule ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "report",
+                        "description": "Success message",
                         "schema": {
-                            "type": "body"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/salesreportbydate/{start}/{end}": {
-            "get": {
-                "description": "Showing the sales report with respect to the given date",
-                "consumes": [
-                    "application/json"
-                ],
+        "/deletesegment/{id}": {
+            "delete": {
+                "description": "Deleting a segment no coupon or offer is limited to",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Order Management"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Sales report by date",
+                "summary": "Deleting customer segment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "start date D-M-Y",
-                        "name": "start",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "end date D-M-Y",
-                        "name": "end",
+                        "description": "Segment ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "report",
+                        "description": "Success message",
                         "schema": {
-                            "type": "body"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/salesreportbyperiod/{period}": {
-            "get": {
-                "description": "Showing the report of sales for last week,month and year",
+        "/deleteticket/{id}": {
+            "delete": {
+                "description": "Soft deleting the data of a product from database in category ticket",
                 "consumes": [
                     "application/json"
                 ],
@@ -1393,31 +1770,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Order Management"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Sales report by time period",
+                "summary": "Delete existing product from database",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Weekly Monthlt Yearly",
-                        "name": "period",
-                        "in": "path",
+                        "type": "integer",
+                        "description": "product id",
+                        "name": "ProductId",
+                        "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.Ticket"
                         }
                     }
                 }
             }
         },
-        "/searchapparel": {
-            "get": {
-                "description": "Showing the available apparels as per user search",
+        "/editapparel/{id}": {
+            "put": {
+                "description": "Edit data of a product in category apparel",
                 "consumes": [
                     "application/json"
                 ],
@@ -1425,32 +1802,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Search Result",
+                "summary": "Edit existing product data",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "page no",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit no",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search By Name",
-                        "name": "search",
-                        "in": "query"
+                        "description": "Apparel Data",
+                        "name": "admin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Apparel"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Apparel Data",
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/entity.Apparel"
                         }
@@ -1458,9 +1826,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/searchticket": {
-            "get": {
-                "description": "Showing the available tickets as per user search",
+        "/editcoupon/{id}": {
+            "put": {
+                "description": "Updating the terms of a coupon, carts holding it are re-priced at checkout",
                 "consumes": [
                     "application/json"
                 ],
@@ -1468,42 +1836,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Search Result",
+                "summary": "Editing coupon",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "page no",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit no",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Search By Name",
-                        "name": "search",
-                        "in": "query"
+                        "description": "coupon",
+                        "name": "coupon",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Coupon"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Ticket Data",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Ticket"
+                            "$ref": "#/definitions/entity.Coupon"
                         }
                     }
                 }
             }
         },
-        "/searchuser": {
-            "get": {
-                "description": "Showing user list for management by searching with name or id",
+        "/editoffer/{id}": {
+            "put": {
+                "description": "Updating the terms of an offer and re-pricing the carts holding it",
                 "consumes": [
                     "application/json"
                 ],
@@ -1511,36 +1877,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin User Management"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Search user by id or name",
+                "summary": "Editing offer",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User Name",
-                        "name": "name",
-                        "in": "query"
+                        "description": "Offer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "User Id",
-                        "name": "id",
-                        "in": "query"
+                        "description": "offer",
+                        "name": "offer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Offer"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.User"
+                            "$ref": "#/definitions/entity.Offer"
                         }
                     }
                 }
             }
         },
-        "/signup": {
-            "post": {
-                "description": "Adding new user to the database",
+        "/editprofile": {
+            "put": {
+                "description": "Edit User details including address",
                 "consumes": [
                     "application/json"
                 ],
@@ -1550,70 +1920,94 @@ const docTemplate = `{
                 "tags": [
                     "User Authentication"
                 ],
-                "summary": "signup",
+                "summary": "Edit User Details",
                 "parameters": [
                     {
                         "description": "User Data",
-                        "name": "userInput",
+                        "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Signup"
+                            "$ref": "#/definitions/models.EditUser"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Success message",
                         "schema": {
-                            "$ref": "#/definitions/entity.User"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/signupotpvalidation": {
-            "post": {
-                "description": "Validating user otp for signup",
+        "/editticket/{id}": {
+            "put": {
+                "description": "Edit data of a product in category ticket",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Authentication"
+                    "Admin Product\u0026Offer Management"
                 ],
-                "summary": "Sign Up Otp Validation",
+                "summary": "Edit existing product data",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Twilio Key",
-                        "name": "key",
-                        "in": "formData",
-                        "required": true
-                    },
+                        "description": "Ticket Data",
+                        "name": "admin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Ticket"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Ticket"
+                        }
+                    }
+                }
+            }
+        },
+        "/exportcampaign/{id}": {
+            "get": {
+                "description": "Downloading every code of a campaign with its redemption as CSV",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Exporting campaign codes",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Otp",
-                        "name": "otp",
-                        "in": "formData",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "file"
                         }
                     }
                 }
             }
         },
-        "/signupwithotp": {
+        "/forgotpassword": {
             "post": {
-                "description": "Adding new user to the database",
+                "description": "Option for changing password from user side",
                 "consumes": [
                     "application/json"
                 ],
@@ -1623,91 +2017,62 @@ const docTemplate = `{
                 "tags": [
                     "User Authentication"
                 ],
-                "summary": "signup with opt validation",
-                "parameters": [
-                    {
-                        "description": "User Data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Signup"
-                        }
-                    }
-                ],
+                "summary": "Change password",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Success message",
                         "schema": {
-                            "$ref": "#/definitions/entity.User"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/sortorders": {
-            "post": {
-                "description": "Showing the sorted list of orders in admin panel",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+        "/giftcardbalance/{code}": {
+            "get": {
+                "description": "Checking the balance, status and expiry of a gift card by its code",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Order Management"
+                    "User Shopping"
                 ],
-                "summary": "Sorting orders by order status",
+                "summary": "Gift card balance",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Status",
-                        "name": "status",
-                        "in": "formData",
+                        "description": "gift card code",
+                        "name": "code",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "sorted orders",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Order"
+                            "$ref": "#/definitions/entity.GiftCardBalance"
                         }
                     }
                 }
             }
         },
-        "/sortuser": {
+        "/giftcardlookup/{code}": {
             "get": {
-                "description": "Showing user list for management by sorting with permisiion",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Showing a gift card with its balance and every movement on it",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin User Management"
+                    "Admin Order Management"
                 ],
-                "summary": "User list by permission",
+                "summary": "Gift card lookup",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "page no",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "limit no",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "true/false",
-                        "name": "permission",
-                        "in": "query",
+                        "description": "gift card code",
+                        "name": "code",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -1715,47 +2080,35 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.User"
+                            "$ref": "#/definitions/entity.GiftCardDetail"
                         }
                     }
                 }
             }
         },
-        "/ticketdetails/{ticketid}": {
+        "/giftcardoptions": {
             "get": {
-                "description": "Showing details of a single product and option to adding cart",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Listing the fixed gift card amounts, the range allowed for a custom amount and how long a card stays valid",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "User Shopping"
                 ],
-                "summary": "Details of a Ticket",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Ticket ID",
-                        "name": "ticketid",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "Gift card amounts",
                 "responses": {
                     "200": {
-                        "description": "Ticket Details",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.TicketDetails"
+                            "$ref": "#/definitions/entity.GiftCardOptions"
                         }
                     }
                 }
             }
         },
-        "/tickets": {
+        "/home": {
             "get": {
-                "description": "Showing the available tickets in the site",
+                "description": "User home with the next navigations",
                 "consumes": [
                     "application/json"
                 ],
@@ -1765,40 +2118,20 @@ const docTemplate = `{
                 "tags": [
                     "User Shopping"
                 ],
-                "summary": "Tickets List",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "page no",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit no",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Sort by location",
-                        "name": "sort",
-                        "in": "query"
-                    }
-                ],
+                "summary": "User Home",
                 "responses": {
                     "200": {
-                        "description": "Ticket Data",
+                        "description": "Success message",
                         "schema": {
-                            "$ref": "#/definitions/entity.Ticket"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/updateorder/{orderid}/{status}": {
+        "/increasequantity/{category}/{productid}": {
             "put": {
-                "description": "Updating the order status by admin",
+                "description": "Increasing quantity one by one in the cart",
                 "consumes": [
                     "application/json"
                 ],
@@ -1806,28 +2139,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Order Management"
+                    "User Shopping"
                 ],
-                "summary": "Update order status",
+                "summary": "Increase quantity of existing product in cart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Order Id",
-                        "name": "orderid",
+                        "description": "Product Category",
+                        "name": "category",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "status",
-                        "name": "status",
+                        "description": "Product Id",
+                        "name": "productid",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success massage",
+                        "description": "Added",
                         "schema": {
                             "type": "string"
                         }
@@ -1835,9 +2168,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/updatereturn/{returnid}/{status}/{refund}": {
+        "/issuegiftcard": {
             "post": {
-                "description": "Updating the retunr status by admin and implimenting refund",
+                "description": "Issuing an active gift card of any amount, optionally addressed to a recipient and valid for validitydays (365 by default). The code is only shown in this response and emailed to the recipient",
                 "consumes": [
                     "application/json"
                 ],
@@ -1847,33 +2180,67 @@ const docTemplate = `{
                 "tags": [
                     "Admin Order Management"
                 ],
-                "summary": "Updating return status and refund",
+                "summary": "Issuing a gift card",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Return Id",
-                        "name": "returnid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "status",
-                        "name": "status",
+                        "description": "gift card",
+                        "name": "giftcard",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.GiftCardInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.GiftCard"
+                        }
+                    }
+                }
+            }
+        },
+        "/joinwaitlist/{category}/{productid}/{quantity}": {
+            "post": {
+                "description": "Joining the waitlist of a sold out product, a time limited reservation is given when stock is back",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Join waitlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket/Apparel",
+                        "name": "category",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "refund method -wallet -account",
-                        "name": "refund",
+                        "description": "Product ID",
+                        "name": "productid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Product Quantity",
+                        "name": "quantity",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "updated succesfuly",
+                        "description": "Success message",
                         "schema": {
                             "type": "string"
                         }
@@ -1881,9 +2248,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/usercart": {
-            "get": {
-                "description": "Showing user cart",
+        "/leavewaitlist/{category}/{productid}": {
+            "delete": {
+                "description": "Leaving the waitlist of a product, an active reservation moves to the next user",
                 "consumes": [
                     "application/json"
                 ],
@@ -1893,45 +2260,77 @@ const docTemplate = `{
                 "tags": [
                     "User Shopping"
                 ],
-                "summary": "User Cart",
+                "summary": "Leave waitlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket/Apparel",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "productid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "User Cart",
+                        "description": "Success message",
                         "schema": {
-                            "$ref": "#/definitions/entity.Cart"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/usercartlist": {
-            "get": {
-                "description": "Showing the products in user cart",
+        "/loginwithemail": {
+            "post": {
+                "description": "Login for user with a verified email address and password",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Shopping"
+                    "User Authentication"
+                ],
+                "summary": "Login with email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
                 ],
-                "summary": "Cart List",
                 "responses": {
                     "200": {
-                        "description": "Cart List",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.CartItem"
+                            "$ref": "#/definitions/entity.AuthTokens"
                         }
                     }
                 }
             }
         },
-        "/userdetails": {
-            "get": {
-                "description": "User profile with adress and user details",
+        "/loginwithotp": {
+            "post": {
+                "description": "Login for user with otp",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -1939,411 +2338,3532 @@ const docTemplate = `{
                 "tags": [
                     "User Authentication"
                 ],
-                "summary": "User Details",
+                "summary": "Login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Phone No",
+                        "name": "phone",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.User"
+                            "$ref": "#/definitions/entity.Login"
                         }
                     }
                 }
             }
         },
-        "/usermanagement": {
-            "get": {
-                "description": "Showing user list for management by admin",
+        "/loginwithpassword": {
+            "post": {
+                "description": "Login for user with password",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin User Management"
+                    "User Authentication"
                 ],
-                "summary": "User list",
+                "summary": "Login",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "page no",
-                        "name": "page",
-                        "in": "query"
+                        "description": "Phone No",
+                        "name": "phone",
+                        "in": "formData",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "limit no",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.User"
+                            "$ref": "#/definitions/entity.Login"
                         }
                     }
                 }
             }
         },
-        "/userpermission/{id}": {
+        "/logout": {
             "post": {
-                "description": "Toggling user permission for block/unblock",
+                "description": "Revoking the session's tokens and deleting the cookies from the browser while logout",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin User Management"
+                    "User Authentication"
                 ],
-                "summary": "block/unblock user",
+                "summary": "logout",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Refresh token when not sent as a cookie",
+                        "name": "refreshtoken",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Success message",
                         "schema": {
-                            "$ref": "#/definitions/entity.Admin"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/userwishlist": {
-            "get": {
-                "description": "Showing the products in user wishlist",
-                "consumes": [
+        "/logouteverywhere": {
+            "post": {
+                "description": "Ending every session of the user on every device",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "logout everywhere",
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/loyalty": {
+            "get": {
+                "description": "Showing the points available to spend, those pending until the return window closes and the points history",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "User Shopping"
                 ],
-                "summary": "Wish List",
+                "summary": "Loyalty points balance",
                 "responses": {
                     "200": {
-                        "description": "Wishlist",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Wishlist"
+                            "$ref": "#/definitions/entity.LoyaltyBalance"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "entity.Address": {
-            "type": "object",
-            "properties": {
-                "city": {
-                    "type": "string"
-                },
-                "house": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "pincode": {
-                    "type": "integer"
-                },
-                "street": {
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
+        },
+        "/loyaltysettings": {
+            "get": {
+                "description": "Showing the points earned per 100 spent in each category, the value of a point, the return window, point expiry and the redemption cap",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Loyalty settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.LoyaltySetting"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Changing how points are earned, how long they stay pending and valid, what they are worth and how much of a cart they may cover",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Updating loyalty settings",
+                "parameters": [
+                    {
+                        "description": "settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.LoyaltySetting"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.LoyaltySetting"
+                        }
+                    }
                 }
             }
         },
-        "entity.Admin": {
-            "type": "object",
-            "properties": {
-                "active": {
-                    "type": "boolean"
-                },
-                "adminname": {
-                    "type": "string"
-                },
-                "email": {
+        "/mygiftcards": {
+            "get": {
+                "description": "Listing the gift cards the user bought and those sent to their email or phone, with the last four characters of their codes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "User gift cards",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.GiftCard"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/mytickets": {
+            "get": {
+                "description": "Showing the tickets owned by the user with their entry codes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Purchased tickets",
+                "responses": {
+                    "200": {
+                        "description": "Tickets",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TicketPass"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "description": "Showing the notifications sent to the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "User notifications",
+                "responses": {
+                    "200": {
+                        "description": "Notifications",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Notification"
+                        }
+                    }
+                }
+            }
+        },
+        "/offer": {
+            "get": {
+                "description": "finding and showing offer for user with respect to user cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "checking offer availability",
+                "responses": {
+                    "200": {
+                        "description": "offers",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/offerlist": {
+            "get": {
+                "description": "Listing offers with filters for management by admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Offer list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "active/inactive/expired/scheduled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ticket/apparel",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Offer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orderhistory": {
+            "get": {
+                "description": "showing the history of orders to the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Order History",
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/orderreturn/{orderid}": {
+            "post": {
+                "description": "Returning the orders which are delivered to the user, within the return window of the loyalty settings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Return delivered order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "orderid",
+                        "name": "orderid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "wrong product-product quality-late delivery-other",
+                        "name": "reason",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success massage",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/otpvalidation": {
+            "post": {
+                "description": "Validating user otp for login validation",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "Otp Validation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Otp",
+                        "name": "otp",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key",
+                        "name": "key",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "phone",
+                        "name": "phone",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "resend",
+                        "name": "resend",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Login"
+                        }
+                    }
+                }
+            }
+        },
+        "/otpvalidationpassword": {
+            "post": {
+                "description": "Otp validation for changing password",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "Otp validation for password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "New Password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key",
+                        "name": "key",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Otp",
+                        "name": "otp",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/paymentverification/{sign}/{razorid}/{payid}": {
+            "post": {
+                "description": "After placing order - checking the status of online payment",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Payment Verification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment signature",
+                        "name": "sign",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Razor Order Id",
+                        "name": "razorid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Razor Payment Id",
+                        "name": "payid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/placeorder/{addressid}/{payment}": {
+            "post": {
+                "description": "Placing order from user side with respect to the payment method: cod, paypal, razorpay, wallet, or split to pay the wallet balance first and the rest with razorpay",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Place Order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "address id",
+                        "name": "addressid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "payment method",
+                        "name": "payment",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Cart changed since it was filled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.CartReview"
+                        }
+                    }
+                }
+            }
+        },
+        "/promotionrules": {
+            "get": {
+                "description": "Showing which promotions stack, the order they apply in and the overall discount cap",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Promotion stacking rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.PromotionRule"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Choosing whether a coupon stacks with offers, whether offers stack with each other, which applies first and the overall discount cap (0 means no cap)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Updating promotion stacking rules",
+                "parameters": [
+                    {
+                        "description": "rules",
+                        "name": "rules",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.PromotionRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.PromotionRule"
+                        }
+                    }
+                }
+            }
+        },
+        "/redeemgiftcard/{code}": {
+            "post": {
+                "description": "Moving the whole balance of a gift card into the user's wallet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Redeeming a gift card to wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "gift card code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "amount added",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/referral": {
+            "get": {
+                "description": "Showing the user's referral code, the users they invited and the wallet credit earned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "Referral code and rewards",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ReferralSummary"
+                        }
+                    }
+                }
+            }
+        },
+        "/referralreport": {
+            "get": {
+                "description": "Showing how many invited users converted, were rejected as abuse or are still waiting on their first order, and the credit paid out",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "Referral report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "rewarded/rejected",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ReferralReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/referralsettings": {
+            "get": {
+                "description": "Showing the wallet credit given to both users when a referral converts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "Referral settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ReferralSetting"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Changing the wallet credit for the referrer and the referred user, or pausing the programme",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "Updating referral settings",
+                "parameters": [
+                    {
+                        "description": "settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.ReferralSetting"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ReferralSetting"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Exchanging the refresh token, from the cookie or the refreshtoken field, for new tokens",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "refresh session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Refresh token when not sent as a cookie",
+                        "name": "refreshtoken",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.AuthTokens"
+                        }
+                    }
+                }
+            }
+        },
+        "/refund/{orderid}": {
+            "post": {
+                "description": "Refunding an approved return once, or cancelling an order that has not shipped and refunding what was paid. A refund that failed can be retried; one that went through cannot be paid again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Order Management"
+                ],
+                "summary": "Aproving refund by admin",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "order return id",
+                        "name": "orderid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success massage",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/registeradmin": {
+            "post": {
+                "description": "Adding new admin to the database. The role defaults to support",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Authentication"
+                ],
+                "summary": "registering new admin",
+                "parameters": [
+                    {
+                        "description": "Admin Data",
+                        "name": "admin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.AdminInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Admin"
+                        }
+                    }
+                }
+            }
+        },
+        "/rejecttransfer/{transferid}": {
+            "post": {
+                "description": "Rejecting a transfer as the recipient or cancelling it as the sender",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Reject or cancel ticket transfer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transfer ID",
+                        "name": "transferid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/removecoupon": {
+            "delete": {
+                "description": "Removing the coupon applied to user cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Remove coupon",
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/removefromcart/{product}/{id}": {
+            "delete": {
+                "description": "Removing product from the cart for unique and decrese quantity for existing product",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Remove Product from cart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product Name",
+                        "name": "product",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/removefromwishlist/{product}/{id}": {
+            "delete": {
+                "description": "Removing product from the user wishlist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Remove Product from wishlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ticket/apparel",
+                        "name": "product",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/removegiftcard/{id}": {
+            "delete": {
+                "description": "Removing a gift card the user has not paid for yet from the cart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Removing a gift card from cart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "gift card id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/removegiftcardpayment": {
+            "delete": {
+                "description": "Removing the gift card applied to pay for the user cart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Removing gift card payment",
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/removepoints": {
+            "delete": {
+                "description": "Removing the loyalty points applied to user cart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Remove loyalty points",
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/removeseat/{seatid}": {
+            "delete": {
+                "description": "Removing a held seat from cart and releasing it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Remove seat from cart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Seat ID",
+                        "name": "seatid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/requestpasswordreset": {
+            "post": {
+                "description": "Mailing a password reset link to the account with this verified email. The answer is the same whether or not there is one",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "Request password reset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/resendverification": {
+            "post": {
+                "description": "Mailing the logged in user a new link to verify their email address",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "Resend verification email",
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/resetpassword": {
+            "post": {
+                "description": "Setting a new password with the token from a reset link. Every session of the user is ended",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the reset link",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "New Password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/resettotp/{id}": {
+            "patch": {
+                "description": "Clearing an admin's TOTP and recovery codes so they enrol again at their next login, and ending their sessions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Two-Factor"
+                ],
+                "summary": "Reset admin two-factor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/restock/{category}/{productid}/{quantity}": {
+            "put": {
+                "description": "Increasing the inventory of a product, waitlisted users get reservations for the new stock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Restocking a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket/Apparel",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "productid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Quantity",
+                        "name": "quantity",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/salesreportbycategory/{category}/{period}": {
+            "get": {
+                "description": "Showing the report of sales with respect to product category",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Order Management"
+                ],
+                "summary": "Sales report by product category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "period",
+                        "name": "period",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "report",
+                        "schema": {
+                            "type": "body"
+                        }
+                    }
+                }
+            }
+        },
+        "/salesreportbydate/{start}/{end}": {
+            "get": {
+                "description": "Showing the sales report with respect to the given date",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Order Management"
+                ],
+                "summary": "Sales report by date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "start date D-M-Y",
+                        "name": "start",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "end date D-M-Y",
+                        "name": "end",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "report",
+                        "schema": {
+                            "type": "body"
+                        }
+                    }
+                }
+            }
+        },
+        "/salesreportbyperiod/{period}": {
+            "get": {
+                "description": "Showing the report of sales for last week,month and year",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Order Management"
+                ],
+                "summary": "Sales report by time period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Weekly Monthlt Yearly",
+                        "name": "period",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/searchapparel": {
+            "get": {
+                "description": "Showing the available apparels as per user search",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Search Result",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search By Name",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Apparel Data",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Apparel"
+                        }
+                    }
+                }
+            }
+        },
+        "/searchticket": {
+            "get": {
+                "description": "Showing the available tickets as per user search",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Search Result",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search By Name",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ticket Data",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Ticket"
+                        }
+                    }
+                }
+            }
+        },
+        "/searchuser": {
+            "get": {
+                "description": "Showing user list for management by searching with name or id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "Search user by id or name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User Name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User Id",
+                        "name": "id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/seatmap/{ticketid}": {
+            "get": {
+                "description": "Showing sections and seats of a reserved-seating event with their availability",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Seat map",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "ticketid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.SectionAvailability"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/segmentlist": {
+            "get": {
+                "description": "Listing the customer segments coupons and offers can be limited to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Customer segment list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Segment"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/signup": {
+            "post": {
+                "description": "Adding new user to the database, optionally with the referral code of the user who invited them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "signup",
+                "parameters": [
+                    {
+                        "description": "User Data",
+                        "name": "userInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Signup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/signupotpvalidation": {
+            "post": {
+                "description": "Validating user otp for signup",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "Sign Up Otp Validation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Twilio Key",
+                        "name": "key",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Otp",
+                        "name": "otp",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/signupwithotp": {
+            "post": {
+                "description": "Adding new user to the database, optionally with the referral code of the user who invited them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "signup with opt validation",
+                "parameters": [
+                    {
+                        "description": "User Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Signup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/sortorders": {
+            "post": {
+                "description": "Showing the sorted list of orders in admin panel",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Order Management"
+                ],
+                "summary": "Sorting orders by order status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Status",
+                        "name": "status",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "sorted orders",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Order"
+                        }
+                    }
+                }
+            }
+        },
+        "/sortuser": {
+            "get": {
+                "description": "Showing user list for management by sorting with permisiion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "User list by permission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "true/false",
+                        "name": "permission",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/ticketdetails/{ticketid}": {
+            "get": {
+                "description": "Showing details of a single product and option to adding cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Details of a Ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "ticketid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ticket Details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TicketDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/ticketpricing/{ticketid}": {
+            "get": {
+                "description": "Showing the price schedules, demand rules and current price of a ticket",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Ticket pricing",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "ticketid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TicketPricing"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets": {
+            "get": {
+                "description": "Showing the available tickets in the site",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Tickets List",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by location",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ticket Data",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Ticket"
+                        }
+                    }
+                }
+            }
+        },
+        "/toggleadmin/{id}": {
+            "patch": {
+                "description": "Deactivating an admin ends their sessions and stops them logging in; toggling again reactivates them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Authentication"
+                ],
+                "summary": "Deactivate/reactivate admin",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/togglecoupon/{id}": {
+            "put": {
+                "description": "Pausing or resuming a coupon, a paused coupon is removed from the carts it was applied to",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Activating or deactivating coupon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Coupon"
+                        }
+                    }
+                }
+            }
+        },
+        "/toggleoffer/{id}": {
+            "put": {
+                "description": "Pausing or resuming an offer and re-pricing the carts holding it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Activating or deactivating offer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Offer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Offer"
+                        }
+                    }
+                }
+            }
+        },
+        "/transferhistory": {
+            "get": {
+                "description": "Showing the tickets sent and received by the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Ticket transfer history",
+                "responses": {
+                    "200": {
+                        "description": "Transfers",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TicketTransfer"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfersettings/{id}": {
+            "put": {
+                "description": "Enabling or disabling ticket transfers for an event and setting the cutoff before the event date",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Ticket transfer settings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "true/false",
+                        "name": "disabled",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cutoff in hours before the event",
+                        "name": "cutoff",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/transferticket/{passid}": {
+            "post": {
+                "description": "Sending a purchased ticket to another registered user by phone or email",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Order"
+                ],
+                "summary": "Transfer ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket pass ID",
+                        "name": "passid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipient phone or email",
+                        "name": "recipient",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.TicketTransfer"
+                        }
+                    }
+                }
+            }
+        },
+        "/updateorder/{orderid}/{status}": {
+            "put": {
+                "description": "Updating the order status by admin. Orders move forward through pending, confirmed, shipped, delivered and completed, online orders only once paid. A delivered or completed order earns loyalty points, and a first such order pays out the user's referral, once per order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Order Management"
+                ],
+                "summary": "Update order status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order Id",
+                        "name": "orderid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success massage",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/updatereturn/{returnid}/{status}/{refund}": {
+            "post": {
+                "description": "Approving or rejecting a return that has not been decided yet. Rejecting puts the order back as it was. Approving a return takes back the loyalty points and referral reward the order earned and gives back points spent on it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Order Management"
+                ],
+                "summary": "Updating return status and refund",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Return Id",
+                        "name": "returnid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "approved or rejected",
+                        "name": "status",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refund method -wallet -account",
+                        "name": "refund",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated succesfuly",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/usercart": {
+            "get": {
+                "description": "Showing user cart re-priced against the current catalogue, with the lines that changed and the offer applied. The stored cart is only updated at checkout",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "User Cart",
+                "responses": {
+                    "200": {
+                        "description": "User Cart",
+                        "schema": {
+                            "$ref": "#/definitions/entity.CartReview"
+                        }
+                    }
+                }
+            }
+        },
+        "/usercartlist": {
+            "get": {
+                "description": "Showing the products in user cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Cart List",
+                "responses": {
+                    "200": {
+                        "description": "Cart List",
+                        "schema": {
+                            "$ref": "#/definitions/entity.CartItem"
+                        }
+                    }
+                }
+            }
+        },
+        "/userdetails": {
+            "get": {
+                "description": "User profile with adress and user details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "User Details",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/usermanagement": {
+            "get": {
+                "description": "Showing user list for management by admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "User list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "page no",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "limit no",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/userpermission/{id}": {
+            "post": {
+                "description": "Toggling user permission for block/unblock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "block/unblock user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Admin"
+                        }
+                    }
+                }
+            }
+        },
+        "/userwaitlist": {
+            "get": {
+                "description": "Showing the waitlists joined by the user with reservation status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "User waitlist",
+                "responses": {
+                    "200": {
+                        "description": "Waitlist",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WaitlistEntry"
+                        }
+                    }
+                }
+            }
+        },
+        "/userwishlist": {
+            "get": {
+                "description": "Showing the products in user wishlist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Shopping"
+                ],
+                "summary": "Wish List",
+                "responses": {
+                    "200": {
+                        "description": "Wishlist",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Wishlist"
+                        }
+                    }
+                }
+            }
+        },
+        "/verifyemail": {
+            "get": {
+                "description": "Verifying the user's email address with the token from the link mailed to it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Authentication"
+                ],
+                "summary": "Verify email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the verification link",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/voidgiftcard/{id}": {
+            "patch": {
+                "description": "Stopping a gift card from being used and writing off its balance",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Order Management"
+                ],
+                "summary": "Voiding a gift card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "gift card id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/waitlistreport": {
+            "get": {
+                "description": "Showing the number of users waiting and holding reservations for each product",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product\u0026Offer Management"
+                ],
+                "summary": "Waitlist length per product",
+                "responses": {
+                    "200": {
+                        "description": "Waitlist report",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WaitlistReport"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entity.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "house": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pincode": {
+                    "type": "integer"
+                },
+                "street": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Admin": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "adminname": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "totpenabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "entity.AdminDashboard": {
+            "type": "object",
+            "properties": {
+                "averageordervalue": {
+                    "type": "integer"
+                },
+                "newusers": {
+                    "type": "integer"
+                },
+                "pendingorders": {
+                    "type": "integer"
+                },
+                "returnorders": {
+                    "type": "integer"
+                },
+                "stocklesscategory": {
+                    "type": "string"
+                },
+                "totalorders": {
+                    "type": "integer"
+                },
+                "totalproducts": {
+                    "type": "integer"
+                },
+                "totalquery": {
+                    "type": "integer"
+                },
+                "totalrevenue": {
+                    "type": "integer"
+                },
+                "totalusers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.AdminInput": {
+            "type": "object",
+            "properties": {
+                "adminname": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.AdminRole": {
+            "type": "object",
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Apparel": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "imageurl": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "subcategory": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ApparelDetails": {
+            "type": "object",
+            "properties": {
+                "apparelid": {
+                    "type": "integer"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ApparelInput": {
+            "type": "object",
+            "properties": {
+                "apparelid": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "imageurl": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "productCategory": {
+                    "type": "string"
+                },
+                "productId": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "subcategory": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.AppliedPromotion": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "promotionid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "adminid": {
+                    "type": "integer"
+                },
+                "createdat": {
+                    "type": "string"
+                },
+                "diff": {
+                    "type": "string"
+                },
+                "entity": {
+                    "type": "string"
+                },
+                "entityid": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "request": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.AuditLog": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.AuditEntry"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.AuthTokens": {
+            "type": "object",
+            "properties": {
+                "accesstoken": {
+                    "type": "string"
+                },
+                "expiresin": {
+                    "type": "integer"
+                },
+                "refreshtoken": {
+                    "type": "string"
+                },
+                "tokentype": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CampaignStats": {
+            "type": "object",
+            "properties": {
+                "campaign": {
+                    "$ref": "#/definitions/entity.CouponCampaign"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "generated": {
+                    "type": "integer"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "redeemed": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Cart": {
+            "type": "object",
+            "properties": {
+                "apparelquantity": {
+                    "type": "integer"
+                },
+                "couponcode": {
+                    "type": "string"
+                },
+                "giftcardid": {
+                    "type": "integer"
+                },
+                "loyaltypoints": {
+                    "type": "integer"
+                },
+                "offernote": {
+                    "type": "string"
+                },
+                "offerprice": {
+                    "type": "integer"
+                },
+                "promotions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.AppliedPromotion"
+                    }
+                },
+                "ticketquantity": {
+                    "type": "integer"
+                },
+                "totalprice": {
+                    "type": "number"
+                }
+            }
+        },
+        "entity.CartChange": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "newprice": {
+                    "type": "number"
+                },
+                "newquantity": {
+                    "type": "integer"
+                },
+                "oldprice": {
+                    "type": "number"
+                },
+                "oldquantity": {
+                    "type": "integer"
+                },
+                "productid": {
+                    "type": "integer"
+                },
+                "productname": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "seatid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.CartItem": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "productid": {
+                    "type": "integer"
+                },
+                "productname": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "seatid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.CartReview": {
+            "type": "object",
+            "properties": {
+                "cart": {
+                    "$ref": "#/definitions/entity.Cart"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CartChange"
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.CartItem"
+                    }
+                },
+                "previousoffer": {
+                    "type": "integer"
+                },
+                "previoustotal": {
+                    "type": "number"
+                }
+            }
+        },
+        "entity.Coupon": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_discount": {
+                    "type": "integer"
+                },
+                "min_order": {
+                    "type": "integer"
+                },
+                "per_user_limit": {
+                    "type": "integer"
+                },
+                "productid": {
+                    "type": "integer"
+                },
+                "segmentid": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "usage_limit": {
+                    "type": "integer"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CouponCampaign": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "alphabet": {
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "length": {
+                    "type": "integer"
+                },
+                "max_discount": {
+                    "type": "integer"
+                },
+                "min_order": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "productid": {
+                    "type": "integer"
+                },
+                "segmentid": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CouponUsage": {
+            "type": "object",
+            "properties": {
+                "coupon": {
+                    "$ref": "#/definitions/entity.Coupon"
+                },
+                "uses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.UsedCoupon"
+                    }
+                }
+            }
+        },
+        "entity.DemandRule": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "increase": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.GiftCard": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "balance": {
+                    "type": "integer"
+                },
+                "code": {
+                    "description": "Code is only filled in on the card returned when it is issued.",
                     "type": "string"
                 },
-                "password": {
+                "codehint": {
                     "type": "string"
                 },
-                "phone": {
+                "expiresat": {
                     "type": "string"
                 },
-                "role": {
+                "id": {
+                    "type": "integer"
+                },
+                "issuedby": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "orderid": {
+                    "type": "integer"
+                },
+                "purchaserid": {
+                    "type": "integer"
+                },
+                "recipientemail": {
+                    "type": "string"
+                },
+                "recipientphone": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "entity.AdminDashboard": {
+        "entity.GiftCardBalance": {
             "type": "object",
             "properties": {
-                "averageordervalue": {
+                "balance": {
                     "type": "integer"
                 },
-                "newusers": {
+                "codehint": {
+                    "type": "string"
+                },
+                "expiresat": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.GiftCardDetail": {
+            "type": "object",
+            "properties": {
+                "card": {
+                    "$ref": "#/definitions/entity.GiftCard"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.GiftCardTxn"
+                    }
+                }
+            }
+        },
+        "entity.GiftCardInput": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
                     "type": "integer"
                 },
-                "pendingorders": {
+                "message": {
+                    "type": "string"
+                },
+                "recipientemail": {
+                    "type": "string"
+                },
+                "recipientphone": {
+                    "type": "string"
+                },
+                "validitydays": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.GiftCardOptions": {
+            "type": "object",
+            "properties": {
+                "denominations": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "maxamount": {
                     "type": "integer"
                 },
-                "returnorders": {
+                "minamount": {
                     "type": "integer"
                 },
-                "stocklesscategory": {
+                "validitydays": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.GiftCardTxn": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "date": {
                     "type": "string"
                 },
-                "totalorders": {
+                "giftcardid": {
                     "type": "integer"
                 },
-                "totalproducts": {
+                "id": {
                     "type": "integer"
                 },
-                "totalquery": {
+                "kind": {
+                    "type": "string"
+                },
+                "orderid": {
                     "type": "integer"
                 },
-                "totalrevenue": {
+                "userid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Login": {
+            "type": "object",
+            "required": [
+                "password",
+                "phone"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.LoyaltyBalance": {
+            "type": "object",
+            "properties": {
+                "available": {
                     "type": "integer"
                 },
-                "totalusers": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.LoyaltyEntry"
+                    }
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "value": {
                     "type": "integer"
                 }
             }
         },
-        "entity.Apparel": {
+        "entity.LoyaltyEntry": {
             "type": "object",
             "properties": {
+                "availableat": {
+                    "type": "string"
+                },
                 "category": {
                     "type": "string"
                 },
+                "date": {
+                    "type": "string"
+                },
+                "expiresat": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "imageurl": {
+                "kind": {
                     "type": "string"
                 },
-                "name": {
+                "orderid": {
+                    "type": "integer"
+                },
+                "points": {
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string"
+                }
+            }
+        },
+        "entity.LoyaltySetting": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
                 },
-                "price": {
+                "apparelrate": {
                     "type": "integer"
                 },
-                "subcategory": {
+                "expirydays": {
+                    "type": "integer"
+                },
+                "maxredeempercent": {
+                    "type": "integer"
+                },
+                "pointvalue": {
+                    "type": "integer"
+                },
+                "returnwindowdays": {
+                    "type": "integer"
+                },
+                "ticketrate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Notification": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "entity.ApparelDetails": {
+        "entity.Offer": {
             "type": "object",
             "properties": {
-                "apparelid": {
+                "active": {
+                    "type": "boolean"
+                },
+                "amount": {
                     "type": "integer"
                 },
-                "color": {
+                "category": {
                     "type": "string"
                 },
-                "description": {
+                "id": {
+                    "type": "integer"
+                },
+                "minprice": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
-                "size": {
+                "segmentid": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "usage_limit": {
+                    "type": "integer"
+                },
+                "valid_until": {
                     "type": "string"
                 }
             }
         },
-        "entity.ApparelInput": {
+        "entity.Order": {
             "type": "object",
             "properties": {
-                "apparelid": {
+                "adressid": {
                     "type": "integer"
                 },
-                "category": {
+                "couponcode": {
                     "type": "string"
                 },
-                "color": {
+                "deliveredat": {
                     "type": "string"
                 },
-                "description": {
+                "discount": {
+                    "type": "integer"
+                },
+                "gatewaypayid": {
+                    "type": "string"
+                },
+                "giftcardpaid": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "paymentid": {
+                    "type": "string"
+                },
+                "paymentmethod": {
+                    "type": "string"
+                },
+                "paymentstatus": {
+                    "type": "string"
+                },
+                "promotions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.AppliedPromotion"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "userid": {
+                    "type": "integer"
+                },
+                "walletpaid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.PriceSchedule": {
+            "type": "object",
+            "properties": {
+                "endsat": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "startsat": {
                     "type": "string"
+                }
+            }
+        },
+        "entity.PromotionRule": {
+            "type": "object",
+            "properties": {
+                "couponfirst": {
+                    "type": "boolean"
+                },
+                "maxdiscount": {
+                    "type": "integer"
+                },
+                "maxdiscountpercent": {
+                    "type": "integer"
+                },
+                "stackcouponwithoffers": {
+                    "type": "boolean"
                 },
+                "stackoffers": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "entity.Referral": {
+            "type": "object",
+            "properties": {
                 "id": {
                     "type": "integer"
                 },
-                "imageurl": {
+                "orderid": {
+                    "type": "integer"
+                },
+                "processedat": {
                     "type": "string"
                 },
-                "name": {
+                "reason": {
                     "type": "string"
                 },
-                "price": {
+                "refereecredit": {
                     "type": "integer"
                 },
-                "productCategory": {
-                    "type": "string"
-                },
-                "productId": {
+                "refereeid": {
                     "type": "integer"
                 },
-                "quantity": {
+                "referrercredit": {
                     "type": "integer"
                 },
-                "size": {
-                    "type": "string"
+                "referrerid": {
+                    "type": "integer"
                 },
-                "subcategory": {
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "entity.Cart": {
+        "entity.ReferralReport": {
             "type": "object",
             "properties": {
-                "apparelquantity": {
+                "converted": {
                     "type": "integer"
                 },
-                "offerprice": {
+                "credited": {
                     "type": "integer"
                 },
-                "ticketquantity": {
+                "invited": {
                     "type": "integer"
                 },
-                "totalprice": {
-                    "type": "number"
+                "pending": {
+                    "type": "integer"
+                },
+                "referrals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Referral"
+                    }
+                },
+                "rejected": {
+                    "type": "integer"
                 }
             }
         },
-        "entity.CartItem": {
+        "entity.ReferralSetting": {
             "type": "object",
             "properties": {
-                "category": {
-                    "type": "string"
-                },
-                "price": {
-                    "type": "number"
+                "active": {
+                    "type": "boolean"
                 },
-                "productid": {
+                "refereecredit": {
                     "type": "integer"
                 },
-                "productname": {
-                    "type": "string"
-                },
-                "quantity": {
+                "referrercredit": {
                     "type": "integer"
                 }
             }
         },
-        "entity.Coupon": {
+        "entity.ReferralSummary": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "integer"
-                },
-                "category": {
-                    "type": "string"
-                },
                 "code": {
                     "type": "string"
                 },
-                "type": {
-                    "type": "string"
-                },
-                "usage_limit": {
+                "earned": {
                     "type": "integer"
                 },
-                "used_count": {
+                "invited": {
                     "type": "integer"
                 },
-                "valid_from": {
-                    "type": "string"
-                },
-                "valid_until": {
-                    "type": "string"
+                "referrals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Referral"
+                    }
                 }
             }
         },
-        "entity.Login": {
+        "entity.RowInput": {
             "type": "object",
-            "required": [
-                "password",
-                "phone"
-            ],
             "properties": {
-                "password": {
+                "row": {
                     "type": "string"
                 },
-                "phone": {
-                    "type": "string"
+                "seats": {
+                    "type": "integer"
                 }
             }
         },
-        "entity.Offer": {
+        "entity.SeatMapInput": {
             "type": "object",
             "properties": {
-                "amount": {
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.SectionInput"
+                    }
+                }
+            }
+        },
+        "entity.SeatStatus": {
+            "type": "object",
+            "properties": {
+                "number": {
                     "type": "integer"
                 },
-                "category": {
+                "row": {
                     "type": "string"
                 },
-                "minprice": {
+                "seatid": {
                     "type": "integer"
                 },
-                "name": {
+                "status": {
                     "type": "string"
+                }
+            }
+        },
+        "entity.SectionAvailability": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "integer"
                 },
-                "type": {
+                "name": {
                     "type": "string"
                 },
-                "usage_limit": {
+                "price": {
                     "type": "integer"
                 },
-                "valid_from": {
-                    "type": "string"
+                "seats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.SeatStatus"
+                    }
                 },
-                "valid_until": {
+                "sectionid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.SectionInput": {
+            "type": "object",
+            "properties": {
+                "name": {
                     "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.RowInput"
+                    }
                 }
             }
         },
-        "entity.Order": {
+        "entity.Segment": {
             "type": "object",
             "properties": {
-                "adressid": {
+                "days": {
                     "type": "integer"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "paymentid": {
-                    "type": "string"
-                },
-                "paymentmethod": {
+                "name": {
                     "type": "string"
                 },
-                "paymentstatus": {
+                "rule": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "string"
+                "ticketid": {
+                    "type": "integer"
                 },
-                "total": {
-                    "type": "number"
+                "walletmax": {
+                    "type": "integer"
                 },
-                "userid": {
+                "walletmin": {
                     "type": "integer"
                 }
             }
@@ -2374,6 +5894,12 @@ const docTemplate = `{
                 },
                 "subcategory": {
                     "type": "string"
+                },
+                "transfercutoff": {
+                    "type": "integer"
+                },
+                "transferdisabled": {
+                    "type": "boolean"
                 }
             }
         },
@@ -2433,11 +5959,123 @@ const docTemplate = `{
                 "ticketid": {
                     "type": "integer"
                 },
+                "transfercutoff": {
+                    "type": "integer"
+                },
+                "transferdisabled": {
+                    "type": "boolean"
+                },
                 "venue": {
                     "type": "string"
                 }
             }
         },
+        "entity.TicketPass": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "orderid": {
+                    "type": "integer"
+                },
+                "seatid": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticketid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.TicketPricing": {
+            "type": "object",
+            "properties": {
+                "baseprice": {
+                    "type": "integer"
+                },
+                "currentprice": {
+                    "type": "integer"
+                },
+                "demandrules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.DemandRule"
+                    }
+                },
+                "schedules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.PriceSchedule"
+                    }
+                },
+                "ticketid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.TicketTransfer": {
+            "type": "object",
+            "properties": {
+                "completedat": {
+                    "type": "string"
+                },
+                "fromuserid": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "requestedat": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "ticketid": {
+                    "type": "integer"
+                },
+                "ticketpassid": {
+                    "type": "integer"
+                },
+                "touserid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.TotpEnrolment": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string"
+                },
+                "uri": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.UsedCoupon": {
+            "type": "object",
+            "properties": {
+                "couponcode": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "orderid": {
+                    "type": "integer"
+                },
+                "userid": {
+                    "type": "integer"
+                }
+            }
+        },
         "entity.User": {
             "type": "object",
             "required": [
@@ -2450,6 +6088,10 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "emailverified": {
+                    "description": "EmailVerified is set once the user follows the link mailed to Email,\nand cleared when Email changes. Only verified addresses can log in.",
+                    "type": "boolean"
+                },
                 "firstname": {
                     "type": "string"
                 },
@@ -2459,11 +6101,57 @@ const docTemplate = `{
                 "phone": {
                     "type": "string"
                 },
+                "referralcode": {
+                    "type": "string"
+                },
                 "wallet": {
                     "type": "integer"
                 }
             }
         },
+        "entity.WaitlistEntry": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "productid": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reserveduntil": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.WaitlistReport": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "productid": {
+                    "type": "integer"
+                },
+                "productname": {
+                    "type": "string"
+                },
+                "reserved": {
+                    "type": "integer"
+                },
+                "waiting": {
+                    "type": "integer"
+                }
+            }
+        },
         "entity.Wishlist": {
             "type": "object",
             "properties": {
@@ -2517,14 +6205,19 @@ const docTemplate = `{
                 },
                 "phone": {
                     "type": "string"
+                },
+                "referredby": {
+                    "description": "ReferredBy is the referral code of the user who invited them, if any.",
+                    "type": "string"
                 }
             }
         }
     },
     "securityDefinitions": {
         "JWT": {
+            "description": "Access token from login or refresh, as \"Bearer \u003ctoken\u003e\". The Authorise cookie works too.",
             "type": "apiKey",
-            "name": "token",
+            "name": "Authorization",
             "in": "header"
         }
     }
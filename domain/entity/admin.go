@@ -2,14 +2,38 @@ package entity
 
 import "gorm.io/gorm"
 
+// Admin is a staff account. Role is one of the roles in utils.AdminRoles and
+// decides what the admin may do; deactivated admins cannot log in.
 type Admin struct {
 	gorm.Model `json:"-"`
+	ID         int    `gorm:"primarykey" json:"id"`
 	AdminName  string `json:"adminname"`
 	Email      string `json:"email"`
 	Phone      string `json:"phone"`
-	Password   string `json:"password"`
+	Password   string `json:"-"`
 	Role       string `json:"role"`
-	Active     bool   `gorm:"not null;default true"`
+	Active     bool   `gorm:"not null;default:true" json:"active"`
+	// TotpSecret is set on enrolment and only used for login once
+	// TotpEnabled; TotpLastStep is the last step accepted, so a code cannot
+	// be replayed.
+	TotpSecret   string `json:"-"`
+	TotpEnabled  bool   `gorm:"not null;default:false" json:"totpenabled"`
+	TotpLastStep int64  `json:"-"`
+}
+
+// AdminInput is what an admin is registered with; the password only ever
+// comes in, it is never sent back.
+type AdminInput struct {
+	AdminName string `json:"adminname"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	Password  string `json:"password"`
+	Role      string `json:"role"`
+}
+
+type AdminRole struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
 }
 
 type AdminDashboard struct {
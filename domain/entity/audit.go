@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// AuditEntry records one call to a mutating admin endpoint. Entries are only
+// ever inserted; the database refuses updates and deletes. Request holds the
+// path parameters and body, and Diff the fields of the target that changed,
+// both as JSON with secrets redacted.
+type AuditEntry struct {
+	ID        int       `gorm:"primarykey" json:"id"`
+	AdminId   int       `gorm:"index" json:"adminid"`
+	Action    string    `gorm:"index" json:"action"`
+	Entity    string    `gorm:"index" json:"entity"`
+	EntityId  int       `gorm:"index" json:"entityid"`
+	Request   string    `gorm:"type:text" json:"request"`
+	Diff      string    `gorm:"type:text" json:"diff"`
+	Status    int       `json:"status"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `gorm:"index" json:"createdat"`
+}
+
+type AuditFilter struct {
+	AdminId  int
+	Entity   string
+	EntityId int
+	Action   string
+	From     time.Time
+	To       time.Time
+}
+
+type AuditLog struct {
+	Total   int          `json:"total"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+// AuditChange is a field's value before and after an audited call.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
@@ -4,11 +4,30 @@ import "gorm.io/gorm"
 
 type Cart struct {
 	gorm.Model      `json:"-"`
-	UserId          int     `json:"-"`
-	ApparelQuantity int     `json:"apparelquantity"`
-	TicketQuantity  int     `json:"ticketquantity"`
-	TotalPrice      float64 `json:"totalprice"`
-	OfferPrice      int     `json:"offerprice"`
+	UserId          int                `json:"-"`
+	ApparelQuantity int                `json:"apparelquantity"`
+	TicketQuantity  int                `json:"ticketquantity"`
+	TotalPrice      float64            `json:"totalprice"`
+	OfferPrice      int                `json:"offerprice"`
+	CouponCode      string             `json:"couponcode"`
+	LoyaltyPoints   int                `json:"loyaltypoints"`
+	GiftCardId      int                `json:"giftcardid"`
+	OfferNote       string             `json:"offernote"`
+	Promotions      []AppliedPromotion `gorm:"-" json:"promotions"`
+}
+
+// AppliedPromotion is one line of a discount breakdown. Rows belong to a cart
+// until checkout, when they are copied onto the order.
+type AppliedPromotion struct {
+	gorm.Model  `json:"-"`
+	ID          int    `gorm:"primarykey" json:"-"`
+	CartId      int    `json:"-"`
+	OrderId     int    `json:"-"`
+	Kind        string `json:"kind"`
+	PromotionId int    `json:"promotionid"`
+	Name        string `json:"name"`
+	Amount      int    `json:"amount"`
+	Position    int    `json:"position"`
 }
 
 type CartItem struct {
@@ -19,6 +38,7 @@ type CartItem struct {
 	Quantity    int     `json:"quantity"`
 	ProductName string  `json:"productname"`
 	Price       float64 `json:"price"`
+	SeatId      int     `json:"seatid"`
 }
 
 type Wishlist struct {
@@ -29,3 +49,25 @@ type Wishlist struct {
 	ProductName string  `json:"productname"`
 	Price       float64 `json:"price"`
 }
+
+// CartChange describes a cart line that no longer matched the catalogue when
+// the cart was re-validated.
+type CartChange struct {
+	Category    string  `json:"category"`
+	ProductId   int     `json:"productid"`
+	SeatId      int     `json:"seatid,omitempty"`
+	ProductName string  `json:"productname"`
+	Reason      string  `json:"reason"`
+	OldPrice    float64 `json:"oldprice"`
+	NewPrice    float64 `json:"newprice"`
+	OldQuantity int     `json:"oldquantity"`
+	NewQuantity int     `json:"newquantity"`
+}
+
+type CartReview struct {
+	Cart          Cart         `json:"cart"`
+	Items         []CartItem   `json:"items"`
+	Changes       []CartChange `json:"changes"`
+	PreviousTotal float64      `json:"previoustotal"`
+	PreviousOffer int          `json:"previousoffer"`
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// Email token purposes.
+const (
+	EmailTokenReset  = "reset"
+	EmailTokenVerify = "verify"
+)
+
+// EmailToken is a single-use token mailed to a user, to reset their password
+// or to verify their address. Only its hash is kept.
+type EmailToken struct {
+	ID        int       `gorm:"primarykey"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	UserId    int       `gorm:"index;not null"`
+	Purpose   string    `gorm:"not null"`
+	Email     string    `gorm:"not null"`
+	Used      bool      `gorm:"not null;default:false"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// Mail is a plain text email.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
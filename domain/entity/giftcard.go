@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GiftCard is a stored value card bought at checkout or issued by an admin.
+// A bought card waits in the cart as "pending" and only gets its code once the
+// order is paid. The code is the secret: whoever holds it can spend the card,
+// so only its hash is stored and the code is shown once, when it is issued.
+//
+// Statuses: pending, active, redeemed (balance moved to a wallet) and void.
+type GiftCard struct {
+	gorm.Model     `json:"-"`
+	ID             int       `gorm:"primarykey" json:"id"`
+	CodeHash       string    `gorm:"index" json:"-"`
+	CodeHint       string    `json:"codehint"`
+	Amount         int       `json:"amount"`
+	Balance        int       `json:"balance"`
+	Status         string    `json:"status"`
+	PurchaserId    int       `gorm:"index" json:"purchaserid"`
+	OrderId        int       `json:"orderid"`
+	IssuedBy       int       `json:"issuedby"`
+	RecipientEmail string    `json:"recipientemail"`
+	RecipientPhone string    `json:"recipientphone"`
+	Message        string    `json:"message"`
+	ExpiresAt      time.Time `json:"expiresat"`
+	// Code is only filled in on the card returned when it is issued.
+	Code string `gorm:"-" json:"code,omitempty"`
+}
+
+// GiftCardTxn is one movement on a gift card's balance. Amounts are signed:
+// issuing and refunds add to the balance, payments, wallet redemptions and
+// voiding take from it.
+type GiftCardTxn struct {
+	gorm.Model `json:"-"`
+	ID         int       `gorm:"primarykey" json:"id"`
+	GiftCardId int       `gorm:"index" json:"giftcardid"`
+	UserId     int       `json:"userid"`
+	OrderId    int       `json:"orderid"`
+	Kind       string    `json:"kind"`
+	Amount     int       `json:"amount"`
+	Date       time.Time `json:"date"`
+}
+
+type GiftCardInput struct {
+	Amount         int    `json:"amount" binding:"required"`
+	RecipientEmail string `json:"recipientemail"`
+	RecipientPhone string `json:"recipientphone"`
+	Message        string `json:"message"`
+	ValidityDays   int    `json:"validitydays"`
+}
+
+type GiftCardOptions struct {
+	Denominations []int `json:"denominations"`
+	MinAmount     int   `json:"minamount"`
+	MaxAmount     int   `json:"maxamount"`
+	ValidityDays  int   `json:"validitydays"`
+}
+
+type GiftCardBalance struct {
+	CodeHint  string    `json:"codehint"`
+	Balance   int       `json:"balance"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expiresat"`
+}
+
+type GiftCardDetail struct {
+	Card         GiftCard      `json:"card"`
+	Transactions []GiftCardTxn `json:"transactions"`
+}
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoyaltySetting holds the earning and redemption rules for loyalty points.
+// Rates are points per 100 spent in the category. Until an admin saves a
+// setting the defaults in the loyalty repository apply.
+type LoyaltySetting struct {
+	gorm.Model       `json:"-"`
+	ID               int  `gorm:"primarykey" json:"-"`
+	TicketRate       int  `json:"ticketrate"`
+	ApparelRate      int  `json:"apparelrate"`
+	PointValue       int  `json:"pointvalue"`
+	ReturnWindowDays int  `json:"returnwindowdays"`
+	ExpiryDays       int  `json:"expirydays"`
+	MaxRedeemPercent int  `json:"maxredeempercent"`
+	Active           bool `json:"active"`
+}
+
+// LoyaltyEntry is one line of a user's points ledger. Earned and restored
+// entries are lots that are spent oldest-expiry first; Remaining is what is
+// left of the lot.
+//
+// Kinds: earn, redeem, restore, expire, clawback.
+// Earned lots are pending until the return window closes, then available.
+type LoyaltyEntry struct {
+	gorm.Model  `json:"-"`
+	ID          int       `gorm:"primarykey" json:"id"`
+	UserId      int       `gorm:"index" json:"-"`
+	OrderId     int       `json:"orderid"`
+	Category    string    `json:"category,omitempty"`
+	Kind        string    `json:"kind"`
+	Points      int       `json:"points"`
+	Remaining   int       `json:"-"`
+	Status      string    `json:"status"`
+	Date        time.Time `json:"date"`
+	AvailableAt time.Time `json:"availableat"`
+	ExpiresAt   time.Time `json:"expiresat"`
+}
+
+type LoyaltyBalance struct {
+	Available int            `json:"available"`
+	Pending   int            `json:"pending"`
+	Value     int            `json:"value"`
+	History   []LoyaltyEntry `json:"history"`
+}
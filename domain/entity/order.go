@@ -1,19 +1,31 @@
 package entity
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type Order struct {
 	gorm.Model    `json:"-"`
-	ID            int     `gorm:"primarykey" json:"id"`
-	UserID        int     `json:"userid"`
-	AddressId     int     `json:"adressid"`
-	Total         float64 `json:"total"`
-	Status        string  `json:"status"`
-	PaymentMethod string  `json:"paymentmethod"`
-	PaymentStatus string  `json:"paymentstatus"`
-	PaymentId     string  `json:"paymentid"`
+	ID            int                `gorm:"primarykey" json:"id"`
+	UserID        int                `json:"userid"`
+	AddressId     int                `json:"adressid"`
+	Total         float64            `json:"total"`
+	Status        string             `json:"status"`
+	PaymentMethod string             `json:"paymentmethod"`
+	PaymentStatus string             `json:"paymentstatus"`
+	PaymentId     string             `json:"paymentid"`
+	CouponCode    string             `json:"couponcode"`
+	Discount      int                `json:"discount"`
+	GiftCardPaid  int                `json:"giftcardpaid"`
+	WalletPaid    int                `json:"walletpaid"`
+	GatewayPayId  string             `json:"gatewaypayid"`
+	DeliveredAt   time.Time          `json:"deliveredat"`
+	Promotions    []AppliedPromotion `gorm:"-" json:"promotions"`
+	// OfferId is only set on orders placed before the discount breakdown was
+	// stored; startup migrates it into Promotions.
+	OfferId int `json:"-"`
 }
 
 type OrderItem struct {
@@ -23,29 +35,57 @@ type OrderItem struct {
 	Category   string  `json:"category"`
 	Quantity   int     `json:"quantity"`
 	Price      float64 `json:"price"`
+	SeatId     int     `json:"seatid"`
 }
 
+// Return is a user's request to return a fulfilled order.
+//
+// Statuses: Initiated, approved, rejected and completed (refunded).
+// OrderStatus is what the order was before the request, restored when the
+// return is rejected.
 type Return struct {
-	gorm.Model `json:"-"`
-	OrderId    int    `json:"orderid"`
-	UserId     int    `json:"userid"`
-	Reason     string `json:"reason"`
-	Status     string `json:"status"`
-	Refund     string `json:"refund"`
-	TotalPrice int    `json:"totalprice"`
+	gorm.Model  `json:"-"`
+	OrderId     int    `json:"orderid"`
+	UserId      int    `json:"userid"`
+	Reason      string `json:"reason"`
+	Status      string `json:"status"`
+	Refund      string `json:"refund"`
+	TotalPrice  int    `json:"totalprice"`
+	OrderStatus string `json:"-"`
 }
 
 type Invoice struct {
 	gorm.Model  `json:"-"`
-	OrderId     int     `json:"orderid"`
-	UserId      int     `json:"userid"`
-	AddressType string  `json:"addresstype"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
-	Payment     string  `json:"payment"`
-	Status      string  `json:"status"`
-	PaymentId   string  `json:"paymentid"`
-	Remark      string  `json:"remark" gorm:"default zog_festiv"`
+	OrderId     int                `json:"orderid"`
+	UserId      int                `json:"userid"`
+	AddressType string             `json:"addresstype"`
+	Quantity    int                `json:"quantity"`
+	Price       float64            `json:"price"`
+	Payment     string             `json:"payment"`
+	Status      string             `json:"status"`
+	PaymentId   string             `json:"paymentid"`
+	Discount    int                `json:"discount"`
+	GiftCard    int                `json:"giftcard"`
+	Wallet      int                `json:"wallet"`
+	Remark      string             `json:"remark" gorm:"default zog_festiv"`
+	Promotions  []AppliedPromotion `gorm:"-" json:"promotions"`
+	// GiftCards carries the codes of the cards the order bought, which are
+	// only shown here.
+	GiftCards []GiftCard `gorm:"-" json:"giftcards,omitempty"`
+}
+
+// WalletHold is the wallet share of a split payment. The balance is taken when
+// the order is placed and the hold is captured once the gateway confirms the
+// rest, or released back to the wallet if the gateway payment fails. A hold
+// that never got attached to an order is released once it expires.
+type WalletHold struct {
+	gorm.Model `json:"-"`
+	ID         int       `gorm:"primarykey" json:"id"`
+	UserId     int       `json:"userid"`
+	OrderId    int       `gorm:"index" json:"orderid"`
+	Amount     int       `json:"amount"`
+	Status     string    `json:"status"`
+	ExpiresAt  time.Time `json:"expiresat"`
 }
 
 type SalesReport struct {
@@ -7,17 +7,49 @@ import (
 )
 
 type Ticket struct {
-	gorm.Model  `json:"-"`
-	ID          int       `gorm:"primarykey"`
-	Name        string    `json:"name"`
-	Price       int       `json:"price"`
-	Date        time.Time `json:"date"`
-	Location    string    `json:"location"`
-	ImageURL    string    `json:"imageurl"`
-	Removed     bool      `json:"-" gorm:"default false"`
-	Category    string    `json:"category" gorm:"default ticket"`
-	SubCategory string    `json:"subcategory"`
-	AdminId     int       `json:"-"`
+	gorm.Model       `json:"-"`
+	ID               int       `gorm:"primarykey"`
+	Name             string    `json:"name"`
+	Price            int       `json:"price"`
+	Date             time.Time `json:"date"`
+	Location         string    `json:"location"`
+	ImageURL         string    `json:"imageurl"`
+	Removed          bool      `json:"-" gorm:"default false"`
+	Category         string    `json:"category" gorm:"default ticket"`
+	SubCategory      string    `json:"subcategory"`
+	AdminId          int       `json:"-"`
+	TransferDisabled bool      `json:"transferdisabled"`
+	TransferCutoff   int       `json:"transfercutoff"`
+}
+
+// PriceSchedule fixes the ticket price between StartsAt and EndsAt, used for
+// early bird, regular and last-minute windows.
+type PriceSchedule struct {
+	gorm.Model `json:"-"`
+	ID         int       `gorm:"primarykey" json:"id"`
+	TicketId   int       `json:"-"`
+	Name       string    `json:"name"`
+	Price      int       `json:"price"`
+	StartsAt   time.Time `json:"startsat"`
+	EndsAt     time.Time `json:"endsat"`
+}
+
+// DemandRule raises the price by Increase percent once the remaining stock
+// drops to Remaining or below.
+type DemandRule struct {
+	gorm.Model `json:"-"`
+	ID         int `gorm:"primarykey" json:"id"`
+	TicketId   int `json:"-"`
+	Remaining  int `json:"remaining"`
+	Increase   int `json:"increase"`
+}
+
+type TicketPricing struct {
+	TicketId     int             `json:"ticketid"`
+	BasePrice    int             `json:"baseprice"`
+	CurrentPrice int             `json:"currentprice"`
+	Schedules    []PriceSchedule `json:"schedules"`
+	DemandRules  []DemandRule    `json:"demandrules"`
 }
 
 type TicketDetails struct {
@@ -63,21 +95,30 @@ type Inventory struct {
 	Quantity        int
 }
 
+// Coupon is a code the user applies to the cart. Category and ProductId scope
+// the discount to matching lines, a zero limit or cap means unlimited and a
+// zero PerUserLimit allows one use per user.
 type Coupon struct {
-	Id         int       `json:"-" gorm:"primarykey"`
-	Code       string    `json:"code"`
-	Type       string    `json:"type"`
-	Amount     int       `json:"amount"`
-	ValidFrom  time.Time `json:"-"`
-	ValidUntil time.Time `json:"valid_until"`
-	UsageLimit int       `json:"usage_limit"`
-	UsedCount  int       `json:"-"`
-	Category   string    `json:"category"`
-	AdminId    int       `json:"-"`
+	Id           int       `json:"id" gorm:"primarykey"`
+	Code         string    `json:"code"`
+	Type         string    `json:"type"`
+	Amount       int       `json:"amount"`
+	ValidFrom    time.Time `json:"valid_from"`
+	ValidUntil   time.Time `json:"valid_until"`
+	UsageLimit   int       `json:"usage_limit"`
+	PerUserLimit int       `json:"per_user_limit"`
+	UsedCount    int       `json:"-"`
+	MinOrder     int       `json:"min_order"`
+	MaxDiscount  int       `json:"max_discount"`
+	Category     string    `json:"category"`
+	ProductId    int       `json:"productid"`
+	SegmentId    int       `json:"segmentid"`
+	Active       bool      `json:"active" gorm:"default:true"`
+	AdminId      int       `json:"-"`
 }
 
 type Offer struct {
-	Id         int       `json:"-" gorm:"primarykey"`
+	Id         int       `json:"id" gorm:"primarykey"`
 	Name       string    `json:"name"`
 	Type       string    `json:"type"`
 	Amount     int       `json:"amount"`
@@ -87,10 +128,75 @@ type Offer struct {
 	UsageLimit int       `json:"usage_limit"`
 	UsedCount  int       `json:"-"`
 	Category   string    `json:"category"`
+	SegmentId  int       `json:"segmentid"`
+	Active     bool      `json:"active" gorm:"default:true"`
 	AdminId    int       `json:"-"`
 }
 
 type UsedCoupon struct {
+	gorm.Model `json:"-"`
+	ID         int    `gorm:"primarykey" json:"id"`
 	UserId     int    `json:"userid"`
 	CouponCode string `json:"couponcode"`
+	OrderId    int    `json:"orderid"`
+}
+
+type CouponUsage struct {
+	Coupon Coupon       `json:"coupon"`
+	Uses   []UsedCoupon `json:"uses"`
+}
+
+// CouponCampaign holds the rules shared by a batch of generated single-use
+// coupon codes.
+type CouponCampaign struct {
+	gorm.Model  `json:"-"`
+	ID          int       `gorm:"primarykey" json:"id"`
+	Name        string    `json:"name"`
+	Prefix      string    `json:"prefix"`
+	Length      int       `json:"length"`
+	Alphabet    string    `json:"alphabet"`
+	Count       int       `json:"count"`
+	Type        string    `json:"type"`
+	Amount      int       `json:"amount"`
+	ValidFrom   time.Time `json:"valid_from"`
+	ValidUntil  time.Time `json:"valid_until"`
+	MinOrder    int       `json:"min_order"`
+	MaxDiscount int       `json:"max_discount"`
+	Category    string    `json:"category"`
+	ProductId   int       `json:"productid"`
+	SegmentId   int       `json:"segmentid"`
+	Active      bool      `json:"active" gorm:"default:true"`
+	AdminId     int       `json:"-"`
+}
+
+// CampaignCode is one generated code. It carries no rules of its own so that
+// campaigns with thousands of codes stay small.
+type CampaignCode struct {
+	ID         int        `gorm:"primarykey" json:"-"`
+	CampaignId int        `gorm:"index" json:"-"`
+	Code       string     `gorm:"uniqueIndex" json:"code"`
+	UsedBy     int        `json:"usedby"`
+	OrderId    int        `json:"orderid"`
+	UsedAt     *time.Time `json:"usedat"`
+}
+
+type CampaignStats struct {
+	Campaign  CouponCampaign `json:"campaign"`
+	Generated int            `json:"generated"`
+	Redeemed  int            `json:"redeemed"`
+	Remaining int            `json:"remaining"`
+	Orders    int            `json:"orders"`
+	Discount  int            `json:"discount"`
+}
+
+// PromotionRule decides how coupons and offers combine on a cart. Until an
+// admin saves one, a coupon replaces any offer and only the best offer applies.
+type PromotionRule struct {
+	gorm.Model            `json:"-"`
+	ID                    int  `gorm:"primarykey" json:"-"`
+	StackCouponWithOffers bool `json:"stackcouponwithoffers"`
+	StackOffers           bool `json:"stackoffers"`
+	CouponFirst           bool `json:"couponfirst"`
+	MaxDiscount           int  `json:"maxdiscount"`
+	MaxDiscountPercent    int  `json:"maxdiscountpercent"`
 }
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Referral records the outcome for a referred user once their first order
+// completed. Users still waiting on that order have no row yet.
+//
+// Statuses: rewarded, rejected, clawed back (the order was cancelled or
+// returned after the reward was paid).
+type Referral struct {
+	gorm.Model     `json:"-"`
+	ID             int       `gorm:"primarykey" json:"id"`
+	ReferrerId     int       `json:"referrerid"`
+	RefereeId      int       `gorm:"uniqueIndex" json:"refereeid"`
+	OrderId        int       `json:"orderid"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason"`
+	ReferrerCredit int       `json:"referrercredit"`
+	RefereeCredit  int       `json:"refereecredit"`
+	ProcessedAt    time.Time `json:"processedat"`
+}
+
+// ReferralSetting holds the wallet credit given for a converted referral.
+// Until an admin saves one the defaults in the referral repository apply.
+type ReferralSetting struct {
+	gorm.Model     `json:"-"`
+	ID             int  `gorm:"primarykey" json:"-"`
+	ReferrerCredit int  `json:"referrercredit"`
+	RefereeCredit  int  `json:"refereecredit"`
+	Active         bool `json:"active"`
+}
+
+type ReferralSummary struct {
+	Code      string     `json:"code"`
+	Invited   int        `json:"invited"`
+	Earned    int        `json:"earned"`
+	Referrals []Referral `json:"referrals"`
+}
+
+type ReferralReport struct {
+	Invited   int        `json:"invited"`
+	Pending   int        `json:"pending"`
+	Converted int        `json:"converted"`
+	Rejected  int        `json:"rejected"`
+	Credited  int        `json:"credited"`
+	Referrals []Referral `json:"referrals"`
+}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SeatSection struct {
+	gorm.Model `json:"-"`
+	ID         int    `gorm:"primarykey" json:"id"`
+	TicketId   int    `json:"ticketid"`
+	Name       string `json:"name"`
+	Price      int    `json:"price"`
+}
+
+type Seat struct {
+	gorm.Model `json:"-"`
+	ID         int       `gorm:"primarykey" json:"id"`
+	TicketId   int       `json:"ticketid" gorm:"uniqueIndex:idx_seat_position"`
+	SectionId  int       `json:"sectionid" gorm:"uniqueIndex:idx_seat_position"`
+	RowLabel   string    `json:"row" gorm:"uniqueIndex:idx_seat_position"`
+	Number     int       `json:"number" gorm:"uniqueIndex:idx_seat_position"`
+	Status     string    `json:"status"`
+	HeldBy     int       `json:"-"`
+	HeldUntil  time.Time `json:"-"`
+}
+
+type SeatMapInput struct {
+	Sections []SectionInput `json:"sections"`
+}
+
+type SectionInput struct {
+	Name  string     `json:"name"`
+	Price int        `json:"price"`
+	Rows  []RowInput `json:"rows"`
+}
+
+type RowInput struct {
+	Row   string `json:"row"`
+	Seats int    `json:"seats"`
+}
+
+type SectionAvailability struct {
+	SectionId int          `json:"sectionid"`
+	Name      string       `json:"name"`
+	Price     int          `json:"price"`
+	Available int          `json:"available"`
+	Seats     []SeatStatus `json:"seats"`
+}
+
+type SeatStatus struct {
+	SeatId int    `json:"seatid"`
+	Row    string `json:"row"`
+	Number int    `json:"number"`
+	Status string `json:"status"`
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Segment is a group of customers described by a rule. Coupons, offers and
+// campaigns can be limited to a single segment.
+//
+// Rules:
+//   - new: users without an order, optionally registered in the last Days days
+//   - lapsed: users who have ordered before but not in the last Days days (90
+//     when unset)
+//   - event: users who bought tickets for TicketId
+//   - wallet: users whose wallet balance is between WalletMin and WalletMax,
+//     where a WalletMax of 0 means no upper bound
+type Segment struct {
+	gorm.Model `json:"-"`
+	ID         int    `gorm:"primarykey" json:"id"`
+	Name       string `json:"name"`
+	Rule       string `json:"rule"`
+	Days       int    `json:"days"`
+	TicketId   int    `json:"ticketid"`
+	WalletMin  int    `json:"walletmin"`
+	WalletMax  int    `json:"walletmax"`
+}
+
+// CustomerProfile holds the facts about a user that segment rules look at.
+type CustomerProfile struct {
+	UserId      int
+	JoinedAt    time.Time
+	Wallet      int
+	Orders      int
+	LastOrderAt time.Time
+	TicketIds   []int
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a refresh token kept server-side; only its hash is stored.
+// Every use replaces it with a new token of the same family, so a token seen
+// a second time means it was stolen and the whole family is revoked.
+type RefreshToken struct {
+	gorm.Model `json:"-"`
+	ID         int       `gorm:"primarykey" json:"id"`
+	TokenHash  string    `gorm:"uniqueIndex" json:"-"`
+	Family     string    `gorm:"index" json:"-"`
+	SubjectId  int       `gorm:"index" json:"subjectid"`
+	Role       string    `json:"role"`
+	Phone      string    `json:"-"`
+	ExpiresAt  time.Time `json:"expiresat"`
+	Used       bool      `json:"used"`
+	Revoked    bool      `json:"revoked"`
+}
+
+// TokenRevocation revokes a single access token by its jti, or, when Jti is
+// empty, every access token of the subject issued up to IssuedBefore.
+// Entries are dropped once the tokens they cover have expired anyway.
+type TokenRevocation struct {
+	gorm.Model   `json:"-"`
+	ID           int       `gorm:"primarykey" json:"id"`
+	Jti          string    `gorm:"index" json:"jti"`
+	SubjectId    int       `gorm:"index" json:"subjectid"`
+	Role         string    `json:"role"`
+	IssuedBefore time.Time `json:"issuedbefore"`
+	ExpiresAt    time.Time `json:"expiresat"`
+}
+
+// AuthTokens is returned by login and refresh for clients that do not keep
+// cookies. AccessToken goes in an Authorization: Bearer header.
+type AuthTokens struct {
+	AccessToken  string `json:"accesstoken"`
+	RefreshToken string `json:"refreshtoken"`
+	TokenType    string `json:"tokentype"`
+	ExpiresIn    int    `json:"expiresin"`
+}
+
+// RateCounter is a rate limit counter in the shared store. The count starts
+// over once ResetAt has passed.
+type RateCounter struct {
+	Key     string    `gorm:"primarykey"`
+	Count   int       `gorm:"not null"`
+	ResetAt time.Time `gorm:"index"`
+}
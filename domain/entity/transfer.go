@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TicketPass struct {
+	gorm.Model `json:"-"`
+	ID         int    `gorm:"primarykey" json:"id"`
+	OrderId    int    `json:"orderid"`
+	TicketId   int    `json:"ticketid"`
+	SeatId     int    `json:"seatid"`
+	OwnerId    int    `json:"-"`
+	Code       string `json:"code" gorm:"uniqueIndex"`
+	Status     string `json:"status"`
+}
+
+type TicketTransfer struct {
+	gorm.Model   `json:"-"`
+	ID           int       `gorm:"primarykey" json:"id"`
+	TicketPassId int       `json:"ticketpassid"`
+	TicketId     int       `json:"ticketid"`
+	FromUserId   int       `json:"fromuserid"`
+	ToUserId     int       `json:"touserid"`
+	Status       string    `json:"status"`
+	OldCode      string    `json:"-"`
+	NewCode      string    `json:"-"`
+	RequestedAt  time.Time `json:"requestedat"`
+	CompletedAt  time.Time `json:"completedat"`
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// RecoveryCode is a one-time code an admin can use instead of a TOTP code
+// when their authenticator is lost. Only its hash is kept.
+type RecoveryCode struct {
+	ID        int       `gorm:"primarykey" json:"-"`
+	AdminId   int       `gorm:"index;not null" json:"-"`
+	CodeHash  string    `gorm:"uniqueIndex;not null" json:"-"`
+	Used      bool      `gorm:"not null;default:false" json:"-"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// TwoFactorChallenge is handed out when the password or OTP was right but a
+// TOTP code is still needed. Only its hash is kept.
+type TwoFactorChallenge struct {
+	ID        int       `gorm:"primarykey"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	AdminId   int       `gorm:"not null"`
+	Attempts  int       `gorm:"not null;default:0"`
+	Used      bool      `gorm:"not null;default:false"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// TotpEnrolment is what an authenticator app needs to be set up. Secret is
+// for typing in by hand when the QR code cannot be scanned.
+type TotpEnrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorLogin is the login response when a TOTP code is still needed.
+// Enrolment is set when the admin must first set up an authenticator.
+type TwoFactorLogin struct {
+	Challenge string         `json:"challenge"`
+	ExpiresIn int            `json:"expiresin"`
+	Enrolment *TotpEnrolment `json:"enrolment,omitempty"`
+}
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -10,10 +12,15 @@ type User struct {
 	FirstName  string `json:"firstname" bson:"firstname" binding:"required"`
 	LastName   string `json:"lastname" bson:"lastname" binding:"required"`
 	Email      string `json:"email" bson:"email" binding:"required"`
-	Phone      string `json:"phone" bson:"phone" binding:"required"`
-	Password   string `json:"-" bson:"password" binding:"required"`
-	Wallet     int    `json:"wallet"`
-	Permission bool   `gorm:"not null;default:true" json:"-"`
+	// EmailVerified is set once the user follows the link mailed to Email,
+	// and cleared when Email changes. Only verified addresses can log in.
+	EmailVerified bool   `gorm:"not null;default:false" json:"emailverified"`
+	Phone         string `json:"phone" bson:"phone" binding:"required"`
+	Password      string `json:"-" bson:"password" binding:"required"`
+	Wallet        int    `json:"wallet"`
+	Permission    bool   `gorm:"not null;default:true" json:"-"`
+	ReferralCode  string `gorm:"index" json:"referralcode"`
+	ReferredBy    int    `json:"-"`
 }
 
 type Address struct {
@@ -32,8 +39,32 @@ type Login struct {
 	Password string `json:"password" bson:"Password" binding:"required"`
 }
 
+// Otp purposes.
+const (
+	OtpSignup     = "signup"
+	OtpLogin      = "login"
+	OtpPassword   = "password"
+	OtpAdminLogin = "admin login"
+)
+
+// OtpKey maps the key handed to the client back to the phone an otp was sent
+// to and what it was sent for. Codes generated by the shop itself are kept
+// here hashed; they are empty for codes checked by Twilio Verify.
 type OtpKey struct {
 	gorm.Model
-	Key   string `json:"key"`
-	Phone string `json:"phone"`
+	Key       string    `gorm:"index" json:"key"`
+	Phone     string    `gorm:"index" json:"phone"`
+	Purpose   string    `gorm:"index" json:"-"`
+	CodeHash  string    `json:"-"`
+	ExpiresAt time.Time `json:"-"`
+	Attempts  int       `json:"-"`
+	Used      bool      `json:"-"`
+}
+
+type Notification struct {
+	gorm.Model `json:"-"`
+	ID         int    `gorm:"primarykey" json:"id"`
+	UserId     int    `json:"-"`
+	Title      string `json:"title"`
+	Message    string `json:"message"`
 }
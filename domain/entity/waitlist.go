@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WaitlistEntry struct {
+	gorm.Model    `json:"-"`
+	ID            int       `gorm:"primarykey" json:"id"`
+	UserId        int       `json:"-"`
+	Category      string    `json:"category"`
+	ProductId     int       `json:"productid"`
+	Quantity      int       `json:"quantity"`
+	Status        string    `json:"status"`
+	ReservedUntil time.Time `json:"reserveduntil"`
+}
+
+type WaitlistReport struct {
+	Category    string `json:"category"`
+	ProductId   int    `json:"productid"`
+	ProductName string `json:"productname"`
+	Waiting     int    `json:"waiting"`
+	Reserved    int    `json:"reserved"`
+}
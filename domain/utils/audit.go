@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"zog/domain/entity"
+)
+
+const redacted = "[redacted]"
+
+// auditIgnored are columns that change on every write and say nothing.
+var auditIgnored = map[string]bool{"updated_at": true}
+
+// IsSecretField reports whether a field holds a password, hash or token that
+// must not be copied into the audit log.
+func IsSecretField(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "password") || strings.Contains(name, "hash") ||
+		strings.Contains(name, "token") || strings.Contains(name, "secret") || name == "otp"
+}
+
+// AuditDiff lists the fields that differ between two snapshots of a row.
+// Either snapshot may be nil, for rows created or deleted. Secret fields
+// show only that they changed.
+func AuditDiff(before, after map[string]interface{}) map[string]entity.AuditChange {
+	diff := map[string]entity.AuditChange{}
+	for key, old := range before {
+		if auditIgnored[key] {
+			continue
+		}
+		var next interface{}
+		if after != nil {
+			next = after[key]
+		}
+		if after != nil && reflect.DeepEqual(old, next) {
+			continue
+		}
+		diff[key] = auditChange(key, old, next)
+	}
+	for key, next := range after {
+		if auditIgnored[key] {
+			continue
+		}
+		if _, seen := before[key]; seen {
+			continue
+		}
+		diff[key] = auditChange(key, nil, next)
+	}
+	return diff
+}
+
+func auditChange(key string, before, after interface{}) entity.AuditChange {
+	if IsSecretField(key) {
+		return entity.AuditChange{Before: redacted, After: redacted}
+	}
+	return entity.AuditChange{Before: before, After: after}
+}
+
+// RedactSecrets replaces the values of secret fields anywhere in a decoded
+// JSON or form value.
+func RedactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if IsSecretField(key) {
+				v[key] = redacted
+			} else {
+				v[key] = RedactSecrets(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = RedactSecrets(inner)
+		}
+	}
+	return value
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+const CodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func GenerateCode(length int, alphabet string) (string, error) {
+	if length < 1 || len(alphabet) < 2 {
+		return "", errors.New("Invalid code format")
+	}
+	max := big.NewInt(int64(len(alphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.New("Code generation failed")
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"zog/domain/entity"
+)
+
+// CouponDiscount works out what a coupon takes off the cart at the given time.
+// Only lines matching the coupon's category and product count towards the
+// discount, while the minimum order is checked against the whole cart. Gift
+// cards in the cart count towards neither.
+func CouponDiscount(coupon *entity.Coupon, cartItems []entity.CartItem, now time.Time) (int, error) {
+	if !coupon.Active {
+		return 0, errors.New("Coupon is not active")
+	}
+	if now.Before(coupon.ValidFrom) {
+		return 0, errors.New("Coupon is not active yet")
+	}
+	if now.After(coupon.ValidUntil) {
+		return 0, errors.New("Coupon has expired")
+	}
+	var cartTotal, eligible float64
+	for _, cartItem := range cartItems {
+		if cartItem.Category == "giftcard" {
+			continue
+		}
+		lineTotal := cartItem.Price * float64(cartItem.Quantity)
+		cartTotal += lineTotal
+		if coupon.Category != "" && cartItem.Category != coupon.Category {
+			continue
+		}
+		if coupon.ProductId != 0 && cartItem.ProductId != coupon.ProductId {
+			continue
+		}
+		eligible += lineTotal
+	}
+	if eligible == 0 {
+		return 0, errors.New("Coupon does not apply to any product in the cart")
+	}
+	if cartTotal < float64(coupon.MinOrder) {
+		return 0, fmt.Errorf("Add products worth %d more to use this coupon", coupon.MinOrder-int(cartTotal))
+	}
+	var discount int
+	if coupon.Type == "percentage" {
+		discount = int(eligible * float64(coupon.Amount) / 100)
+	} else {
+		discount = coupon.Amount
+	}
+	if coupon.MaxDiscount > 0 && discount > coupon.MaxDiscount {
+		discount = coupon.MaxDiscount
+	}
+	if discount > int(eligible) {
+		discount = int(eligible)
+	}
+	return discount, nil
+}
+
+// CampaignCoupon builds the coupon a generated campaign code stands for. Each
+// code can be redeemed once, by one user.
+func CampaignCoupon(campaign *entity.CouponCampaign, code string, used bool) *entity.Coupon {
+	coupon := &entity.Coupon{
+		Code:         code,
+		Type:         campaign.Type,
+		Amount:       campaign.Amount,
+		ValidFrom:    campaign.ValidFrom,
+		ValidUntil:   campaign.ValidUntil,
+		UsageLimit:   1,
+		PerUserLimit: 1,
+		MinOrder:     campaign.MinOrder,
+		MaxDiscount:  campaign.MaxDiscount,
+		Category:     campaign.Category,
+		ProductId:    campaign.ProductId,
+		SegmentId:    campaign.SegmentId,
+		Active:       campaign.Active,
+	}
+	if used {
+		coupon.UsedCount = 1
+	}
+	return coupon
+}
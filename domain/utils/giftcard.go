@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"zog/domain/entity"
+)
+
+// Gift cards come in the fixed denominations below or any custom amount
+// between GiftCardMin and GiftCardMax.
+var GiftCardDenominations = []int{500, 1000, 2000, 5000}
+
+const (
+	GiftCardMin          = 100
+	GiftCardMax          = 10000
+	GiftCardValidityDays = 365
+)
+
+func GiftCardAmountValid(amount int) error {
+	if amount < GiftCardMin || amount > GiftCardMax {
+		return fmt.Errorf("Gift card amount must be between %d and %d", GiftCardMin, GiftCardMax)
+	}
+	return nil
+}
+
+// GiftCardUsable reports why a card cannot be spent at the given time.
+func GiftCardUsable(card *entity.GiftCard, now time.Time) error {
+	switch {
+	case card.Status != "active":
+		return errors.New("Gift card is not active")
+	case now.After(card.ExpiresAt):
+		return errors.New("Gift card has expired")
+	case card.Balance <= 0:
+		return errors.New("Gift card has no balance left")
+	}
+	return nil
+}
+
+// GiftCardCode returns a new 16 character code in groups of four.
+func GiftCardCode() (string, error) {
+	code, err := GenerateCode(16, CodeAlphabet)
+	if err != nil {
+		return "", err
+	}
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+// NormalizeGiftCardCode accepts codes typed in lower case or without dashes.
+func NormalizeGiftCardCode(code string) string {
+	code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 16 {
+		return code
+	}
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+}
+
+// GiftCardCodeHash is how a gift card code is stored and looked up.
+func GiftCardCodeHash(code string) string {
+	return HashToken(NormalizeGiftCardCode(code))
+}
+
+// GiftCardCodeHint is the last group of the code, kept so users can tell their
+// cards apart.
+func GiftCardCodeHint(code string) string {
+	code = NormalizeGiftCardCode(code)
+	if len(code) < 4 {
+		return code
+	}
+	return code[len(code)-4:]
+}
+
+// GiftCardMail delivers a newly issued card's code to its recipient, since
+// the code is not stored anywhere it could be shown again.
+func GiftCardMail(card *entity.GiftCard) *entity.Mail {
+	body := fmt.Sprintf("You received a zog gift card worth %d.\n\nCode: %s\nValid until: %s\n", card.Amount, card.Code, card.ExpiresAt.Format("2006-01-02"))
+	if card.Message != "" {
+		body += "\n" + card.Message + "\n"
+	}
+	return &entity.Mail{To: card.RecipientEmail, Subject: "You received a zog gift card", Body: body}
+}
+
+// GiftCardNotice is the in-app message telling a recipient about a card. It
+// leaves the code out; that only goes by email or to the purchaser.
+func GiftCardNotice(card *entity.GiftCard) string {
+	message := fmt.Sprintf("You received a gift card worth %d ending in %s, valid until %s", card.Amount, card.CodeHint, card.ExpiresAt.Format("2006-01-02"))
+	if card.RecipientEmail != "" {
+		message += ", its code was emailed to " + card.RecipientEmail
+	}
+	if card.Message != "" {
+		message += ": " + card.Message
+	}
+	return message
+}
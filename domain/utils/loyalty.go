@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strings"
+	"time"
+	"zog/domain/entity"
+)
+
+// OrderFulfilled reports whether an order counts as completed: delivered or
+// completed, and either paid or cash on delivery.
+func OrderFulfilled(order *entity.Order) bool {
+	if order.Status != "delivered" && order.Status != "completed" {
+		return false
+	}
+	return order.PaymentStatus == "successful" || order.PaymentMethod == "Cod"
+}
+
+// EarnedPoints works out the points for each order line. Lines are valued at
+// what was actually paid, so discounts reduce the points earned. Gift cards
+// earn nothing; points come when the card is spent.
+func EarnedPoints(setting *entity.LoyaltySetting, order *entity.Order, orderItems []entity.OrderItem, now time.Time) []entity.LoyaltyEntry {
+	var gross float64
+	for _, orderItem := range orderItems {
+		gross += orderItem.Price * float64(orderItem.Quantity)
+	}
+	if gross == 0 {
+		return nil
+	}
+	paidShare := order.Total / gross
+	availableAt := now.AddDate(0, 0, setting.ReturnWindowDays)
+	var entries []entity.LoyaltyEntry
+	for _, orderItem := range orderItems {
+		if orderItem.Category == "giftcard" {
+			continue
+		}
+		rate := setting.ApparelRate
+		if strings.EqualFold(orderItem.Category, "ticket") {
+			rate = setting.TicketRate
+		}
+		points := int(orderItem.Price * float64(orderItem.Quantity) * paidShare * float64(rate) / 100)
+		if points <= 0 {
+			continue
+		}
+		entries = append(entries, entity.LoyaltyEntry{
+			UserId:      order.UserID,
+			OrderId:     order.ID,
+			Category:    orderItem.Category,
+			Kind:        "earn",
+			Points:      points,
+			Remaining:   points,
+			Status:      "pending",
+			Date:        now,
+			AvailableAt: availableAt,
+			ExpiresAt:   availableAt.AddDate(0, 0, setting.ExpiryDays),
+		})
+	}
+	return entries
+}
+
+// RedeemablePoints limits the points a user asked to spend to their balance,
+// to what is left to pay and to the share of the cart points may cover.
+func RedeemablePoints(setting *entity.LoyaltySetting, requested, balance, payable, total int) int {
+	points := requested
+	if points > balance {
+		points = balance
+	}
+	if setting.PointValue <= 0 {
+		return 0
+	}
+	limit := payable
+	if setting.MaxRedeemPercent > 0 && total*setting.MaxRedeemPercent/100 < limit {
+		limit = total * setting.MaxRedeemPercent / 100
+	}
+	if points*setting.PointValue > limit {
+		points = limit / setting.PointValue
+	}
+	if points < 0 {
+		return 0
+	}
+	return points
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"zog/domain/entity"
+)
+
+// OfferDiscount works out what an offer takes off the cart at the given time.
+// The minimum price is checked against the lines in the offer's category, and
+// gift cards are never discounted.
+func OfferDiscount(offer *entity.Offer, cartItems []entity.CartItem, now time.Time) (int, error) {
+	if !offer.Active || now.Before(offer.ValidFrom) || now.After(offer.ValidUntil) {
+		return 0, errors.New("Offer is not valid now")
+	}
+	if offer.UsageLimit > 0 && offer.UsedCount >= offer.UsageLimit {
+		return 0, errors.New("Offer usage limit reached")
+	}
+	eligible := offerBase(offer, cartItems)
+	if eligible == 0 {
+		return 0, errors.New("Offer does not apply to any product in the cart")
+	}
+	if eligible < float64(offer.MinPrice) {
+		return 0, fmt.Errorf("Add products worth %d more to get %s", offer.MinPrice-int(eligible), offer.Name)
+	}
+	var discount int
+	if offer.Type == "percentage" {
+		discount = int(eligible * float64(offer.Amount) / 100)
+	} else {
+		discount = offer.Amount
+	}
+	if discount > int(eligible) {
+		discount = int(eligible)
+	}
+	return discount, nil
+}
+
+// BestOffer picks the offer that saves the most on the cart and explains the
+// choice. When nothing applies the note points at the closest offer instead.
+func BestOffer(offers []entity.Offer, cartItems []entity.CartItem, now time.Time) (*entity.Offer, int, string) {
+	var best *entity.Offer
+	bestDiscount, eligibleCount := 0, 0
+	var closest *entity.Offer
+	shortfall := 0
+	for i := range offers {
+		offer := &offers[i]
+		discount, err := OfferDiscount(offer, cartItems, now)
+		if err != nil {
+			missing := offer.MinPrice - int(offerBase(offer, cartItems))
+			if missing > 0 && (closest == nil || missing < shortfall) {
+				closest = offer
+				shortfall = missing
+			}
+			continue
+		}
+		eligibleCount++
+		if discount > bestDiscount {
+			best = offer
+			bestDiscount = discount
+		}
+	}
+	if best != nil {
+		return best, bestDiscount, fmt.Sprintf("%s applied, saving %d - best of %d eligible offers", best.Name, bestDiscount, eligibleCount)
+	}
+	if closest != nil {
+		return nil, 0, fmt.Sprintf("Add products worth %d more to get %s", shortfall, closest.Name)
+	}
+	return nil, 0, ""
+}
+
+func offerBase(offer *entity.Offer, cartItems []entity.CartItem) float64 {
+	var eligible float64
+	for _, cartItem := range cartItems {
+		if cartItem.Category == "giftcard" {
+			continue
+		}
+		if offer.Category == "" || cartItem.Category == offer.Category {
+			eligible += cartItem.Price * float64(cartItem.Quantity)
+		}
+	}
+	return eligible
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"time"
+	"zog/domain/entity"
+)
+
+// orderProgress ranks the statuses an admin moves an order through.
+// Cancellations and returns have their own flows.
+var orderProgress = map[string]int{
+	"pending":   0,
+	"confirmed": 1,
+	"shipped":   2,
+	"delivered": 3,
+	"completed": 4,
+}
+
+// OrderTransitionAllowed reports whether an admin may move the order to
+// status. Orders only move forward, and online orders not before they are
+// paid.
+func OrderTransitionAllowed(order *entity.Order, status string) bool {
+	from, ok := orderProgress[order.Status]
+	if !ok {
+		return false
+	}
+	to, ok := orderProgress[status]
+	if !ok || to <= from {
+		return false
+	}
+	return order.PaymentMethod == "Cod" || order.PaymentStatus == "successful"
+}
+
+// ReturnOpen reports whether a return may still be requested: the order was
+// fulfilled less than windowDays ago. Orders delivered before delivery times
+// were stored count from their last update.
+func ReturnOpen(order *entity.Order, windowDays int, now time.Time) bool {
+	if !OrderFulfilled(order) {
+		return false
+	}
+	deliveredAt := order.DeliveredAt
+	if deliveredAt.IsZero() {
+		deliveredAt = order.UpdatedAt
+	}
+	return now.Before(deliveredAt.AddDate(0, 0, windowDays))
+}
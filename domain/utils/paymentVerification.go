@@ -6,11 +6,12 @@ import (
 	"crypto/subtle"
 	"encoding/hex"
 	"errors"
+	"zog/config"
 )
 
-func RazorPaymentVerification(sign, orderId, paymentId string) error {
+func RazorPaymentVerification(cfg config.Razorpay, sign, orderId, paymentId string) error {
 	signature := sign
-	secret := "MU9PWzkhTBSCkPnxEUOAZdYW"
+	secret := cfg.KeySecret
 	data := orderId + "|" + paymentId
 
 	h := hmac.New(sha256.New, []byte(secret))
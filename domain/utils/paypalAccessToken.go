@@ -8,12 +8,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"zog/config"
 )
 
-func GeneratePayPalAccessToken() (string, error) {
-	clientID := "AcAvim6aHXQxhW3XryTaxzQf-PmqnKnY9Mlq_bliOCoD55clHs5O-7hDFshYDQ6TRPmvMBgMFLx-4FYq"
-	clientSecret := "EOJGhmFQymy8TajUpbHk2bcyzVvzBb3w-sjhzdt8_CgwF9zhs_uNLDAqZgVYBADCOqzOzgl6po0bNYAV"
-	baseURL := "https://api-m.sandbox.paypal.com/v1/oauth2/token"
+func GeneratePayPalAccessToken(cfg config.PayPal) (string, error) {
+	clientID := cfg.ClientId
+	clientSecret := cfg.ClientSecret
+	baseURL := cfg.BaseURL + "/v1/oauth2/token"
 
 	auth := base64.StdEncoding.EncodeToString([]byte(clientID + ":" + clientSecret))
 
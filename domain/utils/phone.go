@@ -0,0 +1,13 @@
+package utils
+
+import "strings"
+
+// E164 writes phone in international form. Numbers that already start with +
+// are kept; others get countryCode in front, less any leading trunk 0.
+func E164(countryCode, phone string) string {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(phone)
+	if strings.HasPrefix(phone, "+") {
+		return phone
+	}
+	return countryCode + strings.TrimLeft(phone, "0")
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"time"
+	"zog/domain/entity"
+)
+
+// EffectiveTicketPrice resolves the price of a ticket at the given time. The
+// schedule window covering now replaces the base price, then the largest
+// demand rule whose stock threshold has been reached is applied on top.
+func EffectiveTicketPrice(basePrice int, schedules []entity.PriceSchedule, rules []entity.DemandRule, remaining int, now time.Time) int {
+	price := basePrice
+	for _, schedule := range schedules {
+		if !now.Before(schedule.StartsAt) && now.Before(schedule.EndsAt) {
+			price = schedule.Price
+			break
+		}
+	}
+	increase := 0
+	for _, rule := range rules {
+		if remaining <= rule.Remaining && rule.Increase > increase {
+			increase = rule.Increase
+		}
+	}
+	return price + price*increase/100
+}
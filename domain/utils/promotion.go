@@ -0,0 +1,54 @@
+package utils
+
+import "zog/domain/entity"
+
+// StackPromotions applies the promotion rule to an eligible coupon and the
+// eligible offers, which are expected best first. It returns the promotions in
+// the order they apply, each cut down so the running total stays within the
+// rule's cap and the cart total. It also reports whether the coupon was kept
+// and whether the cap reduced anything.
+func StackPromotions(rule *entity.PromotionRule, coupon *entity.AppliedPromotion, offers []entity.AppliedPromotion, total int) ([]entity.AppliedPromotion, bool, bool) {
+	if !rule.StackOffers && len(offers) > 1 {
+		offers = offers[:1]
+	}
+	couponKept := coupon != nil
+	var candidates []entity.AppliedPromotion
+	switch {
+	case coupon == nil:
+		candidates = offers
+	case len(offers) == 0:
+		candidates = []entity.AppliedPromotion{*coupon}
+	case !rule.StackCouponWithOffers && rule.CouponFirst:
+		candidates = []entity.AppliedPromotion{*coupon}
+	case !rule.StackCouponWithOffers:
+		candidates = offers
+		couponKept = false
+	case rule.CouponFirst:
+		candidates = append([]entity.AppliedPromotion{*coupon}, offers...)
+	default:
+		candidates = append(append([]entity.AppliedPromotion{}, offers...), *coupon)
+	}
+
+	limit := total
+	if rule.MaxDiscount > 0 && rule.MaxDiscount < limit {
+		limit = rule.MaxDiscount
+	}
+	if rule.MaxDiscountPercent > 0 && total*rule.MaxDiscountPercent/100 < limit {
+		limit = total * rule.MaxDiscountPercent / 100
+	}
+	capped := false
+	var applied []entity.AppliedPromotion
+	for _, promotion := range candidates {
+		if promotion.Amount > limit {
+			promotion.Amount = limit
+			capped = true
+		}
+		if promotion.Amount <= 0 {
+			continue
+		}
+		limit -= promotion.Amount
+		promotion.Position = len(applied) + 1
+		applied = append(applied, promotion)
+	}
+	return applied, couponKept, capped
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"strings"
+	"zog/domain/entity"
+)
+
+// ReferralAbuseReason explains why a referral should not be paid, or returns
+// an empty string when it looks genuine.
+func ReferralAbuseReason(referrer, referee *entity.User, referrerAddresses, refereeAddresses []entity.Address) string {
+	if referrer == nil || !referrer.Permission {
+		return "referrer is blocked or deleted"
+	}
+	if referrer.ID == referee.ID {
+		return "self-referral"
+	}
+	if referrer.Phone == referee.Phone || strings.EqualFold(referrer.Email, referee.Email) {
+		return "same phone or email as referrer"
+	}
+	for _, theirs := range referrerAddresses {
+		for _, ours := range refereeAddresses {
+			if sameAddress(theirs, ours) {
+				return "same address as referrer"
+			}
+		}
+	}
+	return ""
+}
+
+func sameAddress(a, b entity.Address) bool {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+	return a.Pincode == b.Pincode &&
+		normalize(a.House) == normalize(b.House) &&
+		normalize(a.Street) == normalize(b.Street) &&
+		normalize(a.City) == normalize(b.City)
+}
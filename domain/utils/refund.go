@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"errors"
+	"zog/config"
+
+	"github.com/razorpay/razorpay-go"
+)
+
+// SplitRefund divides a refund between the wallet and the gateway in the
+// proportion the order was paid with them. Rounding favours the wallet.
+func SplitRefund(amount, walletPaid, gatewayPaid int) (int, int) {
+	paid := walletPaid + gatewayPaid
+	if paid <= 0 || gatewayPaid <= 0 {
+		return amount, 0
+	}
+	gateway := amount * gatewayPaid / paid
+	return amount - gateway, gateway
+}
+
+func RazorRefund(cfg config.Razorpay, paymentId string, amount int) error {
+	client := razorpay.NewClient(cfg.KeyId, cfg.KeySecret)
+	_, err := client.Payment.Refund(paymentId, amount*100, nil, nil)
+	if err != nil {
+		return errors.New("Gateway refund failed")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package utils
+
+// Admin roles.
+const (
+	RoleSuperAdmin       = "super-admin"
+	RoleCatalogueManager = "catalogue-manager"
+	RoleOrderManager     = "order-manager"
+	RoleFinance          = "finance"
+	RoleSupport          = "support"
+)
+
+// Admin permissions, checked per route.
+const (
+	PermAdmins      = "admins:manage"
+	PermUsersRead   = "users:read"
+	PermUsersManage = "users:manage"
+	PermCatalogue   = "catalogue:manage"
+	PermPromotions  = "promotions:manage"
+	PermOrdersRead  = "orders:read"
+	PermOrders      = "orders:manage"
+	PermRefunds     = "refunds:manage"
+	PermGiftCards   = "giftcards:manage"
+	PermReports     = "reports:read"
+	PermSettings    = "settings:manage"
+	PermAudit       = "audit:read"
+)
+
+// AdminRoles lists the roles in the order they are shown.
+var AdminRoles = []string{RoleSuperAdmin, RoleCatalogueManager, RoleOrderManager, RoleFinance, RoleSupport}
+
+var rolePermissions = map[string][]string{
+	RoleSuperAdmin: {
+		PermAdmins, PermUsersRead, PermUsersManage, PermCatalogue, PermPromotions, PermOrdersRead,
+		PermOrders, PermRefunds, PermGiftCards, PermReports, PermSettings, PermAudit,
+	},
+	RoleCatalogueManager: {PermCatalogue, PermPromotions},
+	RoleOrderManager:     {PermOrdersRead, PermOrders, PermUsersRead},
+	RoleFinance:          {PermOrdersRead, PermRefunds, PermGiftCards, PermReports, PermSettings},
+	RoleSupport:          {PermUsersRead, PermUsersManage, PermOrdersRead},
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions returns the permissions of a role, none for an unknown one.
+func RolePermissions(role string) []string {
+	return rolePermissions[role]
+}
+
+func RoleHas(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"time"
+	"zog/domain/entity"
+)
+
+const lapsedDays = 90
+
+// InSegment reports whether the customer matches the segment's rule.
+func InSegment(segment *entity.Segment, profile *entity.CustomerProfile, now time.Time) bool {
+	switch segment.Rule {
+	case "new":
+		if profile.Orders > 0 {
+			return false
+		}
+		return segment.Days == 0 || profile.JoinedAt.After(now.AddDate(0, 0, -segment.Days))
+	case "lapsed":
+		days := segment.Days
+		if days == 0 {
+			days = lapsedDays
+		}
+		return profile.Orders > 0 && profile.LastOrderAt.Before(now.AddDate(0, 0, -days))
+	case "event":
+		for _, ticketId := range profile.TicketIds {
+			if ticketId == segment.TicketId {
+				return true
+			}
+		}
+		return false
+	case "wallet":
+		return profile.Wallet >= segment.WalletMin && (segment.WalletMax == 0 || profile.Wallet <= segment.WalletMax)
+	}
+	return false
+}
+
+// MatchSegments returns the IDs of the segments whose rule the customer
+// matches.
+func MatchSegments(segments []entity.Segment, profile *entity.CustomerProfile, now time.Time) map[int]bool {
+	members := map[int]bool{}
+	for i := range segments {
+		if InSegment(&segments[i], profile, now) {
+			members[segments[i].ID] = true
+		}
+	}
+	return members
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters, the RFC 6238 defaults every authenticator app supports.
+const (
+	TotpDigits = 6
+	TotpPeriod = 30 * time.Second
+	// TotpSkew is how many periods either side of now are accepted, for
+	// clocks that drift.
+	TotpSkew = 1
+)
+
+const totpSecretSize = 20
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTotpSecret returns a random 160 bit secret, base32 encoded as
+// authenticator apps expect it.
+func NewTotpSecret() (string, error) {
+	b := make([]byte, totpSecretSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.New("Secret generation failed")
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TotpStep is the time step t falls in.
+func TotpStep(t time.Time) int64 {
+	return t.Unix() / int64(TotpPeriod/time.Second)
+}
+
+// TotpCode computes the code for a step, HOTP (RFC 4226) over the step
+// counter with HMAC-SHA1.
+func TotpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", errors.New("Invalid totp secret")
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TotpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TotpDigits, value%mod), nil
+}
+
+// TotpVerify checks code against the steps around now, and returns the step
+// it matched so the caller can refuse it a second time. Steps up to after
+// are not accepted.
+func TotpVerify(secret, code string, now time.Time, after int64) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != TotpDigits {
+		return 0, false
+	}
+	current := TotpStep(now)
+	for step := current - TotpSkew; step <= current+TotpSkew; step++ {
+		if step <= after {
+			continue
+		}
+		expected, err := TotpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TotpURI is the otpauth:// provisioning URI authenticator apps scan.
+func TotpURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TotpDigits))
+	query.Set("period", fmt.Sprint(int(TotpPeriod/time.Second)))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// NewRecoveryCode returns a one-time recovery code such as 4f9k-2mxq-7hta.
+func NewRecoveryCode() (string, error) {
+	// 32 letters and digits, leaving out those easily misread as others.
+	const alphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+	b := make([]byte, 12)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.New("Recovery code generation failed")
+	}
+	var code strings.Builder
+	for i, c := range b {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		code.WriteByte(alphabet[c&31])
+	}
+	return code.String(), nil
+}
+
+// NormaliseRecoveryCode lets users type recovery codes in any case, with or
+// without the dashes.
+func NormaliseRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	var out strings.Builder
+	for i, c := range code {
+		if i > 0 && i%4 == 0 {
+			out.WriteByte('-')
+		}
+		out.WriteRune(c)
+	}
+	return out.String()
+}
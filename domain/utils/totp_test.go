@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfc6238Codes are the RFC 6238 SHA1 vectors cut to six digits.
+var rfc6238Codes = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTotpCodeVectors(t *testing.T) {
+	for _, vector := range rfc6238Codes {
+		code, err := TotpCode(rfc6238Secret, TotpStep(time.Unix(vector.unix, 0)))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, vector.code, code)
+	}
+}
+
+func TestTotpVerifyVectors(t *testing.T) {
+	for _, vector := range rfc6238Codes {
+		now := time.Unix(vector.unix, 0)
+		step, ok := TotpVerify(rfc6238Secret, vector.code, now, 0)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, TotpStep(now), step)
+	}
+}
+
+func TestTotpVerifySkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	_, ok := TotpVerify(rfc6238Secret, "050471", now.Add(TotpPeriod), 0)
+	assert.Equal(t, true, ok)
+	_, ok = TotpVerify(rfc6238Secret, "050471", now.Add(-TotpPeriod), 0)
+	assert.Equal(t, true, ok)
+	_, ok = TotpVerify(rfc6238Secret, "050471", now.Add(3*TotpPeriod), 0)
+	assert.Equal(t, false, ok)
+}
+
+func TestTotpVerifyRefusesReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step, ok := TotpVerify(rfc6238Secret, "005 924", now, 0)
+	assert.Equal(t, true, ok)
+	_, ok = TotpVerify(rfc6238Secret, "005924", now, step)
+	assert.Equal(t, false, ok)
+}
+
+func TestTotpVerifyWrongCode(t *testing.T) {
+	now := time.Unix(2000000000, 0)
+	_, ok := TotpVerify(rfc6238Secret, "279038", now, 0)
+	assert.Equal(t, false, ok)
+	_, ok = TotpVerify(rfc6238Secret, "27903", now, 0)
+	assert.Equal(t, false, ok)
+}
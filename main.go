@@ -4,20 +4,51 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"zog/config"
+	"zog/delivery/cli"
 	"zog/delivery/handlers"
+	middlewares "zog/delivery/middlewares"
 	"zog/delivery/routes"
 	_ "zog/docs"
+	"zog/domain/utils"
 	adminrepository "zog/repository/admin"
+	auditrepository "zog/repository/audit"
 	cartrepository "zog/repository/cart"
+	emailrepository "zog/repository/email"
+	giftcardrepository "zog/repository/giftcard"
 	infrastructure "zog/repository/infrastructure"
+	loyaltyrepository "zog/repository/loyalty"
+	mailerrepository "zog/repository/mailer"
 	orderrepository "zog/repository/order"
+	otprepository "zog/repository/otp"
 	productrepository "zog/repository/product"
+	ratelimitrepository "zog/repository/ratelimit"
+	referralrepository "zog/repository/referral"
+	seatrepository "zog/repository/seat"
+	segmentrepository "zog/repository/segment"
+	sessionrepository "zog/repository/session"
+	transferrepository "zog/repository/transfer"
+	twofactorrepository "zog/repository/twofactor"
 	repository "zog/repository/user"
+	waitlistrepository "zog/repository/waitlist"
 	adminusecase "zog/usecase/admin"
+	auditusecase "zog/usecase/audit"
 	cartusecase "zog/usecase/cart"
+	emailusecase "zog/usecase/email"
+	giftcardusecase "zog/usecase/giftcard"
+	loyaltyusecase "zog/usecase/loyalty"
 	orderusecase "zog/usecase/order"
 	productusecase "zog/usecase/product"
+	ratelimitusecase "zog/usecase/ratelimit"
+	referralusecase "zog/usecase/referral"
+	seatusecase "zog/usecase/seat"
+	segmentusecase "zog/usecase/segment"
+	sessionusecase "zog/usecase/session"
+	transferusecase "zog/usecase/transfer"
+	twofactorusecase "zog/usecase/twofactor"
 	usecase "zog/usecase/user"
+	waitlistusecase "zog/usecase/waitlist"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -30,7 +61,8 @@ import (
 
 //	@securityDefinitions.apiKey	JWT
 //	@in							header
-//	@name						token
+//	@name						Authorization
+//	@description				Access token from login or refresh, as "Bearer <token>". The Authorise cookie works too.
 
 //	@license.name	Apache 2.0
 //	@license.url	http://www.apache.org/licenses/LICENSE-2.0.html
@@ -39,7 +71,11 @@ import (
 
 // @schemes	http
 func main() {
-	db, err := infrastructure.ConnectToDB()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := infrastructure.ConnectToDB(cfg.Database)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -48,25 +84,114 @@ func main() {
 	productRepo := productrepository.NewProductRepository(db)
 	cartRepo := cartrepository.NewCartRepository(db)
 	orderRepo := orderrepository.NewOrderRepository(db)
+	waitlistRepo := waitlistrepository.NewWaitlistRepository(db)
+	transferRepo := transferrepository.NewTransferRepository(db)
+	seatRepo := seatrepository.NewSeatRepository(db)
+	segmentRepo := segmentrepository.NewSegmentRepository(db)
+	referralRepo := referralrepository.NewReferralRepository(db)
+	loyaltyRepo := loyaltyrepository.NewLoyaltyRepository(db)
+	giftCardRepo := giftcardrepository.NewGiftCardRepository(db)
+	sessionRepo := sessionrepository.NewSessionRepository(db)
+	auditRepo := auditrepository.NewAuditRepository(db)
+	twoFactorRepo := twofactorrepository.NewTwoFactorRepository(db)
+	emailRepo := emailrepository.NewEmailRepository(db)
+	otpProvider, err := otprepository.NewProvider(db, cfg.OTP, cfg.Twilio)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mailer, err := mailerrepository.NewMailer(cfg.Mail)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rateLimitStore, err := ratelimitrepository.NewStore(db, cfg.RateLimit)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userUsecase := usecase.NewUser(userRepo, otpProvider)
+	adminUsecase := adminusecase.NewAdmin(adminRepo, otpProvider)
+	productUsecase := productusecase.NewProduct(productRepo, segmentRepo)
+	cartUsecase := cartusecase.NewCart(cartRepo, productRepo, waitlistRepo, seatRepo, segmentRepo, loyaltyRepo)
+	orderUsecase := orderusecase.NewOrder(orderRepo, cartRepo, userRepo, productRepo, waitlistRepo, seatRepo, loyaltyRepo, giftCardRepo, referralRepo, mailer, cfg.Razorpay, cfg.PayPal, cfg.Windows.Payment)
+	waitlistUsecase := waitlistusecase.NewWaitlist(waitlistRepo, productRepo, userRepo, cfg.Windows.Reservation)
+	transferUsecase := transferusecase.NewTransfer(transferRepo, productRepo, userRepo)
+	seatUsecase := seatusecase.NewSeat(seatRepo, cartRepo, productRepo, cfg.Windows.SeatHold)
+	segmentUsecase := segmentusecase.NewSegment(segmentRepo, productRepo)
+	referralUsecase := referralusecase.NewReferral(referralRepo, userRepo)
+	loyaltyUsecase := loyaltyusecase.NewLoyalty(loyaltyRepo)
+	giftCardUsecase := giftcardusecase.NewGiftCard(giftCardRepo, cartRepo, userRepo, mailer)
+	sessionUsecase := sessionusecase.NewSession(sessionRepo, cfg.JWT)
+	auditUsecase := auditusecase.NewAudit(auditRepo)
+	twoFactorUsecase := twofactorusecase.NewTwoFactor(twoFactorRepo, adminRepo, cfg.TwoFactor)
+	emailUsecase := emailusecase.NewEmail(emailRepo, userRepo, mailer, cfg.Email)
+	rateLimitUsecase := ratelimitusecase.NewRateLimit(rateLimitStore, cfg.RateLimit)
+
+	for _, role := range cfg.TwoFactor.Required {
+		if !utils.ValidRole(role) {
+			log.Fatalf("TOTP_REQUIRED_ROLES: unknown admin role %q", role)
+		}
+	}
+
+	promoted, err := adminUsecase.ExecuteMigrateRoles()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if promoted != nil {
+		log.Printf("admin %d (%s) made super-admin, there was none", promoted.ID, promoted.Phone)
+	}
+
+	hashed, err := giftCardUsecase.ExecuteMigrateCodes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if hashed > 0 {
+		log.Printf("codes of %d gift cards hashed", hashed)
+	}
+
+	migrated, err := orderUsecase.ExecuteMigratePromotions()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if migrated > 0 {
+		log.Printf("discount breakdown filled in for %d older orders", migrated)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		err = cli.NewAdminCommand(adminUsecase, sessionUsecase, twoFactorUsecase, os.Stdin, os.Stdout).Run(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	userUsecase := usecase.NewUser(userRepo)
-	adminUsecase := adminusecase.NewAdmin(adminRepo)
-	productUsecase := productusecase.NewProduct(productRepo)
-	cartUsecase := cartusecase.NewCart(cartRepo, productRepo)
-	orderUsecase := orderusecase.NewOrder(orderRepo, cartRepo, userRepo, productRepo)
+	auth := middlewares.NewAuth(cfg.JWT, sessionUsecase, adminUsecase)
+	audit := middlewares.NewAudit(auditUsecase)
+	rateLimit := middlewares.NewRateLimit(rateLimitUsecase)
 
-	userHandler := handlers.NewUserHandler(userUsecase, productUsecase, cartUsecase)
-	adminHandler := handlers.NewAdminHandler(adminUsecase, productUsecase)
-	orderHandler := handlers.NewOrderHandler(orderUsecase)
+	userHandler := handlers.NewUserHandler(userUsecase, productUsecase, cartUsecase, waitlistUsecase, transferUsecase, seatUsecase, referralUsecase, loyaltyUsecase, giftCardUsecase, emailUsecase, auth, rateLimit)
+	adminHandler := handlers.NewAdminHandler(adminUsecase, productUsecase, waitlistUsecase, seatUsecase, cartUsecase, segmentUsecase, referralUsecase, loyaltyUsecase, giftCardUsecase, auditUsecase, twoFactorUsecase, auth, rateLimit)
+	orderHandler := handlers.NewOrderHandler(orderUsecase, waitlistUsecase, cartUsecase)
+
+	go waitlistUsecase.StartReservationSweeper(cfg.Windows.SweepInterval)
+	go orderUsecase.StartSweeper(cfg.Windows.SweepInterval)
+	go sessionUsecase.StartSweeper(cfg.Windows.SweepInterval)
+	go rateLimitUsecase.StartSweeper(cfg.Windows.SweepInterval)
+	go twoFactorUsecase.StartSweeper(cfg.Windows.SweepInterval)
+	go emailUsecase.StartSweeper(cfg.Windows.SweepInterval)
 
 	router := gin.Default()
+	err = router.SetTrustedProxies(cfg.RateLimit.TrustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	routes.UserRouter(router, userHandler)
-	routes.AdminRouter(router, adminHandler)
-	routes.OrderRouter(router, orderHandler)
+	routes.UserRouter(router, userHandler, auth, rateLimit)
+	routes.AdminRouter(router, adminHandler, auth, audit, rateLimit)
+	routes.OrderRouter(router, orderHandler, auth, audit)
 
-	fmt.Println("Starting server on port 8080...")
-	err1 := http.ListenAndServe(":8080", router)
+	fmt.Printf("Starting server on port %s (%s profile)...\n", cfg.Port, cfg.Profile)
+	err1 := http.ListenAndServe(":"+cfg.Port, router)
 	if err1 != nil {
 		log.Fatal(err1)
 	}
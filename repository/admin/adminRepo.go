@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 
 	"zog/domain/entity"
+	"zog/domain/utils"
 )
 
 type AdminRepository struct {
@@ -21,13 +22,6 @@ func (ac *AdminRepository) Create(admin *entity.Admin) error {
 	return ac.db.Create(admin).Error
 }
 
-func (ar *AdminRepository) CreateOtpKey(key, phone string) error {
-	var otpKey entity.OtpKey
-	otpKey.Key = key
-	otpKey.Phone = phone
-	return ar.db.Create(otpKey).Error
-}
-
 func (ar *AdminRepository) GetByPhone(phone string) (*entity.Admin, error) {
 	var admin entity.Admin
 	result := ar.db.Where(&entity.Admin{Phone: phone}).First(&admin)
@@ -50,6 +44,80 @@ func (ar *AdminRepository) GetByEmail(email string) (*entity.Admin, error) {
 	}
 	return &admin, nil
 }
+func (ar *AdminRepository) GetAdminByID(id int) (*entity.Admin, error) {
+	var admin entity.Admin
+	result := ar.db.Where("id = ?", id).First(&admin)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &admin, nil
+}
+
+func (ar *AdminRepository) GetAdmins() ([]entity.Admin, error) {
+	var admins []entity.Admin
+	err := ar.db.Order("id").Find(&admins).Error
+	if err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
+
+func (ar *AdminRepository) UpdateAdmin(admin *entity.Admin) error {
+	return ar.db.Save(admin).Error
+}
+
+// CountSuperAdmins counts the active super-admins other than exceptId.
+func (ar *AdminRepository) CountSuperAdmins(exceptId int) (int, error) {
+	var count int64
+	err := ar.db.Model(&entity.Admin{}).Where("role = ? AND active = ? AND id <> ?", utils.RoleSuperAdmin, true, exceptId).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// MigrateRoles brings admins created before roles were enforced in line. It
+// only runs while there is no super-admin: admins without a role become
+// support, and the oldest active admin is made super-admin and returned.
+// Whether an admin is active is left alone.
+func (ar *AdminRepository) MigrateRoles() (*entity.Admin, error) {
+	var promoted *entity.Admin
+	err := ar.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&entity.Admin{}).Where("role = ?", utils.RoleSuperAdmin).Count(&count).Error
+		if err != nil || count > 0 {
+			return err
+		}
+		err = tx.Model(&entity.Admin{}).Where("role = '' OR role IS NULL").Update("role", utils.RoleSupport).Error
+		if err != nil {
+			return err
+		}
+		var oldest entity.Admin
+		err = tx.Where("active = ?", true).Order("id").First(&oldest).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		oldest.Role = utils.RoleSuperAdmin
+		err = tx.Model(&oldest).Update("role", oldest.Role).Error
+		if err != nil {
+			return err
+		}
+		promoted = &oldest
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
 func (ar *AdminRepository) GetByID(id int) (*entity.User, error) {
 	var user entity.User
 	result := ar.db.Where(&entity.User{ID: id}).First(&user)
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"errors"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// auditedModels maps the entity names used in audit actions to the rows
+// snapshotted before and after a call.
+var auditedModels = map[string]func() interface{}{
+	"admin":         func() interface{} { return &entity.Admin{} },
+	"user":          func() interface{} { return &entity.User{} },
+	"ticket":        func() interface{} { return &entity.Ticket{} },
+	"apparel":       func() interface{} { return &entity.Apparel{} },
+	"priceschedule": func() interface{} { return &entity.PriceSchedule{} },
+	"demandrule":    func() interface{} { return &entity.DemandRule{} },
+	"coupon":        func() interface{} { return &entity.Coupon{} },
+	"offer":         func() interface{} { return &entity.Offer{} },
+	"campaign":      func() interface{} { return &entity.CouponCampaign{} },
+	"segment":       func() interface{} { return &entity.Segment{} },
+	"giftcard":      func() interface{} { return &entity.GiftCard{} },
+	"order":         func() interface{} { return &entity.Order{} },
+	"return":        func() interface{} { return &entity.Return{} },
+}
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db}
+}
+
+// Create is the only write there is; see infrastructure for the trigger that
+// keeps entries from being changed.
+func (ar *AuditRepository) Create(entry *entity.AuditEntry) error {
+	return ar.db.Create(entry).Error
+}
+
+// Snapshot returns the row of the named entity as column values, or nil if
+// the entity is not audited by row or the row does not exist.
+func (ar *AuditRepository) Snapshot(name string, id int) (map[string]interface{}, error) {
+	model, ok := auditedModels[name]
+	if !ok || id == 0 {
+		return nil, nil
+	}
+	row := map[string]interface{}{}
+	err := ar.db.Model(model()).Where("id = ?", id).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// Search returns a page of entries matching the filter, newest first, and
+// how many match in all.
+func (ar *AuditRepository) Search(filter entity.AuditFilter, offset, limit int) (*entity.AuditLog, error) {
+	query := ar.db.Model(&entity.AuditEntry{})
+	if filter.AdminId != 0 {
+		query = query.Where("admin_id = ?", filter.AdminId)
+	}
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityId != 0 {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+	log := &entity.AuditLog{Total: int(total)}
+	err = query.Order("id desc").Offset(offset).Limit(limit).Find(&log.Entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return log, nil
+}
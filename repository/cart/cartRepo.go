@@ -81,6 +81,90 @@ func (cr *CartRepository) GetByName(productName string, cartId int) (*entity.Car
 	return &cartItem, nil
 }
 
+func (cr *CartRepository) GetBySeat(seatId int, cartId int) (*entity.CartItem, error) {
+	var cartItem entity.CartItem
+	result := cr.db.Where("seat_id = ? AND cart_id = ?", seatId, cartId).First(&cartItem)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &cartItem, nil
+}
+
+func (cr *CartRepository) CountCartsWithCoupon(code string) (int, error) {
+	var count int64
+	err := cr.db.Model(&entity.Cart{}).Where("coupon_code = ?", code).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// ClearCoupon takes the coupon and its discount off every cart it is applied
+// to and returns how many carts were changed.
+func (cr *CartRepository) ClearCoupon(code string) (int, error) {
+	result := cr.db.Model(&entity.Cart{}).Where("coupon_code = ?", code).
+		Updates(map[string]interface{}{"coupon_code": "", "offer_price": 0})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	err := cr.db.Unscoped().Where("kind = ? AND name = ? AND order_id = 0", "coupon", code).
+		Delete(&entity.AppliedPromotion{}).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected), nil
+}
+
+func (cr *CartRepository) GetCartPromotions(cartId int) ([]entity.AppliedPromotion, error) {
+	var promotions []entity.AppliedPromotion
+	err := cr.db.Where("cart_id = ? AND order_id = 0", cartId).Order("position").Find(&promotions).Error
+	if err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// GetCartsByPromotion lists the carts whose discount currently includes the
+// given promotion.
+func (cr *CartRepository) GetCartsByPromotion(kind string, promotionId int) ([]entity.Cart, error) {
+	var carts []entity.Cart
+	held := cr.db.Model(&entity.AppliedPromotion{}).Select("cart_id").
+		Where("kind = ? AND promotion_id = ? AND order_id = 0", kind, promotionId)
+	err := cr.db.Where("id IN (?)", held).Find(&carts).Error
+	if err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+// ReplaceCartPromotions swaps the cart's discount breakdown for the given one.
+func (cr *CartRepository) ReplaceCartPromotions(cartId int, promotions []entity.AppliedPromotion) error {
+	return cr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("cart_id = ? AND order_id = 0", cartId).Delete(&entity.AppliedPromotion{}).Error
+		if err != nil {
+			return err
+		}
+		if len(promotions) == 0 {
+			return nil
+		}
+		rows := make([]entity.AppliedPromotion, len(promotions))
+		for i, promotion := range promotions {
+			rows[i] = entity.AppliedPromotion{
+				CartId:      cartId,
+				Kind:        promotion.Kind,
+				PromotionId: promotion.PromotionId,
+				Name:        promotion.Name,
+				Amount:      promotion.Amount,
+				Position:    promotion.Position,
+			}
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
 func (cr *CartRepository) GetAllCartItems(cartId int) ([]entity.CartItem, error) {
 	var cartItems []entity.CartItem
 	result := cr.db.Where("cart_id=?", cartId).Find(&cartItems)
@@ -0,0 +1,95 @@
+package email
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailRepository(db *gorm.DB) *EmailRepository {
+	return &EmailRepository{db}
+}
+
+// CreateToken stores token and retires the user's earlier tokens for the same
+// purpose, so only the newest link works.
+func (er *EmailRepository) CreateToken(token *entity.EmailToken) error {
+	return er.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.EmailToken{}).Where("user_id = ? AND purpose = ? AND used = ?", token.UserId, token.Purpose, false).
+			Update("used", true).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// UseToken marks the token with the given hash used and returns it. Unknown,
+// used and expired tokens, and tokens for another purpose, are refused alike.
+func (er *EmailRepository) UseToken(hash, purpose string, now time.Time) (*entity.EmailToken, error) {
+	var token entity.EmailToken
+	err := er.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ? AND purpose = ?", hash, purpose).First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("Invalid or expired link")
+		}
+		if err != nil {
+			return err
+		}
+		if token.Used || !now.Before(token.ExpiresAt) {
+			return errors.New("Invalid or expired link")
+		}
+		return tx.Model(&token).Update("used", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkVerified verifies the user's address if it is still the one the link
+// was sent to and no other user has verified it since.
+func (er *EmailRepository) MarkVerified(userId int, email string) error {
+	return er.db.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		err := tx.Model(&entity.User{}).Where("LOWER(email) = LOWER(?) AND email_verified = ? AND id <> ?", email, true, userId).
+			Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return errors.New("This email is already verified by another account")
+		}
+		result := tx.Model(&entity.User{}).Where("id = ? AND email = ?", userId, email).Update("email_verified", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("The email address has changed since this link was sent")
+		}
+		return nil
+	})
+}
+
+// SetPassword stores the user's new password hash and retires their other
+// reset links.
+func (er *EmailRepository) SetPassword(userId int, passwordHash string) error {
+	return er.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.User{}).Where("id = ?", userId).Update("password", passwordHash).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.EmailToken{}).Where("user_id = ? AND purpose = ? AND used = ?", userId, entity.EmailTokenReset, false).
+			Update("used", true).Error
+	})
+}
+
+func (er *EmailRepository) DeleteExpired(now time.Time) error {
+	return er.db.Where("expires_at <= ?", now).Delete(&entity.EmailToken{}).Error
+}
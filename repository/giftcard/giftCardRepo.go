@@ -0,0 +1,358 @@
+package giftcard
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+	"zog/domain/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GiftCardRepository struct {
+	db *gorm.DB
+}
+
+func NewGiftCardRepository(db *gorm.DB) *GiftCardRepository {
+	return &GiftCardRepository{db}
+}
+
+func (gr *GiftCardRepository) Create(card *entity.GiftCard) error {
+	return gr.db.Create(card).Error
+}
+
+func (gr *GiftCardRepository) Delete(card *entity.GiftCard) error {
+	return gr.db.Unscoped().Delete(card).Error
+}
+
+func (gr *GiftCardRepository) GetByID(id int) (*entity.GiftCard, error) {
+	var card entity.GiftCard
+	result := gr.db.First(&card, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Gift card not found")
+		}
+		return nil, result.Error
+	}
+	return &card, nil
+}
+
+func (gr *GiftCardRepository) GetByCodeHash(codeHash string) (*entity.GiftCard, error) {
+	var card entity.GiftCard
+	result := gr.db.Where("code_hash = ? AND code_hash <> ''", codeHash).First(&card)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &card, nil
+}
+
+// NewCode returns a gift card code no card has yet.
+func (gr *GiftCardRepository) NewCode() (string, error) {
+	for i := 0; i < 5; i++ {
+		code, err := utils.GiftCardCode()
+		if err != nil {
+			return "", err
+		}
+		existing, err := gr.GetByCodeHash(utils.GiftCardCodeHash(code))
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return code, nil
+		}
+	}
+	return "", errors.New("Gift card code generation failed")
+}
+
+// GetForUser lists the cards a user bought and the active cards sent to their
+// email or phone, newest first.
+func (gr *GiftCardRepository) GetForUser(userId int, email, phone string) ([]entity.GiftCard, error) {
+	var cards []entity.GiftCard
+	err := gr.db.Where("purchaser_id = ? AND status <> ?", userId, "pending").
+		Or("status <> ? AND ((recipient_email <> '' AND recipient_email = ?) OR (recipient_phone <> '' AND recipient_phone = ?))", "pending", email, phone).
+		Order("id desc").Find(&cards).Error
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (gr *GiftCardRepository) GetByOrder(orderId int) ([]entity.GiftCard, error) {
+	var cards []entity.GiftCard
+	err := gr.db.Where("order_id = ?", orderId).Find(&cards).Error
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (gr *GiftCardRepository) GetTxns(cardId int) ([]entity.GiftCardTxn, error) {
+	var txns []entity.GiftCardTxn
+	err := gr.db.Where("gift_card_id = ?", cardId).Order("id").Find(&txns).Error
+	if err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
+
+// Issue saves a card that is usable straight away, along with its opening
+// balance entry.
+func (gr *GiftCardRepository) Issue(card *entity.GiftCard, now time.Time) error {
+	return gr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(card).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&entity.GiftCardTxn{GiftCardId: card.ID, UserId: card.PurchaserId, OrderId: card.OrderId, Kind: "issue", Amount: card.Amount, Date: now}).Error
+	})
+}
+
+// Activate gives a paid pending card its code and opening balance. Cards that
+// are no longer pending are left alone and returned as nil, so an order is
+// only activated once.
+func (gr *GiftCardRepository) Activate(cardId, orderId int, code string, expiresAt, now time.Time) (*entity.GiftCard, error) {
+	var card entity.GiftCard
+	issued := false
+	err := gr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, cardId).Error
+		if err != nil {
+			return err
+		}
+		if card.Status != "pending" {
+			return nil
+		}
+		issued = true
+		card.CodeHash = utils.GiftCardCodeHash(code)
+		card.CodeHint = utils.GiftCardCodeHint(code)
+		card.Balance = card.Amount
+		card.Status = "active"
+		card.OrderId = orderId
+		card.ExpiresAt = expiresAt
+		err = tx.Save(&card).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&entity.GiftCardTxn{GiftCardId: card.ID, UserId: card.PurchaserId, OrderId: orderId, Kind: "issue", Amount: card.Amount, Date: now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !issued {
+		return nil, nil
+	}
+	card.Code = code
+	return &card, nil
+}
+
+// Charge takes up to max from the card's balance as payment for an order.
+func (gr *GiftCardRepository) Charge(cardId, userId, max int, now time.Time) (*entity.GiftCardTxn, error) {
+	txn := &entity.GiftCardTxn{UserId: userId, Kind: "payment", Date: now}
+	err := gr.db.Transaction(func(tx *gorm.DB) error {
+		var card entity.GiftCard
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, cardId).Error
+		if err != nil {
+			return errors.New("Gift card not found")
+		}
+		err = utils.GiftCardUsable(&card, now)
+		if err != nil {
+			return err
+		}
+		amount := card.Balance
+		if amount > max {
+			amount = max
+		}
+		err = tx.Model(&card).Update("balance", card.Balance-amount).Error
+		if err != nil {
+			return err
+		}
+		txn.GiftCardId = card.ID
+		txn.Amount = -amount
+		return tx.Create(txn).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+func (gr *GiftCardRepository) SetTxnOrder(txn *entity.GiftCardTxn, orderId int) error {
+	return gr.db.Model(txn).Update("order_id", orderId).Error
+}
+
+// Refund puts a payment back on the card it came from.
+func (gr *GiftCardRepository) Refund(txn *entity.GiftCardTxn, now time.Time) error {
+	return gr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.GiftCard{}).Where("id = ?", txn.GiftCardId).
+			Update("balance", gorm.Expr("balance + ?", -txn.Amount)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&entity.GiftCardTxn{GiftCardId: txn.GiftCardId, UserId: txn.UserId, OrderId: txn.OrderId, Kind: "refund", Amount: -txn.Amount, Date: now}).Error
+	})
+}
+
+// RefundOrder puts every gift card payment made for the order back on its
+// card, once.
+func (gr *GiftCardRepository) RefundOrder(orderId int, now time.Time) error {
+	var txns []entity.GiftCardTxn
+	err := gr.db.Where("order_id = ? AND kind IN ?", orderId, []string{"payment", "refund"}).Find(&txns).Error
+	if err != nil {
+		return err
+	}
+	refunded := make(map[int]bool)
+	for _, txn := range txns {
+		if txn.Kind == "refund" {
+			refunded[txn.GiftCardId] = true
+		}
+	}
+	for _, txn := range txns {
+		if txn.Kind != "payment" || refunded[txn.GiftCardId] {
+			continue
+		}
+		err = gr.Refund(&txn, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedeemToWallet moves the whole balance of the card into the user's wallet
+// and returns the amount moved.
+func (gr *GiftCardRepository) RedeemToWallet(codeHash string, userId int, now time.Time) (int, error) {
+	amount := 0
+	err := gr.db.Transaction(func(tx *gorm.DB) error {
+		var card entity.GiftCard
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code_hash = ? AND code_hash <> ''", codeHash).First(&card).Error
+		if err != nil {
+			return errors.New("Gift card not found")
+		}
+		err = utils.GiftCardUsable(&card, now)
+		if err != nil {
+			return err
+		}
+		amount = card.Balance
+		err = tx.Model(&card).Updates(map[string]interface{}{"balance": 0, "status": "redeemed"}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&entity.GiftCardTxn{GiftCardId: card.ID, UserId: userId, Kind: "wallet", Amount: -amount, Date: now}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.User{}).Where("id = ?", userId).
+			Update("wallet", gorm.Expr("wallet + ?", amount)).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
+
+// Void stops a card from being used and writes off what is left on it.
+func (gr *GiftCardRepository) Void(cardId, userId int, now time.Time) error {
+	return gr.db.Transaction(func(tx *gorm.DB) error {
+		var card entity.GiftCard
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, cardId).Error
+		if err != nil {
+			return errors.New("Gift card not found")
+		}
+		if card.Status == "void" {
+			return errors.New("Gift card is already void")
+		}
+		err = tx.Model(&card).Updates(map[string]interface{}{"balance": 0, "status": "void"}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&entity.GiftCardTxn{GiftCardId: card.ID, UserId: userId, Kind: "void", Amount: -card.Balance, Date: now}).Error
+	})
+}
+
+// OrderCardsSpent reports whether any card bought with the order has been
+// spent, which stops the order from being cancelled or returned.
+func (gr *GiftCardRepository) OrderCardsSpent(orderId int) (bool, error) {
+	cards, err := gr.GetByOrder(orderId)
+	if err != nil {
+		return false, err
+	}
+	for _, card := range cards {
+		if card.Status == "redeemed" || (card.Status == "active" && card.Balance < card.Amount) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// VoidOrder voids the cards bought with an order that is being cancelled or
+// returned. It refuses when any of them has already been spent.
+func (gr *GiftCardRepository) VoidOrder(orderId int, now time.Time) error {
+	spent, err := gr.OrderCardsSpent(orderId)
+	if err != nil {
+		return err
+	}
+	if spent {
+		return errors.New("Gift cards from this order have already been used")
+	}
+	cards, err := gr.GetByOrder(orderId)
+	if err != nil {
+		return err
+	}
+	for _, card := range cards {
+		if card.Status == "void" {
+			continue
+		}
+		err = gr.Void(card.ID, card.PurchaserId, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateCodes runs at startup and replaces the plaintext codes of cards
+// issued before codes were hashed, pointing carts that had one applied at the
+// card instead. It returns the number of cards migrated.
+func (gr *GiftCardRepository) MigrateCodes() (int, error) {
+	if !gr.db.Migrator().HasColumn("gift_cards", "code") {
+		return 0, nil
+	}
+	type legacyCard struct {
+		ID   int
+		Code string
+	}
+	var cards []legacyCard
+	err := gr.db.Table("gift_cards").Select("id, code").Where("code <> ''").Find(&cards).Error
+	if err != nil {
+		return 0, err
+	}
+	err = gr.db.Transaction(func(tx *gorm.DB) error {
+		if tx.Migrator().HasColumn("carts", "gift_card_code") {
+			err := tx.Exec("UPDATE carts SET gift_card_id = gift_cards.id FROM gift_cards WHERE carts.gift_card_code <> '' AND carts.gift_card_code = gift_cards.code").Error
+			if err != nil {
+				return err
+			}
+			err = tx.Migrator().DropColumn("carts", "gift_card_code")
+			if err != nil {
+				return err
+			}
+		}
+		for _, card := range cards {
+			err := tx.Table("gift_cards").Where("id = ?", card.ID).Updates(map[string]interface{}{
+				"code_hash": utils.GiftCardCodeHash(card.Code),
+				"code_hint": utils.GiftCardCodeHint(card.Code),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().DropColumn("gift_cards", "code")
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(cards), nil
+}
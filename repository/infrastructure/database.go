@@ -3,41 +3,57 @@ package infrastructre
 import (
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"zog/config"
 	"zog/delivery/models"
 	"zog/domain/entity"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
-var dsn string
-var KEY5 = "host=localhost user=edwin dbname=edwin password=acid port=5432 sslmode=disable"
 
-func init() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-	dsn = os.Getenv("KEY5")
-
-}
-
-func ConnectToDB() (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(KEY5), &gorm.Config{})
+func ConnectToDB(cfg config.Database) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DSN), &gorm.Config{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 	DB = db
-	DB.AutoMigrate(&entity.TicketDetails{}, &entity.OtpKey{}, &models.Signup{}, &entity.Admin{}, &entity.User{}, &entity.Ticket{}, &entity.Apparel{}, &entity.CartItem{}, &entity.Cart{}, &entity.Wishlist{}, &entity.Order{}, &entity.OrderItem{}, &entity.Address{}, &entity.Inventory{}, &entity.Invoice{}, &entity.Return{}, &entity.Coupon{}, &entity.UsedCoupon{}, &entity.Offer{})
+	DB.AutoMigrate(&entity.TicketDetails{}, &entity.OtpKey{}, &models.Signup{}, &entity.Admin{}, &entity.User{}, &entity.Ticket{}, &entity.Apparel{}, &entity.CartItem{}, &entity.Cart{}, &entity.Wishlist{}, &entity.Order{}, &entity.OrderItem{}, &entity.Address{}, &entity.Inventory{}, &entity.Invoice{}, &entity.Return{}, &entity.Coupon{}, &entity.UsedCoupon{}, &entity.Offer{}, &entity.WaitlistEntry{}, &entity.Notification{}, &entity.TicketPass{}, &entity.TicketTransfer{}, &entity.SeatSection{}, &entity.Seat{}, &entity.PriceSchedule{}, &entity.DemandRule{}, &entity.AppliedPromotion{}, &entity.PromotionRule{}, &entity.CouponCampaign{}, &entity.CampaignCode{}, &entity.Segment{}, &entity.Referral{}, &entity.ReferralSetting{}, &entity.LoyaltySetting{}, &entity.LoyaltyEntry{}, &entity.GiftCard{}, &entity.GiftCardTxn{}, &entity.WalletHold{}, &entity.RefreshToken{}, &entity.TokenRevocation{}, &entity.AuditEntry{}, &entity.RateCounter{}, &entity.RecoveryCode{}, &entity.TwoFactorChallenge{}, &entity.EmailToken{})
+	err = protectAuditLog(db)
+	if err != nil {
+		return nil, err
+	}
 	return db, nil
 }
 
+// protectAuditLog makes the database itself refuse to change or remove audit
+// entries, whatever the code does.
+func protectAuditLog(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_entries_immutable() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit entries cannot be changed';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_entries_immutable ON audit_entries`,
+		`CREATE TRIGGER audit_entries_immutable BEFORE UPDATE OR DELETE ON audit_entries
+		FOR EACH ROW EXECUTE PROCEDURE audit_entries_immutable()`,
+		`DROP TRIGGER IF EXISTS audit_entries_no_truncate ON audit_entries`,
+		`CREATE TRIGGER audit_entries_no_truncate BEFORE TRUNCATE ON audit_entries
+		FOR EACH STATEMENT EXECUTE PROCEDURE audit_entries_immutable()`,
+	}
+	for _, statement := range statements {
+		err := db.Exec(statement).Error
+		if err != nil {
+			return fmt.Errorf("protecting audit log failed: %w", err)
+		}
+	}
+	return nil
+}
+
 func ConnectToTestDB() (*sql.DB, error) {
 	db, _, err := sqlmock.New()
 	if err != nil {
@@ -0,0 +1,262 @@
+package loyalty
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoyaltyRepository struct {
+	db *gorm.DB
+}
+
+func NewLoyaltyRepository(db *gorm.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{db}
+}
+
+// GetSetting returns the saved loyalty rules, or the defaults when none have
+// been configured.
+func (lr *LoyaltyRepository) GetSetting() (*entity.LoyaltySetting, error) {
+	setting := &entity.LoyaltySetting{}
+	err := lr.db.Order("id").First(setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &entity.LoyaltySetting{
+			TicketRate:       5,
+			ApparelRate:      2,
+			PointValue:       1,
+			ReturnWindowDays: 7,
+			ExpiryDays:       365,
+			MaxRedeemPercent: 50,
+			Active:           true,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+func (lr *LoyaltyRepository) SaveSetting(setting *entity.LoyaltySetting) error {
+	existing := &entity.LoyaltySetting{}
+	err := lr.db.Order("id").First(existing).Error
+	if err == nil {
+		setting.Model = existing.Model
+		setting.ID = existing.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return lr.db.Save(setting).Error
+}
+
+// Settle makes pending points whose return window has closed available and
+// writes off what is left of expired lots. Points of an order with a return
+// that has not been rejected stay pending until the return is decided.
+func (lr *LoyaltyRepository) Settle(userId int, now time.Time) error {
+	return lr.db.Transaction(func(tx *gorm.DB) error {
+		openReturns := tx.Model(&entity.Return{}).Select("order_id").Where("status <> ?", "rejected")
+		err := tx.Model(&entity.LoyaltyEntry{}).
+			Where("user_id = ? AND status = ? AND available_at <= ?", userId, "pending", now).
+			Where("order_id NOT IN (?)", openReturns).
+			Update("status", "available").Error
+		if err != nil {
+			return err
+		}
+		var expired []entity.LoyaltyEntry
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND status = ? AND remaining > 0 AND expires_at <= ?", userId, "available", now).
+			Find(&expired).Error
+		if err != nil {
+			return err
+		}
+		for _, lot := range expired {
+			err = tx.Create(&entity.LoyaltyEntry{
+				UserId:  userId,
+				OrderId: lot.OrderId,
+				Kind:    "expire",
+				Points:  -lot.Remaining,
+				Status:  "done",
+				Date:    now,
+			}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&lot).Update("remaining", 0).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Balance returns the points the user can spend and those still pending.
+func (lr *LoyaltyRepository) Balance(userId int) (int, int, error) {
+	var available, pending int
+	err := lr.db.Model(&entity.LoyaltyEntry{}).
+		Where("user_id = ? AND status = ?", userId, "available").
+		Select("COALESCE(SUM(remaining), 0)").Scan(&available).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	err = lr.db.Model(&entity.LoyaltyEntry{}).
+		Where("user_id = ? AND status = ?", userId, "pending").
+		Select("COALESCE(SUM(remaining), 0)").Scan(&pending).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return available, pending, nil
+}
+
+func (lr *LoyaltyRepository) GetHistory(userId int) ([]entity.LoyaltyEntry, error) {
+	var entries []entity.LoyaltyEntry
+	err := lr.db.Where("user_id = ?", userId).Order("id desc").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Earn credits the points earned on an order unless they were credited
+// before. The order row is locked so concurrent updates credit it only once.
+func (lr *LoyaltyRepository) Earn(orderId int, entries []entity.LoyaltyEntry) (bool, error) {
+	earned := false
+	err := lr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entity.Order{}, orderId).Error
+		if err != nil {
+			return err
+		}
+		var count int64
+		err = tx.Model(&entity.LoyaltyEntry{}).Where("order_id = ? AND kind = ?", orderId, "earn").Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 || len(entries) == 0 {
+			return nil
+		}
+		earned = true
+		return tx.Create(&entries).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return earned, nil
+}
+
+// Redeem spends points from the user's available lots, soonest to expire
+// first, and records the spend.
+func (lr *LoyaltyRepository) Redeem(userId, points int, now time.Time) (*entity.LoyaltyEntry, error) {
+	entry := &entity.LoyaltyEntry{UserId: userId, Kind: "redeem", Points: -points, Status: "done", Date: now}
+	err := lr.db.Transaction(func(tx *gorm.DB) error {
+		var lots []entity.LoyaltyEntry
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND status = ? AND remaining > 0 AND expires_at > ?", userId, "available", now).
+			Order("expires_at").Find(&lots).Error
+		if err != nil {
+			return err
+		}
+		left := points
+		for _, lot := range lots {
+			if left == 0 {
+				break
+			}
+			take := lot.Remaining
+			if take > left {
+				take = left
+			}
+			err = tx.Model(&lot).Update("remaining", lot.Remaining-take).Error
+			if err != nil {
+				return err
+			}
+			left -= take
+		}
+		if left > 0 {
+			return errors.New("Not enough loyalty points - review your cart")
+		}
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (lr *LoyaltyRepository) SetRedeemOrder(entry *entity.LoyaltyEntry, orderId int) error {
+	return lr.db.Model(entry).Update("order_id", orderId).Error
+}
+
+func (lr *LoyaltyRepository) GetRedeemByOrder(orderId int) (*entity.LoyaltyEntry, error) {
+	var entry entity.LoyaltyEntry
+	result := lr.db.Where("order_id = ? AND kind = ? AND status = ?", orderId, "redeem", "done").First(&entry)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &entry, nil
+}
+
+// Restore gives back points spent on an order that did not go through, as a
+// new lot that expires expiryDays from now.
+func (lr *LoyaltyRepository) Restore(entry *entity.LoyaltyEntry, expiryDays int, now time.Time) error {
+	return lr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.LoyaltyEntry{}).
+			Where("id = ? AND status = ?", entry.ID, "done").
+			Update("status", "restored")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+		return tx.Create(&entity.LoyaltyEntry{
+			UserId:      entry.UserId,
+			OrderId:     entry.OrderId,
+			Kind:        "restore",
+			Points:      -entry.Points,
+			Remaining:   -entry.Points,
+			Status:      "available",
+			Date:        now,
+			AvailableAt: now,
+			ExpiresAt:   now.AddDate(0, 0, expiryDays),
+		}).Error
+	})
+}
+
+// Clawback takes back the points earned on an order. Pending points are
+// voided; points already available are taken from what is left of the lot.
+func (lr *LoyaltyRepository) Clawback(orderId int, now time.Time) error {
+	return lr.db.Transaction(func(tx *gorm.DB) error {
+		var lots []entity.LoyaltyEntry
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND kind = ? AND status IN ?", orderId, "earn", []string{"pending", "available"}).
+			Find(&lots).Error
+		if err != nil {
+			return err
+		}
+		for _, lot := range lots {
+			if lot.Status == "available" && lot.Remaining > 0 {
+				err = tx.Create(&entity.LoyaltyEntry{
+					UserId:   lot.UserId,
+					OrderId:  orderId,
+					Category: lot.Category,
+					Kind:     "clawback",
+					Points:   -lot.Remaining,
+					Status:   "done",
+					Date:     now,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			err = tx.Model(&lot).Updates(map[string]interface{}{"status": "clawed back", "remaining": 0}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
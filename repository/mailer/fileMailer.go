@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+	"zog/config"
+	"zog/domain/entity"
+)
+
+// FileMailer is the development driver: each message is written to the
+// outbox directory as an .eml file instead of being sent.
+type FileMailer struct {
+	from   string
+	outbox string
+	count  uint64
+}
+
+func NewFileMailer(cfg config.Mail) *FileMailer {
+	return &FileMailer{from: cfg.From, outbox: cfg.Outbox}
+}
+
+func (fm *FileMailer) Send(mail *entity.Mail) error {
+	if err := checkHeaders(mail); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(fm.outbox, 0o700); err != nil {
+		log.Println("mail:", err)
+		return errors.New("Writing mail failed")
+	}
+	name := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102T150405.000000000"), atomic.AddUint64(&fm.count, 1))
+	path := filepath.Join(fm.outbox, name)
+	if err := os.WriteFile(path, message(fm.from, mail), 0o600); err != nil {
+		log.Println("mail:", err)
+		return errors.New("Writing mail failed")
+	}
+	log.Printf("mail: %q to %s written to %s", mail.Subject, mail.To, path)
+	return nil
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"errors"
+	"strings"
+	"zog/config"
+	"zog/domain/entity"
+)
+
+// Mailer delivers email.
+type Mailer interface {
+	Send(mail *entity.Mail) error
+}
+
+// NewMailer returns the driver named in cfg.
+func NewMailer(cfg config.Mail) (Mailer, error) {
+	switch cfg.Driver {
+	case config.MailSMTP:
+		return NewSMTPMailer(cfg), nil
+	case config.MailFile:
+		return NewFileMailer(cfg), nil
+	}
+	return nil, errors.New("Unknown mail driver " + cfg.Driver)
+}
+
+// checkHeaders refuses line breaks in the fields that become headers, so
+// user input cannot add headers of its own.
+func checkHeaders(mail *entity.Mail) error {
+	if strings.ContainsAny(mail.To+mail.Subject, "\r\n") {
+		return errors.New("Invalid mail header")
+	}
+	return nil
+}
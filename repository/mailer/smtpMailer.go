@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+	"zog/config"
+	"zog/domain/entity"
+)
+
+// SMTPMailer sends through an SMTP relay. The connection is upgraded with
+// STARTTLS when the server offers it; credentials are only sent over TLS.
+type SMTPMailer struct {
+	config config.Mail
+}
+
+func NewSMTPMailer(cfg config.Mail) *SMTPMailer {
+	return &SMTPMailer{config: cfg}
+}
+
+func (sm *SMTPMailer) Send(mail *entity.Mail) error {
+	if err := checkHeaders(mail); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if sm.config.Username != "" {
+		auth = smtp.PlainAuth("", sm.config.Username, sm.config.Password, sm.config.Host)
+	}
+	addr := net.JoinHostPort(sm.config.Host, sm.config.Port)
+	err := smtp.SendMail(addr, auth, sm.config.From, []string{mail.To}, message(sm.config.From, mail))
+	if err != nil {
+		log.Println("mail:", err)
+		return errors.New("Sending mail failed")
+	}
+	return nil
+}
+
+// message renders mail as an RFC 5322 message.
+func message(from string, mail *entity.Mail) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -51,6 +51,43 @@ func (or *OrderRepository) Update(order *entity.Order) error {
 	return or.db.Save(&order).Error
 }
 
+// UpdateStatus applies updates to the order only if its status is still from,
+// so no one else has moved it since it was read.
+func (or *OrderRepository) UpdateStatus(orderId int, from string, updates map[string]interface{}) (bool, error) {
+	result := or.db.Model(&entity.Order{}).
+		Where("id = ? AND status = ?", orderId, from).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ClaimPayment moves a pending online payment to paymentStatus, and the order
+// to status, only if neither has moved on yet. Verification, failure and
+// expiry race for the same order; only the one that claims it may act on it.
+func (or *OrderRepository) ClaimPayment(orderId int, paymentStatus, status string) (bool, error) {
+	result := or.db.Model(&entity.Order{}).
+		Where("id = ? AND payment_status = ? AND status = ?", orderId, "pending", "pending").
+		Updates(map[string]interface{}{"payment_status": paymentStatus, "status": status})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetUnpaidOnline lists online orders still waiting for the gateway that were
+// placed before the given time.
+func (or *OrderRepository) GetUnpaidOnline(before time.Time) ([]entity.Order, error) {
+	var orders []entity.Order
+	err := or.db.Where("payment_method IN (?) AND payment_status = ? AND status = ? AND created_at < ?", []string{"razorpay", "split"}, "pending", "pending", before).
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
 func (or *OrderRepository) CreateOrderItems(orderItem []entity.OrderItem) error {
 	if err := or.db.Create(orderItem).Error; err != nil {
 		return err
@@ -58,6 +95,33 @@ func (or *OrderRepository) CreateOrderItems(orderItem []entity.OrderItem) error
 	return nil
 }
 
+func (or *OrderRepository) GetOrderItems(orderId int) ([]entity.OrderItem, error) {
+	var orderItems []entity.OrderItem
+	result := or.db.Where("order_id=?", orderId).Find(&orderItems)
+	if result.Error != nil {
+		return nil, errors.New("Order items not found")
+	}
+	return orderItems, nil
+}
+
+func (or *OrderRepository) CreateTicketPasses(passes []entity.TicketPass) error {
+	return or.db.Create(&passes).Error
+}
+
+// CancelTicketPasses cancels the order's passes still held by the buyer. Passes
+// transferred to someone else are left alone.
+func (or *OrderRepository) CancelTicketPasses(orderId, ownerId int) error {
+	return or.db.Model(&entity.TicketPass{}).Where("order_id = ? AND owner_id = ?", orderId, ownerId).Update("status", "cancelled").Error
+}
+
+// CountTransferredPasses counts the order's passes now owned by someone other
+// than the buyer.
+func (or *OrderRepository) CountTransferredPasses(orderId, ownerId int) (int64, error) {
+	var count int64
+	err := or.db.Model(&entity.TicketPass{}).Where("order_id = ? AND owner_id <> ?", orderId, ownerId).Count(&count).Error
+	return count, err
+}
+
 func (or *OrderRepository) GetAllOrders(userId, offset, limit int) ([]entity.Order, error) {
 	var order []entity.Order
 	result := or.db.Offset(offset).Limit(limit).Where("user_id=?", userId).Find(&order)
@@ -126,6 +190,77 @@ func (or *OrderRepository) GetByCategory(category string, startDate, endDate tim
 	return report, nil
 }
 
+// CreateOrderPromotions records the discount breakdown the order was placed with.
+func (or *OrderRepository) CreateOrderPromotions(orderId int, promotions []entity.AppliedPromotion) error {
+	if len(promotions) == 0 {
+		return nil
+	}
+	rows := make([]entity.AppliedPromotion, len(promotions))
+	for i, promotion := range promotions {
+		rows[i] = entity.AppliedPromotion{
+			OrderId:     orderId,
+			Kind:        promotion.Kind,
+			PromotionId: promotion.PromotionId,
+			Name:        promotion.Name,
+			Amount:      promotion.Amount,
+			Position:    promotion.Position,
+		}
+	}
+	return or.db.Create(&rows).Error
+}
+
+// MigrateLegacyPromotions gives orders placed before the discount breakdown
+// was stored a breakdown row built from their offer or coupon, so history and
+// cancellation treat them like newer orders. It returns how many orders were
+// filled in; once done there is nothing left for it to match.
+func (or *OrderRepository) MigrateLegacyPromotions() (int, error) {
+	var orders []entity.Order
+	recorded := or.db.Model(&entity.AppliedPromotion{}).Select("order_id").Where("order_id <> 0")
+	err := or.db.Where("discount > 0 AND (offer_id <> 0 OR coupon_code <> '') AND id NOT IN (?)", recorded).
+		Find(&orders).Error
+	if err != nil {
+		return 0, err
+	}
+	err = or.db.Transaction(func(tx *gorm.DB) error {
+		for _, order := range orders {
+			promotion := entity.AppliedPromotion{OrderId: order.ID, Amount: order.Discount, Position: 1}
+			if order.OfferId != 0 {
+				var offer entity.Offer
+				promotion.Kind = "offer"
+				promotion.PromotionId = order.OfferId
+				promotion.Name = "offer"
+				if tx.Where("id = ?", order.OfferId).Limit(1).Find(&offer).Error == nil && offer.Id != 0 {
+					promotion.Name = offer.Name
+				}
+			} else {
+				var coupon entity.Coupon
+				promotion.Kind = "coupon"
+				promotion.Name = order.CouponCode
+				if tx.Where("code = ?", order.CouponCode).Limit(1).Find(&coupon).Error == nil {
+					promotion.PromotionId = coupon.Id
+				}
+			}
+			if err := tx.Create(&promotion).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(orders), nil
+}
+
+func (or *OrderRepository) GetOrderPromotions(orderId int) ([]entity.AppliedPromotion, error) {
+	var promotions []entity.AppliedPromotion
+	err := or.db.Where("order_id = ?", orderId).Order("position").Find(&promotions).Error
+	if err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
 func (or *OrderRepository) CreateInvoice(invoice *entity.Invoice) (*entity.Invoice, error) {
 	if err := or.db.Create(invoice).Error; err != nil {
 		return nil, err
@@ -166,6 +301,124 @@ func (or *OrderRepository) UpdateReturn(returnData *entity.Return) error {
 	return or.db.Save(&returnData).Error
 }
 
-func (or *OrderRepository) UpdateUserWallet(user *entity.User) error {
-	return or.db.Save(&user).Error
+// ClaimReturn moves a return from one status to another only if it is still
+// in the first, so a return is decided and refunded once.
+func (or *OrderRepository) ClaimReturn(returnId int, from, to string) (bool, error) {
+	result := or.db.Model(&entity.Return{}).
+		Where("id = ? AND status = ?", returnId, from).
+		Update("status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ClaimRefund marks a paid order's payment as refunded only if it has not
+// been already, so the money goes back once.
+func (or *OrderRepository) ClaimRefund(orderId int) (bool, error) {
+	result := or.db.Model(&entity.Order{}).
+		Where("id = ? AND payment_status = ?", orderId, "successful").
+		Update("payment_status", "refund")
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseRefund puts back a refund claim whose payout failed, so it can be
+// tried again.
+func (or *OrderRepository) ReleaseRefund(orderId int) error {
+	return or.db.Model(&entity.Order{}).
+		Where("id = ? AND payment_status = ?", orderId, "refund").
+		Update("payment_status", "successful").Error
+}
+
+// DebitWallet takes amount from the user's wallet, failing when the balance
+// is not enough. The balance is checked in the update itself, so concurrent
+// checkouts cannot both spend it.
+func (or *OrderRepository) DebitWallet(userId, amount int) error {
+	result := or.db.Model(&entity.User{}).Where("id = ? AND wallet >= ?", userId, amount).
+		Update("wallet", gorm.Expr("wallet - ?", amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("Wallet have not enough money-add money or choose another method")
+	}
+	return nil
+}
+
+// HoldWallet takes amount from the user's wallet for a split payment, failing
+// when the balance is not enough.
+func (or *OrderRepository) HoldWallet(userId, amount int, expiresAt time.Time) (*entity.WalletHold, error) {
+	hold := &entity.WalletHold{UserId: userId, Amount: amount, Status: "held", ExpiresAt: expiresAt}
+	err := or.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.User{}).Where("id = ? AND wallet >= ?", userId, amount).
+			Update("wallet", gorm.Expr("wallet - ?", amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("Wallet have not enough money-add money or choose another method")
+		}
+		return tx.Create(hold).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+func (or *OrderRepository) SetHoldOrder(hold *entity.WalletHold, orderId int) error {
+	return or.db.Model(hold).Update("order_id", orderId).Error
+}
+
+func (or *OrderRepository) CaptureWalletHold(orderId int) error {
+	return or.db.Model(&entity.WalletHold{}).Where("order_id = ? AND status = ?", orderId, "held").
+		Update("status", "captured").Error
+}
+
+// ReleaseWalletHold gives a hold that was never captured back to the wallet.
+func (or *OrderRepository) ReleaseWalletHold(hold *entity.WalletHold) error {
+	return or.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.WalletHold{}).Where("id = ? AND status = ?", hold.ID, "held").
+			Update("status", "released")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Model(&entity.User{}).Where("id = ?", hold.UserId).
+			Update("wallet", gorm.Expr("wallet + ?", hold.Amount)).Error
+	})
+}
+
+// GetExpiredHolds lists expired holds that were never attached to an order,
+// left behind when a checkout failed between holding the wallet and placing
+// the order.
+func (or *OrderRepository) GetExpiredHolds(now time.Time) ([]entity.WalletHold, error) {
+	var holds []entity.WalletHold
+	err := or.db.Where("status = ? AND order_id = 0 AND expires_at < ?", "held", now).Find(&holds).Error
+	if err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+func (or *OrderRepository) GetWalletHold(orderId int) (*entity.WalletHold, error) {
+	var hold entity.WalletHold
+	result := or.db.Where("order_id = ?", orderId).First(&hold)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &hold, nil
+}
+
+func (or *OrderRepository) CreditWallet(userId, amount int) error {
+	return or.db.Model(&entity.User{}).Where("id = ?", userId).
+		Update("wallet", gorm.Expr("wallet + ?", amount)).Error
 }
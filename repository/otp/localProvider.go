@@ -0,0 +1,177 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"zog/config"
+	"zog/domain/entity"
+	"zog/domain/utils"
+
+	"github.com/twilio/twilio-go"
+	api "github.com/twilio/twilio-go/rest/api/v2010"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	codeLength = 6
+	keyLength  = 32
+)
+
+// codeStore generates codes itself and keeps them hashed in OtpKey. A code
+// is good until it expires, is used once, or has been guessed at too often;
+// only the newest code sent to a phone for a purpose counts.
+type codeStore struct {
+	db          *gorm.DB
+	expiry      time.Duration
+	maxAttempts int
+}
+
+func (cs *codeStore) create(phone, purpose string) (string, string, error) {
+	code, err := utils.GenerateCode(codeLength, "0123456789")
+	if err != nil {
+		return "", "", err
+	}
+	key, err := utils.GenerateCode(keyLength, "0123456789abcdef")
+	if err != nil {
+		return "", "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", errors.New("Otp failed to generate")
+	}
+	otpKey := &entity.OtpKey{
+		Key:       key,
+		Phone:     phone,
+		Purpose:   purpose,
+		CodeHash:  string(hash),
+		ExpiresAt: time.Now().Add(cs.expiry),
+	}
+	err = createKey(cs.db, otpKey)
+	if err != nil {
+		return "", "", err
+	}
+	return key, code, nil
+}
+
+func (cs *codeStore) Check(phone, key, purpose, code string) error {
+	otpKey, err := getKey(cs.db, phone, key, purpose)
+	if err != nil {
+		return err
+	}
+	if otpKey.Used || otpKey.CodeHash == "" {
+		return errors.New("Invalid otp")
+	}
+	var newer int64
+	err = cs.db.Model(&entity.OtpKey{}).Where("phone = ? AND purpose = ? AND id > ?", phone, purpose, otpKey.ID).
+		Count(&newer).Error
+	if err != nil {
+		return err
+	}
+	if newer > 0 {
+		return errors.New("Otp expired, request a new one")
+	}
+	if time.Now().After(otpKey.ExpiresAt) {
+		return errors.New("Otp expired, request a new one")
+	}
+	// Count the attempt before comparing so parallel guesses cannot get past
+	// the limit.
+	result := cs.db.Model(&entity.OtpKey{}).Where("id = ? AND attempts < ?", otpKey.ID, cs.maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("Too many wrong attempts, request a new otp")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(otpKey.CodeHash), []byte(code)) != nil {
+		return errors.New("Invalid otp")
+	}
+	result = cs.db.Model(&entity.OtpKey{}).Where("id = ? AND used = ?", otpKey.ID, false).Update("used", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("Invalid otp")
+	}
+	return nil
+}
+
+// LocalProvider is the self-hosted driver: codes are kept in the database
+// and sent as a plain SMS.
+type LocalProvider struct {
+	codeStore
+	twilio      config.Twilio
+	countryCode string
+}
+
+func NewLocalProvider(db *gorm.DB, cfg config.OTP, twilioConfig config.Twilio) *LocalProvider {
+	return &LocalProvider{
+		codeStore:   codeStore{db: db, expiry: cfg.Expiry, maxAttempts: cfg.MaxAttempts},
+		twilio:      twilioConfig,
+		countryCode: cfg.CountryCode,
+	}
+}
+
+func (lp *LocalProvider) Send(phone, purpose string) (string, error) {
+	if lp.twilio.AccountSid == "" || lp.twilio.AuthToken == "" || lp.twilio.From == "" {
+		return "", errors.New("Otp service is not configured")
+	}
+	key, code, err := lp.create(phone, purpose)
+	if err != nil {
+		return "", err
+	}
+	client := twilio.NewRestClientWithParams(twilio.ClientParams{
+		Username: lp.twilio.AccountSid,
+		Password: lp.twilio.AuthToken,
+	})
+	params := &api.CreateMessageParams{}
+	params.SetTo(utils.E164(lp.countryCode, phone))
+	params.SetFrom(lp.twilio.From)
+	params.SetBody(fmt.Sprintf("Your zog verification code is %s. It expires in %d minutes.", code, int(lp.expiry.Minutes())))
+	_, err = client.Api.CreateMessage(params)
+	if err != nil {
+		log.Println("otp sms to", phone, "failed:", err)
+		return "", errors.New("Otp failed to send")
+	}
+	return key, nil
+}
+
+// ConsoleProvider is the development driver: codes are kept like the local
+// driver's but printed to the log, or appended to a file when one is set,
+// instead of being sent.
+type ConsoleProvider struct {
+	codeStore
+	file string
+}
+
+func NewConsoleProvider(db *gorm.DB, cfg config.OTP) *ConsoleProvider {
+	return &ConsoleProvider{
+		codeStore: codeStore{db: db, expiry: cfg.Expiry, maxAttempts: cfg.MaxAttempts},
+		file:      cfg.File,
+	}
+}
+
+func (cp *ConsoleProvider) Send(phone, purpose string) (string, error) {
+	key, code, err := cp.create(phone, purpose)
+	if err != nil {
+		return "", err
+	}
+	if cp.file == "" {
+		log.Printf("otp: %s for %s (key %s)", code, phone, key)
+		return key, nil
+	}
+	f, err := os.OpenFile(cp.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", errors.New("Otp failed to send")
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s %s %s %s\n", time.Now().Format(time.RFC3339), phone, code, key)
+	if err != nil {
+		return "", errors.New("Otp failed to send")
+	}
+	return key, nil
+}
@@ -0,0 +1,52 @@
+package otp
+
+import (
+	"errors"
+	"zog/config"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// OTPProvider sends one-time passwords and checks them. Send records an
+// OtpKey for the phone and purpose and returns its key; Check only accepts
+// the code for that key, phone and purpose, so a code sent for one flow or
+// account cannot be spent on another.
+type OTPProvider interface {
+	Send(phone, purpose string) (string, error)
+	Check(phone, key, purpose, code string) error
+}
+
+// NewProvider returns the driver named in cfg.
+func NewProvider(db *gorm.DB, cfg config.OTP, twilioConfig config.Twilio) (OTPProvider, error) {
+	switch cfg.Driver {
+	case config.OTPTwilio:
+		return NewTwilioProvider(db, twilioConfig, cfg.CountryCode), nil
+	case config.OTPLocal:
+		return NewLocalProvider(db, cfg, twilioConfig), nil
+	case config.OTPConsole:
+		return NewConsoleProvider(db, cfg), nil
+	}
+	return nil, errors.New("Unknown otp driver " + cfg.Driver)
+}
+
+func createKey(db *gorm.DB, otpKey *entity.OtpKey) error {
+	err := db.Create(otpKey).Error
+	if err != nil {
+		return errors.New("Saving otp key failed")
+	}
+	return nil
+}
+
+// getKey returns the OtpKey recorded for phone, key and purpose.
+func getKey(db *gorm.DB, phone, key, purpose string) (*entity.OtpKey, error) {
+	var otpKey entity.OtpKey
+	err := db.Where("key = ? AND phone = ? AND purpose = ?", key, phone, purpose).First(&otpKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("Invalid otp")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &otpKey, nil
+}
@@ -0,0 +1,79 @@
+package otp
+
+import (
+	"errors"
+	"log"
+	"zog/config"
+	"zog/domain/entity"
+	"zog/domain/utils"
+
+	"github.com/twilio/twilio-go"
+	openapi "github.com/twilio/twilio-go/rest/verify/v2"
+	"gorm.io/gorm"
+)
+
+// TwilioProvider leaves generating, sending and checking codes to Twilio
+// Verify and only records the verification sid as the key.
+type TwilioProvider struct {
+	db          *gorm.DB
+	config      config.Twilio
+	countryCode string
+}
+
+func NewTwilioProvider(db *gorm.DB, cfg config.Twilio, countryCode string) *TwilioProvider {
+	return &TwilioProvider{db: db, config: cfg, countryCode: countryCode}
+}
+
+func (tp *TwilioProvider) client() (*twilio.RestClient, error) {
+	if tp.config.AccountSid == "" || tp.config.AuthToken == "" || tp.config.VerifyServiceSid == "" {
+		return nil, errors.New("Otp service is not configured")
+	}
+	return twilio.NewRestClientWithParams(twilio.ClientParams{
+		Username: tp.config.AccountSid,
+		Password: tp.config.AuthToken,
+	}), nil
+}
+
+func (tp *TwilioProvider) Send(phone, purpose string) (string, error) {
+	client, err := tp.client()
+	if err != nil {
+		return "", err
+	}
+	params := &openapi.CreateVerificationParams{}
+	params.SetTo(utils.E164(tp.countryCode, phone))
+	params.SetChannel("sms")
+	resp, err := client.VerifyV2.CreateVerification(tp.config.VerifyServiceSid, params)
+	if err != nil {
+		log.Println("otp verification to", phone, "failed:", err)
+		return "", errors.New("Otp failed to generate")
+	}
+	err = createKey(tp.db, &entity.OtpKey{Key: *resp.Sid, Phone: phone, Purpose: purpose})
+	if err != nil {
+		return "", err
+	}
+	return *resp.Sid, nil
+}
+
+// Check asks Twilio about the verification the key names, so a code is only
+// good for the verification it was sent with.
+func (tp *TwilioProvider) Check(phone, key, purpose, code string) error {
+	client, err := tp.client()
+	if err != nil {
+		return err
+	}
+	if _, err := getKey(tp.db, phone, key, purpose); err != nil {
+		return err
+	}
+	params := &openapi.CreateVerificationCheckParams{}
+	params.SetVerificationSid(key)
+	params.SetCode(code)
+	resp, err := client.VerifyV2.CreateVerificationCheck(tp.config.VerifyServiceSid, params)
+	if err != nil {
+		log.Println("otp verification check", key, "failed:", err)
+		return errors.New("Invalid otp")
+	}
+	if resp.Status == nil || *resp.Status != "approved" {
+		return errors.New("Invalid otp")
+	}
+	return nil
+}
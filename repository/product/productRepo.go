@@ -2,10 +2,13 @@ package repository
 
 import (
 	"errors"
+	"strconv"
 	"time"
 	"zog/domain/entity"
+	"zog/domain/utils"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository struct {
@@ -81,6 +84,54 @@ func (dt *ProductRepository) DeleteTicket(ticket *entity.Ticket) error {
 	return dt.db.Delete(ticket).Error
 }
 
+func (pr *ProductRepository) CreatePriceSchedule(schedule *entity.PriceSchedule) error {
+	return pr.db.Create(schedule).Error
+}
+
+func (pr *ProductRepository) GetPriceSchedules(ticketId int) ([]entity.PriceSchedule, error) {
+	var schedules []entity.PriceSchedule
+	err := pr.db.Where("ticket_id = ?", ticketId).Order("starts_at").Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (pr *ProductRepository) DeletePriceSchedule(id int) error {
+	result := pr.db.Delete(&entity.PriceSchedule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (pr *ProductRepository) CreateDemandRule(rule *entity.DemandRule) error {
+	return pr.db.Create(rule).Error
+}
+
+func (pr *ProductRepository) GetDemandRules(ticketId int) ([]entity.DemandRule, error) {
+	var rules []entity.DemandRule
+	err := pr.db.Where("ticket_id = ?", ticketId).Order("remaining desc").Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (pr *ProductRepository) DeleteDemandRule(id int) error {
+	result := pr.db.Delete(&entity.DemandRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (pr *ProductRepository) CreateTicketDetails(details *entity.TicketDetails) error {
 	return pr.db.Create(details).Error
 }
@@ -116,20 +167,27 @@ func (pr *ProductRepository) IncreaseProductQuantity(product *entity.Inventory)
 	return pr.db.Save(product).Error
 }
 
+// DecreaseProductQuantity takes the quantity from stock, failing rather than
+// going below zero when concurrent checkouts want the last items.
 func (pr *ProductRepository) DecreaseProductQuantity(product *entity.Inventory) error {
-	existingProduct := &entity.Inventory{}
-	err := pr.db.Where("product_category = ? AND product_id =?", product.ProductCategory, product.ProductId).First(existingProduct).Error
-	if err != nil {
-		return err
+	result := pr.db.Model(&entity.Inventory{}).
+		Where("product_category = ? AND product_id = ? AND quantity >= ?", product.ProductCategory, product.ProductId, product.Quantity).
+		Update("quantity", gorm.Expr("quantity - ?", product.Quantity))
+	if result.Error != nil {
+		return result.Error
 	}
-	newQuantity := existingProduct.Quantity - product.Quantity
-	err = pr.db.Model(existingProduct).Update("Quantity", newQuantity).Error
-	if err != nil {
-		return err
+	if result.RowsAffected == 0 {
+		return errors.New("Not enough stock for " + product.ProductCategory + " " + strconv.Itoa(product.ProductId))
 	}
 	return nil
 }
 
+func (pr *ProductRepository) RestoreProductQuantity(product *entity.Inventory) error {
+	return pr.db.Model(&entity.Inventory{}).
+		Where("product_category = ? AND product_id = ?", product.ProductCategory, product.ProductId).
+		Update("quantity", gorm.Expr("quantity + ?", product.Quantity)).Error
+}
+
 func (pr *ProductRepository) GetAllApparels(offset, limit int) ([]entity.Apparel, error) {
 	var apparels []entity.Apparel
 	err := pr.db.Offset(offset).Limit(limit).Where("removed = ?", false).Find(&apparels).Error
@@ -202,7 +260,7 @@ func (dt *ProductRepository) DeleteApparel(apparel *entity.Apparel) error {
 	return dt.db.Delete(apparel).Error
 }
 func (pr *ProductRepository) UpdateInventory(product *entity.Inventory) error {
-	return pr.db.Delete(product).Error
+	return pr.db.Save(product).Error
 }
 
 func (p *ProductRepository) CreateCoupon(coupon *entity.Coupon) error {
@@ -215,37 +273,339 @@ func (p *ProductRepository) CreateCoupon(coupon *entity.Coupon) error {
 func (p *ProductRepository) GetAllCoupons() (*[]entity.Coupon, error) {
 	var coupns []entity.Coupon
 	currentTime := time.Now()
-	err := p.db.Where("valid_until >= ?", currentTime).Find(&coupns).Error
+	err := p.db.Where("active = ? AND valid_until >= ?", true, currentTime).Find(&coupns).Error
 	if err != nil {
 		return nil, err
 	}
 	return &coupns, nil
 }
 
+// promotionFilter narrows a coupon or offer listing by status and category.
+func promotionFilter(query *gorm.DB, status, category string) *gorm.DB {
+	now := time.Now()
+	switch status {
+	case "active":
+		query = query.Where("active = ? AND valid_from <= ? AND valid_until >= ?", true, now, now)
+	case "inactive":
+		query = query.Where("active = ?", false)
+	case "expired":
+		query = query.Where("valid_until < ?", now)
+	case "scheduled":
+		query = query.Where("valid_from > ?", now)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	return query
+}
+
+func (p *ProductRepository) GetCoupons(offset, limit int, status, category, search string) ([]entity.Coupon, error) {
+	var coupons []entity.Coupon
+	query := promotionFilter(p.db.Model(&entity.Coupon{}), status, category)
+	if search != "" {
+		query = query.Where("code ILIKE ?", "%"+search+"%")
+	}
+	err := query.Order("id desc").Offset(offset).Limit(limit).Find(&coupons).Error
+	if err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (p *ProductRepository) GetCouponByID(id int) (*entity.Coupon, error) {
+	coupon := &entity.Coupon{}
+	err := p.db.First(coupon, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// UpdateCoupon writes the editable terms of a coupon, leaving the usage count
+// and active flag to the statements that own them.
+func (p *ProductRepository) UpdateCoupon(coupon *entity.Coupon) error {
+	return p.db.Model(coupon).
+		Select("type", "amount", "valid_from", "valid_until", "usage_limit", "per_user_limit", "min_order", "max_discount", "category", "product_id", "segment_id").
+		Updates(coupon).Error
+}
+
+func (p *ProductRepository) SetCouponActive(couponId int, active bool) error {
+	return p.db.Model(&entity.Coupon{}).Where("id = ?", couponId).Update("active", active).Error
+}
+
+func (p *ProductRepository) DeleteCoupon(coupon *entity.Coupon) error {
+	return p.db.Delete(coupon).Error
+}
+
+func (p *ProductRepository) GetCouponUsages(code string) ([]entity.UsedCoupon, error) {
+	var usages []entity.UsedCoupon
+	err := p.db.Where("coupon_code = ?", code).Order("created_at desc").Find(&usages).Error
+	if err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+// GetCouponByCode looks the code up among coupons first and then among the
+// codes generated for campaigns.
 func (p *ProductRepository) GetCouponByCode(code string) (*entity.Coupon, error) {
 	coupon := &entity.Coupon{}
 	err := p.db.Where("code = ?", code).First(coupon).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return p.getCampaignCoupon(code)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return coupon, nil
 }
 
-func (p *ProductRepository) UpdateCouponCount(coupon *entity.Coupon) error {
-	return p.db.Save(coupon).Error
+func (p *ProductRepository) getCampaignCoupon(code string) (*entity.Coupon, error) {
+	var campaignCode entity.CampaignCode
+	err := p.db.Where("code = ?", code).First(&campaignCode).Error
+	if err != nil {
+		return nil, err
+	}
+	var campaign entity.CouponCampaign
+	err = p.db.First(&campaign, campaignCode.CampaignId).Error
+	if err != nil {
+		return nil, err
+	}
+	return utils.CampaignCoupon(&campaign, code, campaignCode.UsedBy != 0), nil
 }
 
-func (p *ProductRepository) UpdateCouponUsage(usedCoupon *entity.UsedCoupon) error {
-	if err := p.db.Create(usedCoupon).Error; err != nil {
-		return err
+func (p *ProductRepository) CountCouponUsage(userId int, code string) (int, error) {
+	var count int64
+	err := p.db.Model(&entity.UsedCoupon{}).Where("user_id = ? AND coupon_code = ?", userId, code).Count(&count).Error
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return int(count), nil
+}
+
+// RedeemCoupon records one use of the coupon by userId. The coupon row is
+// locked while the limits are checked and the count raised, so concurrent
+// checkouts cannot go past them.
+func (p *ProductRepository) RedeemCoupon(code string, userId int) (*entity.UsedCoupon, error) {
+	usedCoupon := &entity.UsedCoupon{UserId: userId, CouponCode: code}
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		var coupon entity.Coupon
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&coupon).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = redeemCampaignCode(tx, code, userId)
+			if err != nil {
+				return err
+			}
+			return tx.Create(usedCoupon).Error
+		}
+		if err != nil {
+			return errors.New("Sorry coupon not found")
+		}
+		now := time.Now()
+		if !coupon.Active || now.Before(coupon.ValidFrom) || now.After(coupon.ValidUntil) {
+			return errors.New("Coupon is not valid now")
+		}
+		if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+			return errors.New("Coupon usage limit reached")
+		}
+		var used int64
+		err = tx.Model(&entity.UsedCoupon{}).Where("user_id = ? AND coupon_code = ?", userId, code).Count(&used).Error
+		if err != nil {
+			return err
+		}
+		perUserLimit := coupon.PerUserLimit
+		if perUserLimit == 0 {
+			perUserLimit = 1
+		}
+		if int(used) >= perUserLimit {
+			return errors.New("Coupon already used")
+		}
+		err = tx.Create(usedCoupon).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&coupon).Update("used_count", gorm.Expr("used_count + 1")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usedCoupon, nil
 }
 
-func (p *ProductRepository) CheckCouponUsage(usedCoupon *entity.UsedCoupon) error {
+// redeemCampaignCode marks a generated code as used by userId, failing when it
+// was already redeemed.
+func redeemCampaignCode(tx *gorm.DB, code string, userId int) error {
+	var campaignCode entity.CampaignCode
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&campaignCode).Error
+	if err != nil {
+		return errors.New("Sorry coupon not found")
+	}
+	var campaign entity.CouponCampaign
+	err = tx.First(&campaign, campaignCode.CampaignId).Error
+	if err != nil {
+		return errors.New("Sorry coupon not found")
+	}
+	now := time.Now()
+	if !campaign.Active || now.Before(campaign.ValidFrom) || now.After(campaign.ValidUntil) {
+		return errors.New("Coupon is not valid now")
+	}
+	if campaignCode.UsedBy != 0 {
+		return errors.New("Coupon already used")
+	}
+	return tx.Model(&campaignCode).Updates(map[string]interface{}{"used_by": userId, "used_at": now}).Error
+}
 
-	return nil
+func (p *ProductRepository) SetCouponOrder(usedCoupon *entity.UsedCoupon, orderId int) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(usedCoupon).Update("order_id", orderId).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.CampaignCode{}).
+			Where("code = ? AND used_by = ?", usedCoupon.CouponCode, usedCoupon.UserId).
+			Update("order_id", orderId).Error
+	})
 }
+
+func (p *ProductRepository) GetCouponUsageByOrder(orderId int) (*entity.UsedCoupon, error) {
+	var usedCoupon entity.UsedCoupon
+	result := p.db.Where("order_id = ?", orderId).First(&usedCoupon)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &usedCoupon, nil
+}
+
+// ReleaseCoupon gives a use back when the order it was redeemed for does not
+// go through. A single-use campaign code only reopens if checkout failed
+// before an order was placed with it; once an order took it, it stays used
+// even if that order is cancelled.
+func (p *ProductRepository) ReleaseCoupon(usedCoupon *entity.UsedCoupon) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(usedCoupon).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&entity.CampaignCode{}).Where("code = ? AND order_id = 0", usedCoupon.CouponCode).
+			Updates(map[string]interface{}{"used_by": 0, "used_at": nil}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.Coupon{}).
+			Where("code = ? AND used_count > 0", usedCoupon.CouponCode).
+			Update("used_count", gorm.Expr("used_count - 1")).Error
+	})
+}
+
+// CreateCampaign stores the campaign and its codes in one transaction, so a
+// campaign is never left with fewer codes than it asked for. draw returns
+// the given number of fresh candidate codes; candidates that clash with an
+// existing coupon or campaign code are drawn again, up to five times.
+func (p *ProductRepository) CreateCampaign(campaign *entity.CouponCampaign, draw func(count int) ([]string, error)) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(campaign).Error
+		if err != nil {
+			return err
+		}
+		generated := 0
+		for attempt := 0; generated < campaign.Count && attempt < 5; attempt++ {
+			candidates, err := draw(campaign.Count - generated)
+			if err != nil {
+				return err
+			}
+			var taken []string
+			err = tx.Model(&entity.Coupon{}).Where("code IN ?", candidates).Pluck("code", &taken).Error
+			if err != nil {
+				return err
+			}
+			clash := map[string]bool{}
+			for _, code := range taken {
+				clash[code] = true
+			}
+			codes := make([]entity.CampaignCode, 0, len(candidates))
+			for _, code := range candidates {
+				if !clash[code] {
+					codes = append(codes, entity.CampaignCode{CampaignId: campaign.ID, Code: code})
+				}
+			}
+			if len(codes) == 0 {
+				continue
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&codes, 1000)
+			if result.Error != nil {
+				return result.Error
+			}
+			generated += int(result.RowsAffected)
+		}
+		if generated < campaign.Count {
+			return errors.New("Could not generate enough unique codes, try a longer code")
+		}
+		return nil
+	})
+}
+
+func (p *ProductRepository) SetCampaignActive(campaignId int, active bool) error {
+	return p.db.Model(&entity.CouponCampaign{}).Where("id = ?", campaignId).Update("active", active).Error
+}
+
+func (p *ProductRepository) GetCampaigns(offset, limit int, status string) ([]entity.CouponCampaign, error) {
+	var campaigns []entity.CouponCampaign
+	err := promotionFilter(p.db.Model(&entity.CouponCampaign{}), status, "").
+		Order("id desc").Offset(offset).Limit(limit).Find(&campaigns).Error
+	if err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
+
+func (p *ProductRepository) GetCampaignByID(id int) (*entity.CouponCampaign, error) {
+	campaign := &entity.CouponCampaign{}
+	err := p.db.First(campaign, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (p *ProductRepository) GetCampaignCodes(campaignId int) ([]entity.CampaignCode, error) {
+	var codes []entity.CampaignCode
+	err := p.db.Where("campaign_id = ?", campaignId).Order("id").Find(&codes).Error
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (p *ProductRepository) GetCampaignStats(campaign *entity.CouponCampaign) (*entity.CampaignStats, error) {
+	stats := &entity.CampaignStats{Campaign: *campaign}
+	codes := p.db.Model(&entity.CampaignCode{}).Where("campaign_id = ?", campaign.ID)
+	var generated, redeemed, orders int64
+	if err := codes.Session(&gorm.Session{}).Count(&generated).Error; err != nil {
+		return nil, err
+	}
+	if err := codes.Session(&gorm.Session{}).Where("used_by <> 0").Count(&redeemed).Error; err != nil {
+		return nil, err
+	}
+	if err := codes.Session(&gorm.Session{}).Where("order_id <> 0").Count(&orders).Error; err != nil {
+		return nil, err
+	}
+	orderIds := p.db.Model(&entity.CampaignCode{}).Select("order_id").Where("campaign_id = ? AND order_id <> 0", campaign.ID)
+	err := p.db.Model(&entity.AppliedPromotion{}).
+		Where("kind = ? AND order_id IN (?)", "coupon", orderIds).
+		Select("COALESCE(SUM(amount), 0)").Scan(&stats.Discount).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.Generated = int(generated)
+	stats.Redeemed = int(redeemed)
+	stats.Remaining = int(generated - redeemed)
+	stats.Orders = int(orders)
+	return stats, nil
+}
+
 func (p *ProductRepository) CreateOffer(offer *entity.Offer) error {
 	if err := p.db.Create(offer).Error; err != nil {
 		return err
@@ -253,9 +613,104 @@ func (p *ProductRepository) CreateOffer(offer *entity.Offer) error {
 	return nil
 }
 
+func (p *ProductRepository) GetOffers(offset, limit int, status, category string) ([]entity.Offer, error) {
+	var offers []entity.Offer
+	query := promotionFilter(p.db.Model(&entity.Offer{}), status, category)
+	err := query.Order("id desc").Offset(offset).Limit(limit).Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+func (p *ProductRepository) GetOfferByID(id int) (*entity.Offer, error) {
+	offer := &entity.Offer{}
+	err := p.db.First(offer, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+// UpdateOffer writes the editable terms of an offer. The usage count and
+// active flag are left alone so an edit cannot undo redemptions made
+// meanwhile; SetOfferActive changes the flag.
+func (p *ProductRepository) UpdateOffer(offer *entity.Offer) error {
+	return p.db.Model(offer).
+		Select("name", "type", "amount", "min_price", "valid_from", "valid_until", "usage_limit", "category", "segment_id").
+		Updates(offer).Error
+}
+
+func (p *ProductRepository) SetOfferActive(offerId int, active bool) error {
+	return p.db.Model(&entity.Offer{}).Where("id = ?", offerId).Update("active", active).Error
+}
+
+func (p *ProductRepository) DeleteOffer(offer *entity.Offer) error {
+	return p.db.Delete(offer).Error
+}
+
+func (p *ProductRepository) GetActiveOffers() ([]entity.Offer, error) {
+	var offers []entity.Offer
+	now := time.Now()
+	err := p.db.Where("active = ? AND valid_from <= ? AND valid_until >= ?", true, now, now).
+		Where("usage_limit = 0 OR used_count < usage_limit").
+		Order("id").Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// RedeemOffer counts one use of the offer, failing once its limit is reached.
+func (p *ProductRepository) RedeemOffer(offerId int) error {
+	result := p.db.Model(&entity.Offer{}).
+		Where("id = ? AND active = ? AND (usage_limit = 0 OR used_count < usage_limit)", offerId, true).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errors.New("Offer is no longer available - review your cart")
+	}
+	return nil
+}
+
+func (p *ProductRepository) ReleaseOffer(offerId int) error {
+	return p.db.Model(&entity.Offer{}).
+		Where("id = ? AND used_count > 0", offerId).
+		Update("used_count", gorm.Expr("used_count - 1")).Error
+}
+
+// GetPromotionRule returns the saved stacking rule, or the default rule when
+// none has been configured.
+func (p *ProductRepository) GetPromotionRule() (*entity.PromotionRule, error) {
+	rule := &entity.PromotionRule{}
+	err := p.db.Order("id").First(rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &entity.PromotionRule{CouponFirst: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (p *ProductRepository) SavePromotionRule(rule *entity.PromotionRule) error {
+	existing := &entity.PromotionRule{}
+	err := p.db.Order("id").First(existing).Error
+	if err == nil {
+		rule.Model = existing.Model
+		rule.ID = existing.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return p.db.Save(rule).Error
+}
+
 func (p *ProductRepository) GetOfferByPrice(price int) (*[]entity.Offer, error) {
 	offers := &[]entity.Offer{}
-	err := p.db.Where("min_price <= ?", price).Find(offers).Error
+	now := time.Now()
+	err := p.db.Where("min_price <= ? AND active = ? AND valid_from <= ? AND valid_until >= ?", price, true, now, now).Find(offers).Error
 	if err != nil {
 		return nil, err
 	} else if offers == nil {
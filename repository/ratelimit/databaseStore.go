@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseStore keeps counters in the database so every instance sees the
+// same limits.
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db}
+}
+
+// Hit counts in a single upsert, so concurrent hits from several instances
+// are all counted.
+func (ds *DatabaseStore) Hit(key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	var result entity.RateCounter
+	err := ds.db.Raw(`INSERT INTO rate_counters (key, count, reset_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_counters.reset_at <= ? THEN 1 ELSE rate_counters.count + 1 END,
+			reset_at = CASE WHEN rate_counters.reset_at <= ? THEN EXCLUDED.reset_at ELSE rate_counters.reset_at END
+		RETURNING key, count, reset_at`, key, now.Add(window), now, now).Scan(&result).Error
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return result.Count, result.ResetAt, nil
+}
+
+func (ds *DatabaseStore) Get(key string, now time.Time) (int, time.Time, error) {
+	var result entity.RateCounter
+	err := ds.db.Where("key = ? AND reset_at > ?", key, now).Take(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return result.Count, result.ResetAt, nil
+}
+
+func (ds *DatabaseStore) Clear(key string) error {
+	return ds.db.Where("key = ?", key).Delete(&entity.RateCounter{}).Error
+}
+
+func (ds *DatabaseStore) Sweep(now time.Time) error {
+	return ds.db.Where("reset_at <= ?", now).Delete(&entity.RateCounter{}).Error
+}
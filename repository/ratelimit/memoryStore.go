@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type counter struct {
+	count   int
+	resetAt time.Time
+}
+
+// MemoryStore keeps counters in the process. Limits are per instance, so it
+// suits a single server.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counter{}}
+}
+
+func (ms *MemoryStore) Hit(key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	c, ok := ms.counters[key]
+	if !ok || !now.Before(c.resetAt) {
+		c = &counter{resetAt: now.Add(window)}
+		ms.counters[key] = c
+	}
+	c.count++
+	return c.count, c.resetAt, nil
+}
+
+func (ms *MemoryStore) Get(key string, now time.Time) (int, time.Time, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	c, ok := ms.counters[key]
+	if !ok || !now.Before(c.resetAt) {
+		return 0, time.Time{}, nil
+	}
+	return c.count, c.resetAt, nil
+}
+
+func (ms *MemoryStore) Clear(key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.counters, key)
+	return nil
+}
+
+func (ms *MemoryStore) Sweep(now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for key, c := range ms.counters {
+		if !now.Before(c.resetAt) {
+			delete(ms.counters, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package ratelimit
+
+import (
+	"errors"
+	"time"
+	"zog/config"
+
+	"gorm.io/gorm"
+)
+
+// Store keeps fixed-window counters for the rate limiter. Each counter starts
+// at the first hit and starts over window later.
+type Store interface {
+	// Hit counts one more event and returns the count and when it resets.
+	Hit(key string, window time.Duration, now time.Time) (int, time.Time, error)
+	// Get returns the current count and reset time, zero if none is running.
+	Get(key string, now time.Time) (int, time.Time, error)
+	Clear(key string) error
+	// Sweep drops counters that have reset.
+	Sweep(now time.Time) error
+}
+
+// NewStore returns the store named in cfg.
+func NewStore(db *gorm.DB, cfg config.RateLimit) (Store, error) {
+	switch cfg.Store {
+	case config.RateLimitMemory:
+		return NewMemoryStore(), nil
+	case config.RateLimitDatabase:
+		return NewDatabaseStore(db), nil
+	}
+	return nil, errors.New("Unknown rate limit store " + cfg.Store)
+}
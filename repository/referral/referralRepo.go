@@ -0,0 +1,168 @@
+package referral
+
+import (
+	"errors"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultReferrerCredit = 100
+	defaultRefereeCredit  = 100
+)
+
+type ReferralRepository struct {
+	db *gorm.DB
+}
+
+func NewReferralRepository(db *gorm.DB) *ReferralRepository {
+	return &ReferralRepository{db}
+}
+
+// GetSetting returns the saved referral credit, or the defaults when none has
+// been configured.
+func (rr *ReferralRepository) GetSetting() (*entity.ReferralSetting, error) {
+	setting := &entity.ReferralSetting{}
+	err := rr.db.Order("id").First(setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &entity.ReferralSetting{ReferrerCredit: defaultReferrerCredit, RefereeCredit: defaultRefereeCredit, Active: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+func (rr *ReferralRepository) SaveSetting(setting *entity.ReferralSetting) error {
+	existing := &entity.ReferralSetting{}
+	err := rr.db.Order("id").First(existing).Error
+	if err == nil {
+		setting.Model = existing.Model
+		setting.ID = existing.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return rr.db.Save(setting).Error
+}
+
+func (rr *ReferralRepository) GetByReferee(refereeId int) (*entity.Referral, error) {
+	var referral entity.Referral
+	result := rr.db.Where("referee_id = ?", refereeId).First(&referral)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &referral, nil
+}
+
+func (rr *ReferralRepository) GetByReferrer(referrerId int) ([]entity.Referral, error) {
+	var referrals []entity.Referral
+	err := rr.db.Where("referrer_id = ?", referrerId).Order("id desc").Find(&referrals).Error
+	if err != nil {
+		return nil, err
+	}
+	return referrals, nil
+}
+
+func (rr *ReferralRepository) Create(referral *entity.Referral) error {
+	return rr.db.Create(referral).Error
+}
+
+// Reward records the referral and credits both wallets together. The unique
+// referee index stops a referral from being paid twice.
+func (rr *ReferralRepository) Reward(referral *entity.Referral) error {
+	return rr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(referral).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&entity.User{}).Where("id = ?", referral.ReferrerId).
+			Update("wallet", gorm.Expr("wallet + ?", referral.ReferrerCredit)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.User{}).Where("id = ?", referral.RefereeId).
+			Update("wallet", gorm.Expr("wallet + ?", referral.RefereeCredit)).Error
+	})
+}
+
+// Clawback takes back the credit paid for a referral whose order was
+// cancelled or returned. Wallets are not taken below zero.
+func (rr *ReferralRepository) Clawback(orderId int, reason string) error {
+	return rr.db.Transaction(func(tx *gorm.DB) error {
+		var referral entity.Referral
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status = ?", orderId, "rewarded").Limit(1).Find(&referral)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		err := tx.Model(&referral).Updates(map[string]interface{}{"status": "clawed back", "reason": reason}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&entity.User{}).Where("id = ?", referral.ReferrerId).
+			Update("wallet", gorm.Expr("GREATEST(wallet - ?, 0)", referral.ReferrerCredit)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.User{}).Where("id = ?", referral.RefereeId).
+			Update("wallet", gorm.Expr("GREATEST(wallet - ?, 0)", referral.RefereeCredit)).Error
+	})
+}
+
+func (rr *ReferralRepository) CountInvited(referrerId int) (int, error) {
+	var count int64
+	query := rr.db.Model(&entity.User{}).Where("referred_by <> 0")
+	if referrerId != 0 {
+		query = query.Where("referred_by = ?", referrerId)
+	}
+	err := query.Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// GetReport counts referrals by outcome and lists those with the given
+// status, newest first.
+func (rr *ReferralRepository) GetReport(offset, limit int, status string) (*entity.ReferralReport, error) {
+	report := &entity.ReferralReport{}
+	invited, err := rr.CountInvited(0)
+	if err != nil {
+		return nil, err
+	}
+	report.Invited = invited
+	var converted, rejected int64
+	err = rr.db.Model(&entity.Referral{}).Where("status = ?", "rewarded").Count(&converted).Error
+	if err != nil {
+		return nil, err
+	}
+	err = rr.db.Model(&entity.Referral{}).Where("status = ?", "rejected").Count(&rejected).Error
+	if err != nil {
+		return nil, err
+	}
+	report.Converted = int(converted)
+	report.Rejected = int(rejected)
+	report.Pending = invited - report.Converted - report.Rejected
+	err = rr.db.Model(&entity.Referral{}).Where("status = ?", "rewarded").
+		Select("COALESCE(SUM(referrer_credit + referee_credit), 0)").Scan(&report.Credited).Error
+	if err != nil {
+		return nil, err
+	}
+	query := rr.db.Order("id desc").Offset(offset).Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err = query.Find(&report.Referrals).Error
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,154 @@
+package seat
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+type SeatRepository struct {
+	db *gorm.DB
+}
+
+func NewSeatRepository(db *gorm.DB) *SeatRepository {
+	return &SeatRepository{db}
+}
+
+func (sr *SeatRepository) CreateSeatMap(ticketId int, input entity.SeatMapInput) (int, error) {
+	total := 0
+	err := sr.db.Transaction(func(tx *gorm.DB) error {
+		for _, sectionInput := range input.Sections {
+			section := &entity.SeatSection{
+				TicketId: ticketId,
+				Name:     sectionInput.Name,
+				Price:    sectionInput.Price,
+			}
+			if err := tx.Create(section).Error; err != nil {
+				return err
+			}
+			var seats []entity.Seat
+			for _, row := range sectionInput.Rows {
+				for number := 1; number <= row.Seats; number++ {
+					seats = append(seats, entity.Seat{
+						TicketId:  ticketId,
+						SectionId: section.ID,
+						RowLabel:  row.Row,
+						Number:    number,
+						Status:    "available",
+					})
+				}
+			}
+			if len(seats) == 0 {
+				continue
+			}
+			if err := tx.Create(&seats).Error; err != nil {
+				return err
+			}
+			total += len(seats)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (sr *SeatRepository) HasSeatMap(ticketId int) (bool, error) {
+	var count int64
+	err := sr.db.Model(&entity.SeatSection{}).Where("ticket_id = ?", ticketId).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (sr *SeatRepository) GetSections(ticketId int) ([]entity.SeatSection, error) {
+	var sections []entity.SeatSection
+	err := sr.db.Where("ticket_id = ?", ticketId).Order("id").Find(&sections).Error
+	if err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+func (sr *SeatRepository) GetSectionByID(sectionId int) (*entity.SeatSection, error) {
+	var section entity.SeatSection
+	result := sr.db.Where("id = ?", sectionId).First(&section)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Section not found")
+		}
+		return nil, result.Error
+	}
+	return &section, nil
+}
+
+func (sr *SeatRepository) GetSeats(ticketId int) ([]entity.Seat, error) {
+	var seats []entity.Seat
+	err := sr.db.Where("ticket_id = ?", ticketId).Order("section_id, row_label, number").Find(&seats).Error
+	if err != nil {
+		return nil, err
+	}
+	return seats, nil
+}
+
+func (sr *SeatRepository) GetSeatByID(seatId int) (*entity.Seat, error) {
+	var seat entity.Seat
+	result := sr.db.Where("id = ?", seatId).First(&seat)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Seat not found")
+		}
+		return nil, result.Error
+	}
+	return &seat, nil
+}
+
+// HoldSeat takes the seat for userId only if it is unsold and not held by
+// someone else, the check and the write being one statement.
+func (sr *SeatRepository) HoldSeat(seatId, userId int, until time.Time) (bool, error) {
+	result := sr.db.Model(&entity.Seat{}).
+		Where("id = ? AND status = ? AND (held_by = 0 OR held_by = ? OR held_until < ?)", seatId, "available", userId, time.Now()).
+		Updates(map[string]interface{}{"held_by": userId, "held_until": until})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (sr *SeatRepository) ReleaseSeat(seatId, userId int) error {
+	return sr.db.Model(&entity.Seat{}).
+		Where("id = ? AND held_by = ? AND status = ?", seatId, userId, "available").
+		Updates(map[string]interface{}{"held_by": 0, "held_until": time.Time{}}).Error
+}
+
+// ClaimSeats marks the seats sold to userId, failing as a whole if any of them
+// has been taken by another user in the meantime.
+func (sr *SeatRepository) ClaimSeats(seatIds []int, userId int) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		for _, seatId := range seatIds {
+			result := tx.Model(&entity.Seat{}).
+				Where("id = ? AND status = ? AND (held_by = 0 OR held_by = ? OR held_until < ?)", seatId, "available", userId, time.Now()).
+				Updates(map[string]interface{}{"status": "sold", "held_by": userId})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != 1 {
+				return errors.New("Selected seat is no longer available - remove it from cart")
+			}
+		}
+		return nil
+	})
+}
+
+func (sr *SeatRepository) FreeSeats(seatIds []int) error {
+	if len(seatIds) == 0 {
+		return nil
+	}
+	return sr.db.Model(&entity.Seat{}).
+		Where("id IN (?)", seatIds).
+		Updates(map[string]interface{}{"status": "available", "held_by": 0, "held_until": time.Time{}}).Error
+}
@@ -0,0 +1,91 @@
+package segment
+
+import (
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+type SegmentRepository struct {
+	db *gorm.DB
+}
+
+func NewSegmentRepository(db *gorm.DB) *SegmentRepository {
+	return &SegmentRepository{db}
+}
+
+func (sr *SegmentRepository) Create(segment *entity.Segment) error {
+	return sr.db.Create(segment).Error
+}
+
+func (sr *SegmentRepository) GetAll() ([]entity.Segment, error) {
+	var segments []entity.Segment
+	err := sr.db.Order("id").Find(&segments).Error
+	if err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+func (sr *SegmentRepository) GetByID(id int) (*entity.Segment, error) {
+	segment := &entity.Segment{}
+	err := sr.db.First(segment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+func (sr *SegmentRepository) Delete(segment *entity.Segment) error {
+	return sr.db.Delete(segment).Error
+}
+
+// CountPromotions returns how many coupons, offers and campaigns are limited
+// to the segment.
+func (sr *SegmentRepository) CountPromotions(segmentId int) (int, error) {
+	total := 0
+	for _, model := range []interface{}{&entity.Coupon{}, &entity.Offer{}, &entity.CouponCampaign{}} {
+		var count int64
+		err := sr.db.Model(model).Where("segment_id = ?", segmentId).Count(&count).Error
+		if err != nil {
+			return 0, err
+		}
+		total += int(count)
+	}
+	return total, nil
+}
+
+// GetCustomerProfile gathers what segment rules need to know about a user.
+// Cancelled orders and failed payments do not count as orders.
+func (sr *SegmentRepository) GetCustomerProfile(userId int) (*entity.CustomerProfile, error) {
+	var user entity.User
+	err := sr.db.First(&user, userId).Error
+	if err != nil {
+		return nil, err
+	}
+	profile := &entity.CustomerProfile{UserId: userId, JoinedAt: user.CreatedAt, Wallet: user.Wallet}
+	orders := sr.db.Model(&entity.Order{}).
+		Where("user_id = ? AND status <> ? AND payment_status <> ?", userId, "canceled", "failed")
+	var count int64
+	err = orders.Session(&gorm.Session{}).Count(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	profile.Orders = int(count)
+	if count > 0 {
+		var lastOrder entity.Order
+		err = orders.Session(&gorm.Session{}).Order("created_at desc").First(&lastOrder).Error
+		if err != nil {
+			return nil, err
+		}
+		profile.LastOrderAt = lastOrder.CreatedAt
+	}
+	err = sr.db.Model(&entity.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND orders.status <> ? AND orders.payment_status <> ? AND order_items.category = ?", userId, "canceled", "failed", "ticket").
+		Distinct().Pluck("order_items.product_id", &profile.TicketIds).Error
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db}
+}
+
+func (sr *SessionRepository) CreateRefresh(token *entity.RefreshToken) error {
+	return sr.db.Create(token).Error
+}
+
+// Rotate marks the refresh token with the given hash used and stores next in
+// its place, copying over who it belongs to. A token that was already used or
+// revoked has its whole family revoked instead.
+func (sr *SessionRepository) Rotate(hash, role string, next *entity.RefreshToken, now time.Time) (*entity.RefreshToken, error) {
+	var current entity.RefreshToken
+	reused := false
+	err := sr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", hash).First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("Invalid refresh token")
+		}
+		if err != nil {
+			return err
+		}
+		if current.Role != role {
+			return errors.New("Invalid refresh token")
+		}
+		if current.Used || current.Revoked {
+			reused = true
+			return tx.Model(&entity.RefreshToken{}).Where("family = ?", current.Family).Update("revoked", true).Error
+		}
+		if now.After(current.ExpiresAt) {
+			return errors.New("Refresh token expired")
+		}
+		err = tx.Model(&current).Update("used", true).Error
+		if err != nil {
+			return err
+		}
+		next.Family = current.Family
+		next.SubjectId = current.SubjectId
+		next.Role = current.Role
+		next.Phone = current.Phone
+		return tx.Create(next).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, errors.New("Refresh token reused, please login again")
+	}
+	return &current, nil
+}
+
+func (sr *SessionRepository) RevokeRefresh(hash string) error {
+	return sr.db.Model(&entity.RefreshToken{}).Where("token_hash = ?", hash).Update("revoked", true).Error
+}
+
+func (sr *SessionRepository) RevokeAccess(revocation *entity.TokenRevocation) error {
+	return sr.db.Create(revocation).Error
+}
+
+// RevokeSubject ends every session of the subject: its refresh tokens are
+// revoked and its access tokens issued before the cutoff are refused.
+func (sr *SessionRepository) RevokeSubject(cutoff *entity.TokenRevocation) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.RefreshToken{}).
+			Where("subject_id = ? AND role = ? AND revoked = ?", cutoff.SubjectId, cutoff.Role, false).
+			Update("revoked", true).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(cutoff).Error
+	})
+}
+
+// IsRevoked reports whether an access token has been revoked by its jti or
+// by a cutoff for its subject.
+func (sr *SessionRepository) IsRevoked(jti string, subjectId int, role string, issuedAt, now time.Time) (bool, error) {
+	var count int64
+	err := sr.db.Model(&entity.TokenRevocation{}).
+		Where("expires_at > ?", now).
+		Where(sr.db.Where("jti = ?", jti).
+			Or("jti = '' AND subject_id = ? AND role = ? AND issued_before >= ?", subjectId, role, issuedAt)).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired drops refresh tokens and revocations that no longer matter.
+func (sr *SessionRepository) DeleteExpired(now time.Time) error {
+	err := sr.db.Unscoped().Where("expires_at < ?", now).Delete(&entity.RefreshToken{}).Error
+	if err != nil {
+		return err
+	}
+	return sr.db.Unscoped().Where("expires_at < ?", now).Delete(&entity.TokenRevocation{}).Error
+}
@@ -0,0 +1,96 @@
+package transfer
+
+import (
+	"errors"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+type TransferRepository struct {
+	db *gorm.DB
+}
+
+func NewTransferRepository(db *gorm.DB) *TransferRepository {
+	return &TransferRepository{db}
+}
+
+func (tr *TransferRepository) GetPassesByOwner(ownerId int) ([]entity.TicketPass, error) {
+	var passes []entity.TicketPass
+	err := tr.db.Where("owner_id = ? AND status = ?", ownerId, "active").Find(&passes).Error
+	if err != nil {
+		return nil, err
+	}
+	return passes, nil
+}
+
+func (tr *TransferRepository) GetPassByID(passId int) (*entity.TicketPass, error) {
+	var pass entity.TicketPass
+	result := tr.db.Where("id = ?", passId).First(&pass)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Ticket not found")
+		}
+		return nil, result.Error
+	}
+	return &pass, nil
+}
+
+func (tr *TransferRepository) CreateTransfer(transfer *entity.TicketTransfer) error {
+	return tr.db.Create(transfer).Error
+}
+
+func (tr *TransferRepository) GetTransferByID(transferId int) (*entity.TicketTransfer, error) {
+	var transfer entity.TicketTransfer
+	result := tr.db.Where("id = ?", transferId).First(&transfer)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Transfer not found")
+		}
+		return nil, result.Error
+	}
+	return &transfer, nil
+}
+
+func (tr *TransferRepository) GetPendingTransfer(passId int) (*entity.TicketTransfer, error) {
+	var transfer entity.TicketTransfer
+	result := tr.db.Where("ticket_pass_id = ? AND status = ?", passId, "pending").First(&transfer)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &transfer, nil
+}
+
+func (tr *TransferRepository) GetTransfersByUser(userId int) ([]entity.TicketTransfer, error) {
+	var transfers []entity.TicketTransfer
+	err := tr.db.Where("from_user_id = ? OR to_user_id = ?", userId, userId).Order("created_at desc").Find(&transfers).Error
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (tr *TransferRepository) UpdateTransfer(transfer *entity.TicketTransfer) error {
+	return tr.db.Save(transfer).Error
+}
+
+// CompleteTransfer moves the pass to the recipient and swaps its code in one
+// transaction. The owner and code in the WHERE clause make a second accept, or
+// one racing a cancellation, a no-op.
+func (tr *TransferRepository) CompleteTransfer(transfer *entity.TicketTransfer) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.TicketPass{}).
+			Where("id = ? AND owner_id = ? AND code = ? AND status = ?", transfer.TicketPassId, transfer.FromUserId, transfer.OldCode, "active").
+			Updates(map[string]interface{}{"owner_id": transfer.ToUserId, "code": transfer.NewCode})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("Ticket is no longer transferable")
+		}
+		return tx.Save(transfer).Error
+	})
+}
@@ -0,0 +1,145 @@
+package twofactor
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db}
+}
+
+// SetPendingSecret stores a secret for an admin still enrolling. It does
+// nothing once TOTP is enabled.
+func (tr *TwoFactorRepository) SetPendingSecret(adminId int, secret string) error {
+	return tr.db.Model(&entity.Admin{}).Where("id = ? AND totp_enabled = ?", adminId, false).
+		Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0}).Error
+}
+
+// Enable turns TOTP on for the admin, recording the step of the code that
+// confirmed it, and replaces their recovery codes.
+func (tr *TwoFactorRepository) Enable(adminId int, step int64, codeHashes []string) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Admin{}).Where("id = ? AND totp_enabled = ? AND totp_secret <> ''", adminId, false).
+			Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("two-factor authentication is already enabled")
+		}
+		return replaceRecoveryCodes(tx, adminId, codeHashes)
+	})
+}
+
+// AcceptStep records step as the last accepted for the admin, and reports
+// false if it or a later one was accepted already, so each code works once.
+func (tr *TwoFactorRepository) AcceptStep(adminId int, step int64) (bool, error) {
+	result := tr.db.Model(&entity.Admin{}).Where("id = ? AND totp_last_step < ?", adminId, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Reset turns TOTP off for the admin and drops their secret, recovery codes
+// and open challenges.
+func (tr *TwoFactorRepository) Reset(adminId int) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.Admin{}).Where("id = ?", adminId).
+			Updates(map[string]interface{}{"totp_secret": "", "totp_enabled": false, "totp_last_step": 0}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("admin_id = ?", adminId).Delete(&entity.RecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.TwoFactorChallenge{}).Where("admin_id = ?", adminId).Update("used", true).Error
+	})
+}
+
+func (tr *TwoFactorRepository) ReplaceRecoveryCodes(adminId int, codeHashes []string) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, adminId, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, adminId int, codeHashes []string) error {
+	err := tx.Where("admin_id = ?", adminId).Delete(&entity.RecoveryCode{}).Error
+	if err != nil {
+		return err
+	}
+	codes := make([]entity.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = entity.RecoveryCode{AdminId: adminId, CodeHash: hash}
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode marks the admin's unused code with the given hash used,
+// and reports whether there was one.
+func (tr *TwoFactorRepository) UseRecoveryCode(adminId int, hash string) (bool, error) {
+	result := tr.db.Model(&entity.RecoveryCode{}).Where("admin_id = ? AND code_hash = ? AND used = ?", adminId, hash, false).
+		Update("used", true)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (tr *TwoFactorRepository) CountRecoveryCodes(adminId int) (int, error) {
+	var count int64
+	err := tr.db.Model(&entity.RecoveryCode{}).Where("admin_id = ? AND used = ?", adminId, false).Count(&count).Error
+	return int(count), err
+}
+
+func (tr *TwoFactorRepository) CreateChallenge(challenge *entity.TwoFactorChallenge) error {
+	return tr.db.Create(challenge).Error
+}
+
+// GetChallenge returns the open challenge with the given hash, nil if it is
+// unknown, used up or expired.
+func (tr *TwoFactorRepository) GetChallenge(hash string, now time.Time) (*entity.TwoFactorChallenge, error) {
+	var challenge entity.TwoFactorChallenge
+	err := tr.db.Where("token_hash = ? AND used = ? AND expires_at > ?", hash, false, now).First(&challenge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// FailChallenge counts a wrong code against the challenge and closes it after
+// maxAttempts.
+func (tr *TwoFactorRepository) FailChallenge(id, maxAttempts int) error {
+	return tr.db.Model(&entity.TwoFactorChallenge{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts": gorm.Expr("attempts + 1"),
+			"used":     gorm.Expr("attempts + 1 >= ?", maxAttempts),
+		}).Error
+}
+
+// UseChallenge closes the challenge, and reports false if it was closed
+// already, so it only ever logs in once.
+func (tr *TwoFactorRepository) UseChallenge(id int) (bool, error) {
+	result := tr.db.Model(&entity.TwoFactorChallenge{}).Where("id = ? AND used = ?", id, false).Update("used", true)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (tr *TwoFactorRepository) DeleteExpired(now time.Time) error {
+	return tr.db.Where("expires_at <= ?", now).Delete(&entity.TwoFactorChallenge{}).Error
+}
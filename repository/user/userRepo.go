@@ -4,6 +4,7 @@ import (
 	"errors"
 	"zog/delivery/models"
 	"zog/domain/entity"
+	"zog/domain/utils"
 
 	"gorm.io/gorm"
 )
@@ -39,6 +40,20 @@ func (ur *UserRepository) GetByEmail(email string) (*entity.User, error) {
 	}
 	return &user, nil
 }
+
+// GetByVerifiedEmail finds the user who verified the address, ignoring case.
+func (ur *UserRepository) GetByVerifiedEmail(email string) (*entity.User, error) {
+	var user entity.User
+	result := ur.db.Where("LOWER(email) = LOWER(?) AND email_verified = ?", email, true).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
 func (ur *UserRepository) GetByPhone(phone string) (*entity.User, error) {
 	var user entity.User
 	result := ur.db.Where(&entity.User{Phone: phone}).First(&user)
@@ -51,6 +66,36 @@ func (ur *UserRepository) GetByPhone(phone string) (*entity.User, error) {
 	return &user, nil
 }
 
+func (ur *UserRepository) GetByReferralCode(code string) (*entity.User, error) {
+	var user entity.User
+	result := ur.db.Where("referral_code = ?", code).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+// NewReferralCode returns a referral code no user has yet.
+func (ur *UserRepository) NewReferralCode() (string, error) {
+	for i := 0; i < 5; i++ {
+		code, err := utils.GenerateCode(8, utils.CodeAlphabet)
+		if err != nil {
+			return "", err
+		}
+		existing, err := ur.GetByReferralCode(code)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return code, nil
+		}
+	}
+	return "", errors.New("Referral code generation failed")
+}
+
 func (ur *UserRepository) CheckPermission(user *entity.User) (bool, error) {
 	result := ur.db.Where(&entity.User{Phone: user.Phone}).First(user)
 	if result.Error != nil {
@@ -99,6 +144,18 @@ func (ur *UserRepository) Update(user *entity.User) error {
 	return ur.db.Updates(user).Error
 }
 
+// UpdateProfile saves the user's details and, when the email changed, marks
+// it unverified in the same transaction.
+func (ur *UserRepository) UpdateProfile(user *entity.User, emailChanged bool) error {
+	return ur.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Updates(user).Error
+		if err != nil || !emailChanged {
+			return err
+		}
+		return tx.Model(&entity.User{}).Where("id = ?", user.ID).Update("email_verified", false).Error
+	})
+}
+
 func (ur *UserRepository) Delete(id uint) error {
 	return ur.db.Delete(&entity.User{}, id).Error
 }
@@ -130,9 +187,17 @@ func (cr *UserRepository) GetAddressById(addressId int) (*entity.Address, error)
 	return &address, nil
 }
 
-func (ar *UserRepository) CreateOtpKey(otpKey *entity.OtpKey) error {
+func (ur *UserRepository) CreateNotification(notification *entity.Notification) error {
+	return ur.db.Create(notification).Error
+}
 
-	return ar.db.Create(otpKey).Error
+func (ur *UserRepository) GetNotifications(userId int) ([]entity.Notification, error) {
+	var notifications []entity.Notification
+	err := ur.db.Where("user_id = ?", userId).Order("created_at desc").Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
 }
 
 // done
@@ -0,0 +1,106 @@
+package waitlist
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+type WaitlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWaitlistRepository(db *gorm.DB) *WaitlistRepository {
+	return &WaitlistRepository{db}
+}
+
+func (wr *WaitlistRepository) Create(entry *entity.WaitlistEntry) error {
+	return wr.db.Create(entry).Error
+}
+
+func (wr *WaitlistRepository) Update(entry *entity.WaitlistEntry) error {
+	return wr.db.Save(entry).Error
+}
+
+func (wr *WaitlistRepository) GetActiveEntry(userId int, category string, productId int) (*entity.WaitlistEntry, error) {
+	var entry entity.WaitlistEntry
+	result := wr.db.Where("user_id = ? AND category = ? AND product_id = ? AND status IN (?)", userId, category, productId, []string{"waiting", "reserved"}).First(&entry)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &entry, nil
+}
+
+func (wr *WaitlistRepository) GetByUser(userId int) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := wr.db.Where("user_id = ? AND status IN (?)", userId, []string{"waiting", "reserved"}).Order("created_at").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (wr *WaitlistRepository) GetWaiting(category string, productId int) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := wr.db.Where("category = ? AND product_id = ? AND status = ?", category, productId, "waiting").Order("created_at").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (wr *WaitlistRepository) GetWaitingProducts() ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := wr.db.Model(&entity.WaitlistEntry{}).Select("DISTINCT category, product_id").Where("status = ?", "waiting").Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (wr *WaitlistRepository) GetExpiredReservations(now time.Time) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	err := wr.db.Where("status = ? AND reserved_until < ?", "reserved", now).Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReservedQuantity sums the live reservations on a product, leaving out the
+// ones held by excludeUserId so a user is never blocked by their own hold.
+func (wr *WaitlistRepository) ReservedQuantity(category string, productId, excludeUserId int) (int, error) {
+	var reserved int64
+	err := wr.db.Model(&entity.WaitlistEntry{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("category = ? AND product_id = ? AND status = ? AND reserved_until >= ? AND user_id <> ?", category, productId, "reserved", time.Now(), excludeUserId).
+		Row().Scan(&reserved)
+	if err != nil {
+		return 0, err
+	}
+	return int(reserved), nil
+}
+
+func (wr *WaitlistRepository) MarkPurchased(userId int, category string, productId int) error {
+	return wr.db.Model(&entity.WaitlistEntry{}).
+		Where("user_id = ? AND category = ? AND product_id = ? AND status = ?", userId, category, productId, "reserved").
+		Update("status", "purchased").Error
+}
+
+func (wr *WaitlistRepository) GetReport() ([]entity.WaitlistReport, error) {
+	var report []entity.WaitlistReport
+	err := wr.db.Model(&entity.WaitlistEntry{}).
+		Select("category, product_id, SUM(CASE WHEN status = 'waiting' THEN 1 ELSE 0 END) AS waiting, SUM(CASE WHEN status = 'reserved' THEN 1 ELSE 0 END) AS reserved").
+		Where("status IN (?)", []string{"waiting", "reserved"}).
+		Group("category, product_id").
+		Scan(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
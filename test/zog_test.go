@@ -9,11 +9,19 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"zog/config"
 	"zog/delivery/handlers"
+	middlewares "zog/delivery/middlewares"
 	"zog/delivery/models"
 	"zog/domain/entity"
+	emailrepository "zog/repository/email"
 	infrastructure "zog/repository/infrastructure"
+	"zog/repository/mailer"
+	"zog/repository/otp"
+	"zog/repository/ratelimit"
 	repository "zog/repository/user"
+	emailusecase "zog/usecase/email"
+	ratelimitusecase "zog/usecase/ratelimit"
 	usecase "zog/usecase/user"
 
 	"github.com/gin-gonic/gin"
@@ -30,10 +38,20 @@ var userRepo *repository.UserRepository
 var db *gorm.DB
 
 func init() {
-	db, _ = infrastructure.ConnectToDB()
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+	db, _ = infrastructure.ConnectToDB(cfg.Database)
 	userRepo = repository.NewUserRepository(db)
-	userUsecase := usecase.NewUser(userRepo)
-	handler = &handlers.UserHandler{UserUsecase: userUsecase}
+	otpProvider, err := otp.NewProvider(db, cfg.OTP, cfg.Twilio)
+	if err != nil {
+		panic(err)
+	}
+	userUsecase := usecase.NewUser(userRepo, otpProvider)
+	rateLimit := middlewares.NewRateLimit(ratelimitusecase.NewRateLimit(ratelimit.NewMemoryStore(), cfg.RateLimit))
+	emailUsecase := emailusecase.NewEmail(emailrepository.NewEmailRepository(db), userRepo, mailer.NewFileMailer(cfg.Mail), cfg.Email)
+	handler = &handlers.UserHandler{UserUsecase: userUsecase, EmailUsecase: emailUsecase, RateLimit: rateLimit}
 }
 
 func TestSignup(t *testing.T) {
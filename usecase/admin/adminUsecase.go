@@ -5,19 +5,21 @@ import (
 	"zog/domain/entity"
 	"zog/domain/utils"
 	repository "zog/repository/admin"
+	"zog/repository/otp"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AdminUsecase struct {
 	adminRepo *repository.AdminRepository
+	otp       otp.OTPProvider
 }
 
-func NewAdmin(adminRepo *repository.AdminRepository) *AdminUsecase {
-	return &AdminUsecase{adminRepo: adminRepo}
+func NewAdmin(adminRepo *repository.AdminRepository, otpProvider otp.OTPProvider) *AdminUsecase {
+	return &AdminUsecase{adminRepo: adminRepo, otp: otpProvider}
 }
 
-func (ac *AdminUsecase) ExecuteAdminCreate(admin entity.Admin) (*entity.Admin, error) {
+func (ac *AdminUsecase) ExecuteAdminCreate(admin entity.AdminInput) (*entity.Admin, error) {
 	email, err := ac.adminRepo.GetByEmail(admin.Email)
 	if err != nil {
 		return nil, err
@@ -33,6 +35,13 @@ func (ac *AdminUsecase) ExecuteAdminCreate(admin entity.Admin) (*entity.Admin, e
 		return nil, errors.New("admin with this phone already exists")
 	}
 
+	if admin.Role == "" {
+		admin.Role = utils.RoleSupport
+	}
+	if !utils.ValidRole(admin.Role) {
+		return nil, errors.New("unknown admin role")
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(admin.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -44,6 +53,7 @@ func (ac *AdminUsecase) ExecuteAdminCreate(admin entity.Admin) (*entity.Admin, e
 		Phone:     admin.Phone,
 		Role:      admin.Role,
 		Password:  string(hashedPassword),
+		Active:    true,
 	}
 
 	err = ac.adminRepo.Create(newAdmin)
@@ -61,6 +71,9 @@ func (au *AdminUsecase) ExecuteLoginWithPassword(phone, password string) (int, e
 	if admin == nil {
 		return 0, errors.New("admin with this phone not found")
 	}
+	if !admin.Active {
+		return 0, errors.New("admin account is deactivated")
+	}
 	if err := bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(password)); err != nil {
 		return 0, errors.New("Invalid Password")
 	} else {
@@ -69,32 +82,31 @@ func (au *AdminUsecase) ExecuteLoginWithPassword(phone, password string) (int, e
 
 }
 
-func (au *AdminUsecase) ExecuteAdminLogin(phone string) error {
+func (au *AdminUsecase) ExecuteAdminLogin(phone string) (string, error) {
 	result, err := au.adminRepo.GetByPhone(phone)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if result == nil {
-		return errors.New("admin with this phone not found")
+		return "", errors.New("admin with this phone not found")
 	}
-	key, err1 := utils.SendOtp(phone)
-	if err1 != nil {
-		return err
-	} else {
-		err = au.adminRepo.CreateOtpKey(key, phone)
-		if err != nil {
-			return err
-		}
-		return nil
+	if !result.Active {
+		return "", errors.New("admin account is deactivated")
 	}
-
+	return au.otp.Send(phone, entity.OtpAdminLogin)
 }
-func (au *AdminUsecase) ExecuteOtpValidation(phone, otp string) (*entity.Admin, error) {
+func (au *AdminUsecase) ExecuteOtpValidation(phone, key, otp string) (*entity.Admin, error) {
 	result, err := au.adminRepo.GetByPhone(phone)
 	if err != nil {
 		return nil, err
 	}
-	err1 := utils.CheckOtp(phone, otp)
+	if result == nil {
+		return nil, errors.New("admin with this phone not found")
+	}
+	if !result.Active {
+		return nil, errors.New("admin account is deactivated")
+	}
+	err1 := au.otp.Check(phone, key, entity.OtpAdminLogin, otp)
 	if err1 != nil {
 		return nil, err1
 	}
@@ -182,15 +194,159 @@ func (a *AdminUsecase) ExecuteSearchUserByName(name string) ([]entity.User, erro
 	return userlist, nil
 }
 
-func (tp *AdminUsecase) ExecuteTogglePermission(id int) error {
+// ExecuteTogglePermission blocks or unblocks the user and returns whether
+// they may now log in.
+func (tp *AdminUsecase) ExecuteTogglePermission(id int) (bool, error) {
 	result, err := tp.adminRepo.GetByID(id)
 	if err != nil {
-		return err
+		return false, err
 	}
 	result.Permission = !result.Permission
 	err1 := tp.adminRepo.Update(result)
 	if err1 != nil {
-		return errors.New("user permission toggling failed")
+		return false, errors.New("user permission toggling failed")
+	}
+	return result.Permission, nil
+}
+
+// ExecuteAdminAccess returns the admin behind a session, for checking their
+// role on each request.
+func (au *AdminUsecase) ExecuteAdminAccess(adminId int) (*entity.Admin, error) {
+	admin, err := au.adminRepo.GetAdminByID(adminId)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return nil, errors.New("admin not found")
+	}
+	return admin, nil
+}
+
+func (au *AdminUsecase) ExecuteAdminList() ([]entity.Admin, error) {
+	admins, err := au.adminRepo.GetAdmins()
+	if err != nil {
+		return nil, errors.New("fetching admins failed")
+	}
+	return admins, nil
+}
+
+func (au *AdminUsecase) ExecuteRoles() []entity.AdminRole {
+	roles := make([]entity.AdminRole, 0, len(utils.AdminRoles))
+	for _, role := range utils.AdminRoles {
+		roles = append(roles, entity.AdminRole{Role: role, Permissions: utils.RolePermissions(role)})
+	}
+	return roles
+}
+
+// ExecuteAssignRole changes an admin's role. The last active super-admin
+// cannot be given another role.
+func (au *AdminUsecase) ExecuteAssignRole(adminId int, role string) (*entity.Admin, error) {
+	if !utils.ValidRole(role) {
+		return nil, errors.New("unknown admin role")
+	}
+	admin, err := au.ExecuteAdminAccess(adminId)
+	if err != nil {
+		return nil, err
+	}
+	if admin.Role == utils.RoleSuperAdmin && role != utils.RoleSuperAdmin {
+		if err := au.keepSuperAdmin(adminId); err != nil {
+			return nil, err
+		}
+	}
+	admin.Role = role
+	err = au.adminRepo.UpdateAdmin(admin)
+	if err != nil {
+		return nil, errors.New("assigning role failed")
+	}
+	return admin, nil
+}
+
+// ExecuteToggleActive deactivates or reactivates an admin and returns
+// whether they are now active. Admins cannot deactivate themselves, and the
+// last active super-admin cannot be deactivated.
+func (au *AdminUsecase) ExecuteToggleActive(actorId, adminId int) (bool, error) {
+	admin, err := au.ExecuteAdminAccess(adminId)
+	if err != nil {
+		return false, err
+	}
+	if admin.Active {
+		if actorId == adminId {
+			return false, errors.New("admins cannot deactivate themselves")
+		}
+		if admin.Role == utils.RoleSuperAdmin {
+			if err := au.keepSuperAdmin(adminId); err != nil {
+				return false, err
+			}
+		}
+	}
+	admin.Active = !admin.Active
+	err = au.adminRepo.UpdateAdmin(admin)
+	if err != nil {
+		return false, errors.New("admin status toggling failed")
+	}
+	return admin.Active, nil
+}
+
+func (au *AdminUsecase) keepSuperAdmin(adminId int) error {
+	others, err := au.adminRepo.CountSuperAdmins(adminId)
+	if err != nil {
+		return err
+	}
+	if others == 0 {
+		return errors.New("at least one active super-admin is required")
 	}
 	return nil
 }
+
+// ExecuteMigrateRoles runs at startup and makes sure there is a super-admin.
+// It returns the admin promoted, if any.
+func (au *AdminUsecase) ExecuteMigrateRoles() (*entity.Admin, error) {
+	return au.adminRepo.MigrateRoles()
+}
+
+// ExecuteResetPassword sets a new password for the admin with the phone.
+func (au *AdminUsecase) ExecuteResetPassword(phone, password string) (*entity.Admin, error) {
+	admin, err := au.adminRepo.GetByPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return nil, errors.New("admin with this phone not found")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	admin.Password = string(hashedPassword)
+	err = au.adminRepo.UpdateAdmin(admin)
+	if err != nil {
+		return nil, errors.New("password reset failed")
+	}
+	return admin, nil
+}
+
+// ExecuteDeactivate deactivates the admin with the phone. The last active
+// super-admin cannot be deactivated.
+func (au *AdminUsecase) ExecuteDeactivate(phone string) (*entity.Admin, error) {
+	admin, err := au.adminRepo.GetByPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return nil, errors.New("admin with this phone not found")
+	}
+	if !admin.Active {
+		return nil, errors.New("admin is already deactivated")
+	}
+	if admin.Role == utils.RoleSuperAdmin {
+		if err := au.keepSuperAdmin(admin.ID); err != nil {
+			return nil, err
+		}
+	}
+	admin.Active = false
+	err = au.adminRepo.UpdateAdmin(admin)
+	if err != nil {
+		return nil, errors.New("admin deactivation failed")
+	}
+	return admin, nil
+}
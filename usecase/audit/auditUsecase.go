@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	repository "zog/repository/audit"
+)
+
+type AuditUsecase struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAudit(auditRepo *repository.AuditRepository) *AuditUsecase {
+	return &AuditUsecase{auditRepo: auditRepo}
+}
+
+// ExecuteSnapshot reads the target of a call before it runs. A failed read
+// is logged and leaves the diff without its before side rather than
+// blocking the call.
+func (au *AuditUsecase) ExecuteSnapshot(name string, id int) map[string]interface{} {
+	row, err := au.auditRepo.Snapshot(name, id)
+	if err != nil {
+		log.Println("audit: snapshot of", name, id, "failed:", err)
+		return nil
+	}
+	return row
+}
+
+// ExecuteRecord stores the entry for a finished call, diffing the target
+// against the snapshot taken before it.
+func (au *AuditUsecase) ExecuteRecord(entry *entity.AuditEntry, before map[string]interface{}, request interface{}) error {
+	after := au.ExecuteSnapshot(entry.Entity, entry.EntityId)
+	diff := utils.AuditDiff(before, after)
+	if len(diff) > 0 {
+		encoded, err := json.Marshal(diff)
+		if err != nil {
+			return errors.New("Encoding audit diff failed")
+		}
+		entry.Diff = string(encoded)
+	}
+	if request != nil {
+		encoded, err := json.Marshal(utils.RedactSecrets(request))
+		if err != nil {
+			return errors.New("Encoding audit request failed")
+		}
+		entry.Request = string(encoded)
+	}
+	entry.CreatedAt = time.Now()
+	err := au.auditRepo.Create(entry)
+	if err != nil {
+		return errors.New("Saving audit entry failed")
+	}
+	return nil
+}
+
+// ExecuteSearch returns a page of the audit log. Dates are days as
+// YYYY-MM-DD and both ends are included.
+func (au *AuditUsecase) ExecuteSearch(filter entity.AuditFilter, from, to string, page, limit int) (*entity.AuditLog, error) {
+	if page < 1 || limit < 1 {
+		return nil, errors.New("Invalid page or limit")
+	}
+	if from != "" {
+		day, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return nil, errors.New("Invalid from date, use YYYY-MM-DD")
+		}
+		filter.From = day
+	}
+	if to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return nil, errors.New("Invalid to date, use YYYY-MM-DD")
+		}
+		filter.To = day.AddDate(0, 0, 1)
+	}
+	result, err := au.auditRepo.Search(filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, errors.New("Fetching audit log failed")
+	}
+	return result, nil
+}
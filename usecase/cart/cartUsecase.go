@@ -3,18 +3,34 @@ package cart
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 	"zog/domain/entity"
+	"zog/domain/utils"
 	repository "zog/repository/cart"
+	loyaltyrepository "zog/repository/loyalty"
 	productrepository "zog/repository/product"
+	seatrepository "zog/repository/seat"
+	segmentrepository "zog/repository/segment"
+	waitlistrepository "zog/repository/waitlist"
 )
 
+// ErrCartChanged is returned with the review when checkout finds the cart
+// no longer matches the catalogue.
+var ErrCartChanged = errors.New("Cart has changed - review it before placing the order")
+
 type CartUsecase struct {
-	cartRepo    *repository.CartRepository
-	productRepo *productrepository.ProductRepository
+	cartRepo     *repository.CartRepository
+	productRepo  *productrepository.ProductRepository
+	waitlistRepo *waitlistrepository.WaitlistRepository
+	seatRepo     *seatrepository.SeatRepository
+	segmentRepo  *segmentrepository.SegmentRepository
+	loyaltyRepo  *loyaltyrepository.LoyaltyRepository
 }
 
-func NewCart(cartRepo *repository.CartRepository, productRepo *productrepository.ProductRepository) *CartUsecase {
-	return &CartUsecase{cartRepo: cartRepo, productRepo: productRepo}
+func NewCart(cartRepo *repository.CartRepository, productRepo *productrepository.ProductRepository, waitlistRepo *waitlistrepository.WaitlistRepository, seatRepo *seatrepository.SeatRepository, segmentRepo *segmentrepository.SegmentRepository, loyaltyRepo *loyaltyrepository.LoyaltyRepository) *CartUsecase {
+	return &CartUsecase{cartRepo: cartRepo, productRepo: productRepo, waitlistRepo: waitlistRepo, seatRepo: seatRepo, segmentRepo: segmentRepo, loyaltyRepo: loyaltyRepo}
 }
 
 func (cu *CartUsecase) ExecuteAddToCart(product string, id int, quantity int, userid int) error {
@@ -37,26 +53,50 @@ func (cu *CartUsecase) ExecuteAddToCart(product string, id int, quantity int, us
 		if err != nil {
 			return errors.New("Ticket not found")
 		}
+		seated, err := cu.seatRepo.HasSeatMap(id)
+		if err != nil {
+			return errors.New("error with server")
+		}
+		if seated {
+			return errors.New("Select a seat for this event")
+		}
 
+		price, err := cu.ticketPrice(ticket)
+		if err != nil {
+			return errors.New("Fetching ticket price failed")
+		}
 		cartItem := &entity.CartItem{
 			CartId:      cartId,
 			ProductId:   int(ticket.ID),
 			Category:    "ticket",
 			Quantity:    quantity,
 			ProductName: ticket.Name,
-			Price:       float64(ticket.Price),
+			Price:       float64(price),
 		}
 		existingTicket, err := cu.cartRepo.GetByName(ticket.Name, cartId)
 		if err != nil {
 			fmt.Println(err)
 		}
+		inCart := 0
+		if existingTicket != nil {
+			inCart = existingTicket.Quantity
+		}
+		err = cu.checkStock("ticket", ticket.ID, inCart+quantity, userid)
+		if err != nil {
+			return err
+		}
 		if existingTicket == nil {
 			err := cu.cartRepo.CreateCartItem(cartItem)
 			if err != nil {
 				return errors.New("Adding new ticket to cart item failed")
 			}
 		} else {
+			// the whole line moves to the current price so the cart never
+			// mixes units bought at different prices
+			userCart.TotalPrice -= existingTicket.Price * float64(existingTicket.Quantity)
+			userCart.TotalPrice += cartItem.Price * float64(existingTicket.Quantity)
 			existingTicket.Quantity += quantity
+			existingTicket.Price = cartItem.Price
 			err := cu.cartRepo.UpdateCartItem(existingTicket)
 			if err != nil {
 				return errors.New("error updating existing cartitem")
@@ -79,13 +119,26 @@ func (cu *CartUsecase) ExecuteAddToCart(product string, id int, quantity int, us
 			Price:       float64(apparel.Price),
 		}
 		existingApparel, err := cu.cartRepo.GetByName(apparel.Name, cartId)
+		inCart := 0
+		if existingApparel != nil {
+			inCart = existingApparel.Quantity
+		}
+		err = cu.checkStock("apparel", apparel.ID, inCart+quantity, userid)
+		if err != nil {
+			return err
+		}
 		if existingApparel == nil {
 			err = cu.cartRepo.CreateCartItem(cartItem)
 			if err != nil {
 				return errors.New("Adding new ticket to cart item failed")
 			}
 		} else {
+			// the whole line moves to the current price so the cart never
+			// mixes units bought at different prices
+			userCart.TotalPrice -= existingApparel.Price * float64(existingApparel.Quantity)
+			userCart.TotalPrice += cartItem.Price * float64(existingApparel.Quantity)
 			existingApparel.Quantity += quantity
+			existingApparel.Price = cartItem.Price
 			err := cu.cartRepo.UpdateCartItem(existingApparel)
 			if err != nil {
 				return errors.New("error updating existing cartitem")
@@ -94,6 +147,10 @@ func (cu *CartUsecase) ExecuteAddToCart(product string, id int, quantity int, us
 		userCart.TotalPrice += cartItem.Price * float64(quantity)
 		userCart.ApparelQuantity += quantity
 	}
+	err = cu.refreshDiscount(userid, userCart)
+	if err != nil {
+		return err
+	}
 	err1 := cu.cartRepo.UpdateCart(userCart)
 	if err1 != nil {
 		return errors.New("Cart price updation failed")
@@ -102,6 +159,21 @@ func (cu *CartUsecase) ExecuteAddToCart(product string, id int, quantity int, us
 	return nil
 }
 
+func (cu *CartUsecase) checkStock(category string, productId, quantity, userId int) error {
+	inventory, err := cu.productRepo.GetByProductId(productId, category)
+	if err != nil {
+		return errors.New("Product not found in inventory")
+	}
+	reserved, err := cu.waitlistRepo.ReservedQuantity(category, productId, userId)
+	if err != nil {
+		return errors.New("Checking stock failed")
+	}
+	if inventory.Quantity-reserved < quantity {
+		return errors.New("Product out of stock - join the waitlist")
+	}
+	return nil
+}
+
 func (cu *CartUsecase) ExecuteCart(userId int) (*entity.Cart, error) {
 	userCart, err := cu.cartRepo.GetByUserID(userId)
 	if err != nil {
@@ -152,7 +224,7 @@ func (cu *CartUsecase) ExecuteRemoveFromCart(product string, id int, userId int)
 				return errors.New("error updating existing cartitem")
 			}
 		}
-		userCart.TotalPrice -= float64(ticket.Price)
+		userCart.TotalPrice -= existingTicket.Price
 		userCart.TicketQuantity -= 1
 	} else if product == "apparel" {
 		apparel, err := cu.productRepo.GetApparelByID(id)
@@ -175,12 +247,13 @@ func (cu *CartUsecase) ExecuteRemoveFromCart(product string, id int, userId int)
 				return errors.New("error updating existing cartitem")
 			}
 		}
-		userCart.TotalPrice -= float64(apparel.Price)
+		userCart.TotalPrice -= existingApparel.Price
 		userCart.ApparelQuantity -= 1
 
 	}
-	if userCart.OfferPrice > 0 {
-		userCart.OfferPrice = 0
+	err = cu.refreshDiscount(userId, userCart)
+	if err != nil {
+		return err
 	}
 	err1 := cu.cartRepo.UpdateCart(userCart)
 	if err1 != nil {
@@ -196,6 +269,10 @@ func (c *CartUsecase) ExecuteAddToWishlist(catergory string, productId int, user
 		if err != nil {
 			return errors.New("Ticket not found")
 		}
+		price, err := c.ticketPrice(ticket)
+		if err != nil {
+			return errors.New("Fetching ticket price failed")
+		}
 		exsisting, err := c.cartRepo.GetTicketFromWishlist(ticket.Category, ticket.ID, userId)
 		if err != nil {
 			return errors.New("Error finding exsisting product")
@@ -208,7 +285,7 @@ func (c *CartUsecase) ExecuteAddToWishlist(catergory string, productId int, user
 				Category:    ticket.Category,
 				ProductId:   ticket.ID,
 				ProductName: ticket.Name,
-				Price:       float64(ticket.Price),
+				Price:       float64(price),
 			}
 			err = c.cartRepo.AddTicketToWishlist(wishTicket)
 			if err != nil {
@@ -282,12 +359,16 @@ func (c *CartUsecase) ExecuteViewWishlist(userId int) (*[]entity.Wishlist, error
 	return wishlist, nil
 }
 
+// ExecuteApplyCoupon validates the coupon against the cart and stores the
+// discount on it. The use is only counted when the order is placed.
 func (c *CartUsecase) ExecuteApplyCoupon(userId int, code string) (int, error) {
-	var totalOffer, totalPrice int
 	userCart, err := c.cartRepo.GetByUserID(userId)
 	if err != nil {
 		return 0, errors.New("Failed to find user cart")
 	}
+	if userCart.CouponCode != "" {
+		return 0, errors.New("A coupon is already applied to the cart")
+	}
 	coupon, err := c.productRepo.GetCouponByCode(code)
 	if err != nil {
 		return 0, errors.New("Sorry coupon not found")
@@ -296,47 +377,521 @@ func (c *CartUsecase) ExecuteApplyCoupon(userId int, code string) (int, error) {
 	if err != nil {
 		return 0, errors.New("User Cart Items not found")
 	}
-	for _, cartItem := range cartItems {
-		if cartItem.Category == coupon.Category {
-			totalPrice += int(cartItem.Price) * cartItem.Quantity
+	_, err = c.couponDiscount(userId, coupon, cartItems)
+	if err != nil {
+		return 0, err
+	}
+	userCart.CouponCode = coupon.Code
+	dropped, err := c.applyDiscount(userId, userCart, cartItems)
+	if err != nil {
+		return 0, err
+	}
+	err = c.savePromotions(userCart)
+	if err != nil {
+		return 0, err
+	}
+	err = c.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return 0, errors.New("User Cart updation failed")
+	}
+	if dropped != "" {
+		return 0, errors.New(dropped)
+	}
+	for _, promotion := range userCart.Promotions {
+		if promotion.Kind == "coupon" {
+			return promotion.Amount, nil
+		}
+	}
+	return 0, nil
+}
+
+func (c *CartUsecase) ExecuteRemoveCoupon(userId int) error {
+	userCart, err := c.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return errors.New("Failed to find user cart")
+	}
+	if userCart.CouponCode == "" {
+		return errors.New("No coupon applied to cart")
+	}
+	userCart.CouponCode = ""
+	err = c.refreshDiscount(userId, userCart)
+	if err != nil {
+		return err
+	}
+	err = c.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return errors.New("User Cart updation failed")
+	}
+	return nil
+}
+
+// ExecuteRefreshDiscount recomputes the cart discount after a change made
+// outside this usecase, such as a seat being added.
+func (c *CartUsecase) ExecuteRefreshDiscount(userId int) error {
+	userCart, err := c.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return errors.New("Failed to find user cart")
+	}
+	err = c.refreshDiscount(userId, userCart)
+	if err != nil {
+		return err
+	}
+	err = c.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return errors.New("User Cart updation failed")
+	}
+	return nil
+}
+
+// ExecuteRepriceOffer recomputes the discount of every cart holding the offer
+// after an admin changed, paused or deleted it.
+func (c *CartUsecase) ExecuteRepriceOffer(offerId int) error {
+	carts, err := c.cartRepo.GetCartsByPromotion("offer", offerId)
+	if err != nil {
+		return errors.New("Fetching carts failed")
+	}
+	for i := range carts {
+		err = c.refreshDiscount(carts[i].UserId, &carts[i])
+		if err != nil {
+			return err
+		}
+		err = c.cartRepo.UpdateCart(&carts[i])
+		if err != nil {
+			return errors.New("User Cart updation failed")
 		}
 	}
-	if totalPrice > 0 {
-		if coupon.Type == "percentage" {
-			totalOffer = totalPrice / coupon.Amount
+	return nil
+}
+
+func (c *CartUsecase) refreshDiscount(userId int, userCart *entity.Cart) error {
+	cartItems, err := c.cartRepo.GetAllCartItems(int(userCart.ID))
+	if err != nil {
+		return errors.New("User Cart Items not found")
+	}
+	_, err = c.applyDiscount(userId, userCart, cartItems)
+	if err != nil {
+		return err
+	}
+	return c.savePromotions(userCart)
+}
+
+// applyDiscount works the cart discount out from scratch. The coupon and every
+// eligible offer are combined according to the promotion rule, loyalty points
+// the user chose to spend come off what is left, and the result is saved as the
+// cart's breakdown. When the coupon no longer holds, or the rule leaves no room
+// for it, it is dropped and the reason returned.
+func (c *CartUsecase) applyDiscount(userId int, userCart *entity.Cart, cartItems []entity.CartItem) (string, error) {
+	userCart.OfferPrice = 0
+	userCart.OfferNote = ""
+	userCart.Promotions = nil
+	rule, err := c.productRepo.GetPromotionRule()
+	if err != nil {
+		return "", errors.New("Fetching promotion rules failed")
+	}
+	dropped := ""
+	var couponPromotion *entity.AppliedPromotion
+	if userCart.CouponCode != "" {
+		coupon, err := c.productRepo.GetCouponByCode(userCart.CouponCode)
+		amount := 0
+		if err == nil {
+			amount, err = c.couponDiscount(userId, coupon, cartItems)
+		}
+		if err == nil {
+			couponPromotion = &entity.AppliedPromotion{Kind: "coupon", PromotionId: coupon.Id, Name: coupon.Code, Amount: amount}
 		} else {
-			totalOffer = coupon.Amount
+			dropped = err.Error()
+			userCart.CouponCode = ""
 		}
-	} else {
-		return 0, errors.New("Add more product from different category")
 	}
-	if userCart.OfferPrice != 0 {
-		return 0, errors.New("User Cart offer already applied")
-	} else {
-		userCart.OfferPrice = totalOffer
-		err = c.cartRepo.UpdateCart(userCart)
-		if err != nil {
-			return 0, errors.New("User Cart updation failed")
+	offers, err := c.activeOffers(userId)
+	if err != nil {
+		return dropped, err
+	}
+	now := time.Now()
+	var offerPromotions []entity.AppliedPromotion
+	for i := range offers {
+		amount, err := utils.OfferDiscount(&offers[i], cartItems, now)
+		if err == nil {
+			offerPromotions = append(offerPromotions, entity.AppliedPromotion{Kind: "offer", PromotionId: offers[i].Id, Name: offers[i].Name, Amount: amount})
 		}
-		var usedCoupon = entity.UsedCoupon{
-			UserId:     userId,
-			CouponCode: code,
+	}
+	sort.SliceStable(offerPromotions, func(i, j int) bool {
+		return offerPromotions[i].Amount > offerPromotions[j].Amount
+	})
+
+	promotions, couponKept, capped := utils.StackPromotions(rule, couponPromotion, offerPromotions, int(userCart.TotalPrice))
+	if couponPromotion != nil && !couponKept {
+		dropped = "Coupon does not combine with the offers on this cart"
+		userCart.CouponCode = ""
+	}
+	var notes []string
+	for _, promotion := range promotions {
+		userCart.OfferPrice += promotion.Amount
+		notes = append(notes, fmt.Sprintf("%s %s saves %d", promotion.Kind, promotion.Name, promotion.Amount))
+	}
+	userCart.Promotions = promotions
+	switch {
+	case len(offerPromotions) == 0:
+		_, _, hint := utils.BestOffer(offers, cartItems, now)
+		if hint != "" {
+			notes = append(notes, hint)
 		}
-		err = c.productRepo.UpdateCouponUsage(&usedCoupon)
+	case !rule.StackOffers && len(offerPromotions) > 1:
+		notes = append(notes, fmt.Sprintf("best of %d eligible offers", len(offerPromotions)))
+	}
+	if capped {
+		notes = append(notes, fmt.Sprintf("discount capped at %d", userCart.OfferPrice))
+	}
+	if userCart.LoyaltyPoints > 0 {
+		promotion, err := c.pointsPromotion(userId, userCart)
 		if err != nil {
-			return 0, errors.New("User Coupon Usage updation failed")
+			return dropped, err
 		}
-		var coupons = entity.Coupon{
-			UsedCount: coupon.UsedCount + 1,
+		if promotion.Amount > 0 {
+			promotion.Position = len(promotions) + 1
+			promotions = append(promotions, promotion)
+			userCart.Promotions = promotions
+			userCart.OfferPrice += promotion.Amount
+			notes = append(notes, fmt.Sprintf("%d loyalty points save %d", userCart.LoyaltyPoints, promotion.Amount))
+		}
+	}
+	userCart.OfferNote = strings.Join(notes, ", ")
+	return dropped, nil
+}
+
+// savePromotions stores the discount breakdown applyDiscount worked out.
+func (c *CartUsecase) savePromotions(userCart *entity.Cart) error {
+	err := c.cartRepo.ReplaceCartPromotions(int(userCart.ID), userCart.Promotions)
+	if err != nil {
+		return errors.New("Saving cart promotions failed")
+	}
+	return nil
+}
+
+// pointsPromotion turns the points the user chose to spend into a discount,
+// lowering the points when the balance or the redemption cap no longer allows
+// them.
+func (c *CartUsecase) pointsPromotion(userId int, userCart *entity.Cart) (entity.AppliedPromotion, error) {
+	promotion := entity.AppliedPromotion{Kind: "points", Name: "loyalty points"}
+	setting, err := c.loyaltyRepo.GetSetting()
+	if err != nil {
+		return promotion, errors.New("Fetching loyalty settings failed")
+	}
+	if !setting.Active {
+		userCart.LoyaltyPoints = 0
+		return promotion, nil
+	}
+	err = c.loyaltyRepo.Settle(userId, time.Now())
+	if err != nil {
+		return promotion, errors.New("Updating loyalty points failed")
+	}
+	balance, _, err := c.loyaltyRepo.Balance(userId)
+	if err != nil {
+		return promotion, errors.New("Fetching loyalty points failed")
+	}
+	total := int(userCart.TotalPrice)
+	userCart.LoyaltyPoints = utils.RedeemablePoints(setting, userCart.LoyaltyPoints, balance, total-userCart.OfferPrice, total)
+	promotion.Amount = userCart.LoyaltyPoints * setting.PointValue
+	return promotion, nil
+}
+
+// ExecuteApplyPoints sets how many loyalty points to spend on the cart and
+// returns the discount they give, which may be fewer points than asked for.
+func (c *CartUsecase) ExecuteApplyPoints(userId, points int) (int, int, error) {
+	if points < 1 {
+		return 0, 0, errors.New("Invalid points")
+	}
+	userCart, err := c.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return 0, 0, errors.New("Failed to find user cart")
+	}
+	userCart.LoyaltyPoints = points
+	err = c.refreshDiscount(userId, userCart)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = c.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return 0, 0, errors.New("User Cart updation failed")
+	}
+	if userCart.LoyaltyPoints == 0 {
+		return 0, 0, errors.New("No loyalty points can be used on this cart")
+	}
+	for _, promotion := range userCart.Promotions {
+		if promotion.Kind == "points" {
+			return userCart.LoyaltyPoints, promotion.Amount, nil
 		}
-		err = c.productRepo.UpdateCouponCount(&coupons)
+	}
+	return userCart.LoyaltyPoints, 0, nil
+}
+
+func (c *CartUsecase) ExecuteRemovePoints(userId int) error {
+	userCart, err := c.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return errors.New("Failed to find user cart")
+	}
+	if userCart.LoyaltyPoints == 0 {
+		return errors.New("No loyalty points applied to cart")
+	}
+	userCart.LoyaltyPoints = 0
+	err = c.refreshDiscount(userId, userCart)
+	if err != nil {
+		return err
+	}
+	err = c.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return errors.New("User Cart updation failed")
+	}
+	return nil
+}
+
+func (c *CartUsecase) ExecuteCouponCarts(code string) (int, error) {
+	count, err := c.cartRepo.CountCartsWithCoupon(code)
+	if err != nil {
+		return 0, errors.New("Counting carts failed")
+	}
+	return count, nil
+}
+
+// ExecuteDetachCoupon removes a coupon that can no longer be used from every
+// cart it was applied to.
+func (c *CartUsecase) ExecuteDetachCoupon(code string) (int, error) {
+	count, err := c.cartRepo.ClearCoupon(code)
+	if err != nil {
+		return 0, errors.New("Removing coupon from carts failed")
+	}
+	return count, nil
+}
+
+// couponDiscount checks the coupon's limits for userId and works out what it
+// takes off the given cart lines.
+func (c *CartUsecase) couponDiscount(userId int, coupon *entity.Coupon, cartItems []entity.CartItem) (int, error) {
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return 0, errors.New("Coupon usage limit reached")
+	}
+	if coupon.SegmentId != 0 {
+		segments, err := c.userSegments(userId)
 		if err != nil {
-			return 0, errors.New("User Coupon Usage updation failed")
+			return 0, errors.New("Checking coupon eligibility failed")
+		}
+		if !segments[coupon.SegmentId] {
+			return 0, errors.New("Coupon is not available for your account")
+		}
+	}
+	used, err := c.productRepo.CountCouponUsage(userId, coupon.Code)
+	if err != nil {
+		return 0, errors.New("Checking coupon usage failed")
+	}
+	perUserLimit := coupon.PerUserLimit
+	if perUserLimit == 0 {
+		perUserLimit = 1
+	}
+	if used >= perUserLimit {
+		return 0, errors.New("Coupon already used")
+	}
+	return utils.CouponDiscount(coupon, cartItems, time.Now())
+}
+
+// activeOffers returns the running offers the user is eligible for, leaving out
+// those limited to a segment the user is not in.
+func (c *CartUsecase) activeOffers(userId int) ([]entity.Offer, error) {
+	offers, err := c.productRepo.GetActiveOffers()
+	if err != nil {
+		return nil, errors.New("Fetching offers failed")
+	}
+	segments, err := c.userSegments(userId)
+	if err != nil {
+		return nil, errors.New("Checking offer eligibility failed")
+	}
+	eligible := offers[:0]
+	for _, offer := range offers {
+		if offer.SegmentId == 0 || segments[offer.SegmentId] {
+			eligible = append(eligible, offer)
 		}
 	}
+	return eligible, nil
+}
 
-	return totalOffer, nil
+// ExecuteReviewCart shows the cart as it would be after re-validation
+// without changing it, so viewing the cart has no side effects.
+func (cu *CartUsecase) ExecuteReviewCart(userId int) (*entity.CartReview, error) {
+	return cu.revalidateCart(userId, false)
+}
 
+// ExecuteCheckoutCart re-validates the cart before an order is placed and
+// saves the result. The review is returned with ErrCartChanged when anything
+// changed, so the user can look at the cart again before paying.
+func (cu *CartUsecase) ExecuteCheckoutCart(userId int) (*entity.CartReview, error) {
+	review, err := cu.revalidateCart(userId, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(review.Changes) > 0 {
+		return review, ErrCartChanged
+	}
+	if len(review.Items) == 0 {
+		return nil, errors.New("User cart is empty")
+	}
+	return review, nil
+}
+
+// revalidateCart re-prices every cart line against the current catalogue,
+// drops removed or sold-out products, trims quantities to the stock left and
+// recomputes the totals and coupon discount. The cart is only written when
+// save is set.
+func (cu *CartUsecase) revalidateCart(userId int, save bool) (*entity.CartReview, error) {
+	userCart, err := cu.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return nil, errors.New("Failed to find user cart")
+	}
+	cartItems, err := cu.cartRepo.GetAllCartItems(int(userCart.ID))
+	if err != nil {
+		return nil, errors.New("User Cart Items not found")
+	}
+	review := &entity.CartReview{
+		PreviousTotal: userCart.TotalPrice,
+		PreviousOffer: userCart.OfferPrice,
+	}
+	var totalPrice float64
+	var ticketQuantity, apparelQuantity int
+	for _, cartItem := range cartItems {
+		price, quantity, reason, err := cu.currentLine(userId, cartItem)
+		if err != nil {
+			return nil, err
+		}
+		change := entity.CartChange{
+			Category:    cartItem.Category,
+			ProductId:   cartItem.ProductId,
+			SeatId:      cartItem.SeatId,
+			ProductName: cartItem.ProductName,
+			Reason:      reason,
+			OldPrice:    cartItem.Price,
+			NewPrice:    price,
+			OldQuantity: cartItem.Quantity,
+			NewQuantity: quantity,
+		}
+		if quantity == 0 {
+			if save {
+				err = cu.cartRepo.RemoveCartItem(&cartItem)
+				if err != nil {
+					return nil, errors.New("Removing unavailable item failed")
+				}
+				if cartItem.SeatId != 0 {
+					cu.seatRepo.ReleaseSeat(cartItem.SeatId, userId)
+				}
+			}
+			review.Changes = append(review.Changes, change)
+			continue
+		}
+		if price != cartItem.Price || quantity != cartItem.Quantity {
+			if price != cartItem.Price {
+				change.Reason = "price changed"
+			}
+			cartItem.Price = price
+			cartItem.Quantity = quantity
+			if save {
+				err = cu.cartRepo.UpdateCartItem(&cartItem)
+				if err != nil {
+					return nil, errors.New("error updating existing cartitem")
+				}
+			}
+			review.Changes = append(review.Changes, change)
+		}
+		totalPrice += cartItem.Price * float64(cartItem.Quantity)
+		if cartItem.Category == "ticket" {
+			ticketQuantity += cartItem.Quantity
+		} else if cartItem.Category != "giftcard" {
+			apparelQuantity += cartItem.Quantity
+		}
+		review.Items = append(review.Items, cartItem)
+	}
+	userCart.TotalPrice = totalPrice
+	userCart.TicketQuantity = ticketQuantity
+	userCart.ApparelQuantity = apparelQuantity
+	change := entity.CartChange{
+		Category:    "discount",
+		ProductName: userCart.CouponCode,
+		OldPrice:    float64(userCart.OfferPrice),
+	}
+	dropped, err := cu.applyDiscount(userId, userCart, review.Items)
+	if err != nil {
+		return nil, err
+	}
+	change.NewPrice = float64(userCart.OfferPrice)
+	if dropped != "" {
+		change.Reason = dropped
+		review.Changes = append(review.Changes, change)
+	} else if change.NewPrice != change.OldPrice {
+		change.Reason = "discount changed"
+		review.Changes = append(review.Changes, change)
+	}
+	if save {
+		err = cu.savePromotions(userCart)
+		if err != nil {
+			return nil, err
+		}
+		err = cu.cartRepo.UpdateCart(userCart)
+		if err != nil {
+			return nil, errors.New("Cart price updation failed")
+		}
+	}
+	review.Cart = *userCart
+	return review, nil
+}
+
+// currentLine returns the price and quantity a cart line would have if it
+// were added now, with the reason when it differs.
+func (cu *CartUsecase) currentLine(userId int, cartItem entity.CartItem) (float64, int, string, error) {
+	var price int
+	if cartItem.Category == "giftcard" {
+		return cartItem.Price, cartItem.Quantity, "", nil
+	}
+	if cartItem.Category == "ticket" {
+		ticket, err := cu.productRepo.GetTicketByID(cartItem.ProductId)
+		if err != nil || ticket.Removed {
+			return 0, 0, "product removed", nil
+		}
+		if cartItem.SeatId != 0 {
+			seat, err := cu.seatRepo.GetSeatByID(cartItem.SeatId)
+			if err != nil {
+				return 0, 0, "product removed", nil
+			}
+			section, err := cu.seatRepo.GetSectionByID(seat.SectionId)
+			if err != nil {
+				return 0, 0, "product removed", nil
+			}
+			if seat.Status == "sold" || (seat.HeldBy != userId && seat.HeldBy != 0 && seat.HeldUntil.After(time.Now())) {
+				return float64(section.Price), 0, "seat taken", nil
+			}
+			return float64(section.Price), 1, "", nil
+		}
+		price, err = cu.ticketPrice(ticket)
+		if err != nil {
+			return 0, 0, "", errors.New("Fetching ticket price failed")
+		}
+	} else {
+		apparel, err := cu.productRepo.GetApparelByID(cartItem.ProductId)
+		if err != nil || apparel.Removed {
+			return 0, 0, "product removed", nil
+		}
+		price = apparel.Price
+	}
+	inventory, err := cu.productRepo.GetByProductId(cartItem.ProductId, cartItem.Category)
+	if err != nil {
+		return float64(price), 0, "out of stock", nil
+	}
+	reserved, err := cu.waitlistRepo.ReservedQuantity(cartItem.Category, cartItem.ProductId, userId)
+	if err != nil {
+		return 0, 0, "", errors.New("Checking stock failed")
+	}
+	available := inventory.Quantity - reserved
+	if available <= 0 {
+		return float64(price), 0, "out of stock", nil
+	}
+	if available < cartItem.Quantity {
+		return float64(price), available, "stock reduced", nil
+	}
+	return float64(price), cartItem.Quantity, "", nil
 }
 
 func (u *CartUsecase) ExecuteOfferCheck(userId int) (*[]entity.Offer, error) {
@@ -344,9 +899,60 @@ func (u *CartUsecase) ExecuteOfferCheck(userId int) (*[]entity.Offer, error) {
 	if err != nil {
 		return nil, errors.New("Failed to find user cart")
 	}
-	offer, err := u.productRepo.GetOfferByPrice(int(userCart.TotalPrice))
+	cartItems, err := u.cartRepo.GetAllCartItems(int(userCart.ID))
+	if err != nil {
+		return nil, errors.New("User Cart Items not found")
+	}
+	offers, err := u.activeOffers(userId)
+	if err != nil {
+		return nil, err
+	}
+	eligible := []entity.Offer{}
+	now := time.Now()
+	for _, offer := range offers {
+		if _, err := utils.OfferDiscount(&offer, cartItems, now); err == nil {
+			eligible = append(eligible, offer)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, nil
+	}
+	return &eligible, nil
+}
+
+// ticketPrice resolves the current price of a ticket from its schedules,
+// demand rules and remaining stock.
+func (cu *CartUsecase) ticketPrice(ticket *entity.Ticket) (int, error) {
+	schedules, err := cu.productRepo.GetPriceSchedules(ticket.ID)
 	if err != nil {
-		return nil, errors.New("No valid offers, Add few more products worth of 500")
+		return 0, err
+	}
+	rules, err := cu.productRepo.GetDemandRules(ticket.ID)
+	if err != nil {
+		return 0, err
+	}
+	remaining := 0
+	inventory, err := cu.productRepo.GetByProductId(ticket.ID, "ticket")
+	if err != nil {
+		rules = nil
+	} else {
+		remaining = inventory.Quantity
+	}
+	return utils.EffectiveTicketPrice(ticket.Price, schedules, rules, remaining, time.Now()), nil
+}
+
+// userSegments returns the IDs of the segments the user currently belongs to.
+func (c *CartUsecase) userSegments(userId int) (map[int]bool, error) {
+	segments, err := c.segmentRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return map[int]bool{}, nil
+	}
+	profile, err := c.segmentRepo.GetCustomerProfile(userId)
+	if err != nil {
+		return nil, err
 	}
-	return offer, nil
+	return utils.MatchSegments(segments, profile, time.Now()), nil
 }
@@ -0,0 +1,174 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"zog/config"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	repository "zog/repository/email"
+	"zog/repository/mailer"
+	userrepository "zog/repository/user"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	emailTokenSize    = 32
+	minPasswordLength = 8
+)
+
+type EmailUsecase struct {
+	emailRepo *repository.EmailRepository
+	userRepo  *userrepository.UserRepository
+	mailer    mailer.Mailer
+	cfg       config.Email
+}
+
+func NewEmail(emailRepo *repository.EmailRepository, userRepo *userrepository.UserRepository, mailer mailer.Mailer, cfg config.Email) *EmailUsecase {
+	return &EmailUsecase{emailRepo: emailRepo, userRepo: userRepo, mailer: mailer, cfg: cfg}
+}
+
+// ExecuteSendVerification mails the user a link that verifies their address.
+func (eu *EmailUsecase) ExecuteSendVerification(userId int) error {
+	user, err := eu.userRepo.GetByID(userId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user with this id not found")
+	}
+	if user.EmailVerified {
+		return errors.New("Email is already verified")
+	}
+	if strings.TrimSpace(user.Email) == "" {
+		return errors.New("No email address to verify")
+	}
+	token, err := eu.issue(user, entity.EmailTokenVerify, eu.cfg.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	return eu.mailer.Send(&entity.Mail{
+		To:      user.Email,
+		Subject: "Verify your zog email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm this is your email address by opening the link below:\n\n%s\n\nThe link works once and expires in %s. If you did not sign up for zog, you can ignore this email.\n",
+			user.FirstName, eu.link("/verifyemail", token), lifetime(eu.cfg.VerifyTTL)),
+	})
+}
+
+// ExecuteVerify verifies the address a verification link was sent to.
+func (eu *EmailUsecase) ExecuteVerify(token string) error {
+	emailToken, err := eu.emailRepo.UseToken(utils.HashToken(token), entity.EmailTokenVerify, time.Now())
+	if err != nil {
+		return err
+	}
+	return eu.emailRepo.MarkVerified(emailToken.UserId, emailToken.Email)
+}
+
+// ExecuteRequestReset mails a password reset link to the user who verified
+// email. The lookup and the mail happen in the background, so neither the
+// answer nor how long it takes tells which addresses have accounts.
+func (eu *EmailUsecase) ExecuteRequestReset(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errors.New("Email is required")
+	}
+	go func() {
+		if err := eu.sendReset(email); err != nil {
+			log.Println("password reset mail:", err)
+		}
+	}()
+	return nil
+}
+
+func (eu *EmailUsecase) sendReset(email string) error {
+	user, err := eu.userRepo.GetByVerifiedEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil || !user.Permission {
+		return nil
+	}
+	token, err := eu.issue(user, entity.EmailTokenReset, eu.cfg.ResetTTL)
+	if err != nil {
+		return err
+	}
+	err = eu.mailer.Send(&entity.Mail{
+		To:      user.Email,
+		Subject: "Reset your zog password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your zog account. To choose a new password, open the link below:\n\n%s\n\nThe link works once and expires in %s. If it was not you, ignore this email; your password has not changed.\n",
+			user.FirstName, eu.link("/resetpassword", token), lifetime(eu.cfg.ResetTTL)),
+	})
+	if err != nil {
+		return fmt.Errorf("user %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+// ExecuteResetPassword sets a new password with a reset link's token and
+// returns whose it was, so their sessions can be ended.
+func (eu *EmailUsecase) ExecuteResetPassword(token, password string) (int, error) {
+	if len(password) < minPasswordLength {
+		return 0, fmt.Errorf("Password must be at least %d characters", minPasswordLength)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+	emailToken, err := eu.emailRepo.UseToken(utils.HashToken(token), entity.EmailTokenReset, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	err = eu.emailRepo.SetPassword(emailToken.UserId, string(hashedPassword))
+	if err != nil {
+		return 0, errors.New("Password changing failed")
+	}
+	return emailToken.UserId, nil
+}
+
+// StartSweeper periodically drops expired tokens.
+func (eu *EmailUsecase) StartSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if err := eu.emailRepo.DeleteExpired(time.Now()); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (eu *EmailUsecase) issue(user *entity.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.RandomToken(emailTokenSize)
+	if err != nil {
+		return "", err
+	}
+	err = eu.emailRepo.CreateToken(&entity.EmailToken{
+		TokenHash: utils.HashToken(token),
+		UserId:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", errors.New("Saving email token failed")
+	}
+	return token, nil
+}
+
+func (eu *EmailUsecase) link(path, token string) string {
+	return eu.cfg.BaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// lifetime words a link's lifetime for the email, such as "48 hours".
+func lifetime(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		if hours := int(ttl / time.Hour); hours != 1 {
+			return fmt.Sprintf("%d hours", hours)
+		}
+		return "1 hour"
+	}
+	return fmt.Sprintf("%d minutes", int(ttl.Round(time.Minute)/time.Minute))
+}
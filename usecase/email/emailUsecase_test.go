@@ -0,0 +1,85 @@
+package email
+
+import (
+	"testing"
+	"time"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	repository "zog/repository/email"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-playground/assert/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var tokenColumns = []string{"id", "token_hash", "user_id", "purpose", "email", "used", "expires_at"}
+
+func newEmailUsecase(t *testing.T) (*EmailUsecase, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &EmailUsecase{emailRepo: repository.NewEmailRepository(db)}, mock
+}
+
+func expectToken(mock sqlmock.Sqlmock, token, purpose string, used bool) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "email_tokens" WHERE token_hash = \$1 AND purpose = \$2 .* FOR UPDATE`).
+		WithArgs(utils.HashToken(token), purpose).
+		WillReturnRows(sqlmock.NewRows(tokenColumns).
+			AddRow(4, utils.HashToken(token), 3, purpose, "user@example.com", used, time.Now().Add(time.Hour)))
+}
+
+func TestVerifyTokenWorksOnce(t *testing.T) {
+	eu, mock := newEmailUsecase(t)
+	expectToken(mock, "link-token", entity.EmailTokenVerify, false)
+	mock.ExpectExec(`UPDATE "email_tokens" SET "used"=\$1 WHERE "id" = \$2`).
+		WithArgs(true, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`UPDATE "users" SET "email_verified"=\$1,"updated_at"=\$2 WHERE \(id = \$3 AND email = \$4\)`).
+		WithArgs(true, sqlmock.AnyArg(), 3, "user@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.Equal(t, nil, eu.ExecuteVerify("link-token"))
+
+	expectToken(mock, "link-token", entity.EmailTokenVerify, true)
+	mock.ExpectRollback()
+
+	err := eu.ExecuteVerify("link-token")
+	assert.Equal(t, "Invalid or expired link", err.Error())
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
+
+func TestResetTokenWorksOnce(t *testing.T) {
+	eu, mock := newEmailUsecase(t)
+	expectToken(mock, "reset-token", entity.EmailTokenReset, true)
+	mock.ExpectRollback()
+
+	_, err := eu.ExecuteResetPassword("reset-token", "a-new-password")
+	assert.Equal(t, "Invalid or expired link", err.Error())
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
+
+func TestTokenForAnotherPurposeRefused(t *testing.T) {
+	eu, mock := newEmailUsecase(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "email_tokens"`).
+		WithArgs(utils.HashToken("link-token"), entity.EmailTokenReset).
+		WillReturnRows(sqlmock.NewRows(tokenColumns))
+	mock.ExpectRollback()
+
+	_, err := eu.ExecuteResetPassword("link-token", "a-new-password")
+	assert.Equal(t, "Invalid or expired link", err.Error())
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
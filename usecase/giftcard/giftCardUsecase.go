@@ -0,0 +1,285 @@
+package giftcard
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	cartrepository "zog/repository/cart"
+	repository "zog/repository/giftcard"
+	"zog/repository/mailer"
+	userrepository "zog/repository/user"
+)
+
+// ErrMailFailed is returned with an issued card whose code could not be
+// emailed to the recipient.
+var ErrMailFailed = errors.New("Emailing gift card failed")
+
+type GiftCardUsecase struct {
+	giftCardRepo *repository.GiftCardRepository
+	cartRepo     *cartrepository.CartRepository
+	userRepo     *userrepository.UserRepository
+	mailer       mailer.Mailer
+}
+
+func NewGiftCard(giftCardRepo *repository.GiftCardRepository, cartRepo *cartrepository.CartRepository, userRepo *userrepository.UserRepository, mailer mailer.Mailer) *GiftCardUsecase {
+	return &GiftCardUsecase{giftCardRepo: giftCardRepo, cartRepo: cartRepo, userRepo: userRepo, mailer: mailer}
+}
+
+func (gu *GiftCardUsecase) ExecuteOptions() entity.GiftCardOptions {
+	return entity.GiftCardOptions{
+		Denominations: utils.GiftCardDenominations,
+		MinAmount:     utils.GiftCardMin,
+		MaxAmount:     utils.GiftCardMax,
+		ValidityDays:  utils.GiftCardValidityDays,
+	}
+}
+
+// ExecuteAddToCart puts a new gift card in the user's cart. The card stays
+// pending, without a code, until the order is paid.
+func (gu *GiftCardUsecase) ExecuteAddToCart(userId int, input entity.GiftCardInput) (*entity.GiftCard, error) {
+	err := utils.GiftCardAmountValid(input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	userCart, err := gu.cartRepo.GetByUserID(userId)
+	if err != nil {
+		userCart, err = gu.cartRepo.Create(userId)
+		if err != nil {
+			return nil, errors.New("Failed to create user cart")
+		}
+	}
+	card := &entity.GiftCard{
+		Amount:         input.Amount,
+		Status:         "pending",
+		PurchaserId:    userId,
+		RecipientEmail: strings.TrimSpace(input.RecipientEmail),
+		RecipientPhone: strings.TrimSpace(input.RecipientPhone),
+		Message:        input.Message,
+	}
+	err = gu.giftCardRepo.Create(card)
+	if err != nil {
+		return nil, errors.New("Creating gift card failed")
+	}
+	cartItem := &entity.CartItem{
+		CartId:      int(userCart.ID),
+		ProductId:   card.ID,
+		Category:    "giftcard",
+		Quantity:    1,
+		ProductName: fmt.Sprintf("Gift card #%d", card.ID),
+		Price:       float64(card.Amount),
+	}
+	err = gu.cartRepo.CreateCartItem(cartItem)
+	if err != nil {
+		gu.giftCardRepo.Delete(card)
+		return nil, errors.New("Adding gift card to cart failed")
+	}
+	userCart.TotalPrice += cartItem.Price
+	err = gu.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return nil, errors.New("Cart price updation failed")
+	}
+	return card, nil
+}
+
+func (gu *GiftCardUsecase) ExecuteRemoveFromCart(userId, cardId int) error {
+	card, err := gu.giftCardRepo.GetByID(cardId)
+	if err != nil {
+		return err
+	}
+	if card.PurchaserId != userId || card.Status != "pending" {
+		return errors.New("Gift card is not in your cart")
+	}
+	userCart, err := gu.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return errors.New("Failed to find user cart")
+	}
+	cartItem, err := gu.cartRepo.GetByName(fmt.Sprintf("Gift card #%d", card.ID), int(userCart.ID))
+	if err != nil || cartItem == nil {
+		return errors.New("Gift card is not in your cart")
+	}
+	err = gu.cartRepo.RemoveCartItem(cartItem)
+	if err != nil {
+		return errors.New("Removing gift card from cart failed")
+	}
+	userCart.TotalPrice -= cartItem.Price
+	err = gu.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return errors.New("Cart price updation failed")
+	}
+	gu.giftCardRepo.Delete(card)
+	return nil
+}
+
+func (gu *GiftCardUsecase) ExecuteMyGiftCards(userId int) ([]entity.GiftCard, error) {
+	user, err := gu.userRepo.GetByID(userId)
+	if err != nil || user == nil {
+		return nil, errors.New("User not found")
+	}
+	cards, err := gu.giftCardRepo.GetForUser(userId, user.Email, user.Phone)
+	if err != nil {
+		return nil, errors.New("Fetching gift cards failed")
+	}
+	return cards, nil
+}
+
+func (gu *GiftCardUsecase) ExecuteBalance(code string) (*entity.GiftCardBalance, error) {
+	card, err := gu.giftCardRepo.GetByCodeHash(utils.GiftCardCodeHash(code))
+	if err != nil {
+		return nil, errors.New("Fetching gift card failed")
+	}
+	if card == nil {
+		return nil, errors.New("Gift card not found")
+	}
+	return &entity.GiftCardBalance{CodeHint: card.CodeHint, Balance: card.Balance, Status: card.Status, ExpiresAt: card.ExpiresAt}, nil
+}
+
+// ExecuteRedeem moves what is left on a card into the user's wallet.
+func (gu *GiftCardUsecase) ExecuteRedeem(userId int, code string) (int, error) {
+	return gu.giftCardRepo.RedeemToWallet(utils.GiftCardCodeHash(code), userId, time.Now())
+}
+
+// ExecuteApplyGiftCard sets a card to pay for the cart at checkout and returns
+// how much of the cart it covers now. Anything it does not cover is paid with
+// the checkout method.
+func (gu *GiftCardUsecase) ExecuteApplyGiftCard(userId int, code string) (int, error) {
+	card, err := gu.giftCardRepo.GetByCodeHash(utils.GiftCardCodeHash(code))
+	if err != nil {
+		return 0, errors.New("Fetching gift card failed")
+	}
+	if card == nil {
+		return 0, errors.New("Gift card not found")
+	}
+	err = utils.GiftCardUsable(card, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	userCart, err := gu.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return 0, errors.New("Failed to find user cart")
+	}
+	due := int(userCart.TotalPrice) - userCart.OfferPrice
+	if due <= 0 {
+		return 0, errors.New("Nothing to pay in your cart")
+	}
+	userCart.GiftCardId = card.ID
+	err = gu.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return 0, errors.New("User Cart updation failed")
+	}
+	if card.Balance < due {
+		return card.Balance, nil
+	}
+	return due, nil
+}
+
+func (gu *GiftCardUsecase) ExecuteRemoveGiftCardPayment(userId int) error {
+	userCart, err := gu.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return errors.New("Failed to find user cart")
+	}
+	if userCart.GiftCardId == 0 {
+		return errors.New("No gift card applied to cart")
+	}
+	userCart.GiftCardId = 0
+	err = gu.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return errors.New("User Cart updation failed")
+	}
+	return nil
+}
+
+// ExecuteIssue creates an active card on behalf of the festival, for
+// goodwill credits or prizes.
+func (gu *GiftCardUsecase) ExecuteIssue(adminId int, input entity.GiftCardInput) (*entity.GiftCard, error) {
+	if input.Amount < 1 {
+		return nil, errors.New("Gift card amount must be positive")
+	}
+	if input.ValidityDays == 0 {
+		input.ValidityDays = utils.GiftCardValidityDays
+	}
+	if input.ValidityDays < 1 {
+		return nil, errors.New("Gift card must be valid for at least a day")
+	}
+	code, err := gu.giftCardRepo.NewCode()
+	if err != nil {
+		return nil, err
+	}
+	card := &entity.GiftCard{
+		CodeHash:       utils.GiftCardCodeHash(code),
+		CodeHint:       utils.GiftCardCodeHint(code),
+		Amount:         input.Amount,
+		Balance:        input.Amount,
+		Status:         "active",
+		IssuedBy:       adminId,
+		RecipientEmail: strings.TrimSpace(input.RecipientEmail),
+		RecipientPhone: strings.TrimSpace(input.RecipientPhone),
+		Message:        input.Message,
+		ExpiresAt:      time.Now().AddDate(0, 0, input.ValidityDays),
+	}
+	err = gu.giftCardRepo.Issue(card, time.Now())
+	if err != nil {
+		return nil, errors.New("Issuing gift card failed")
+	}
+	card.Code = code
+	err = gu.notifyRecipient(card)
+	if err != nil {
+		return card, err
+	}
+	return card, nil
+}
+
+func (gu *GiftCardUsecase) ExecuteVoid(adminId, cardId int) error {
+	return gu.giftCardRepo.Void(cardId, adminId, time.Now())
+}
+
+func (gu *GiftCardUsecase) ExecuteLookup(code string) (*entity.GiftCardDetail, error) {
+	card, err := gu.giftCardRepo.GetByCodeHash(utils.GiftCardCodeHash(code))
+	if err != nil {
+		return nil, errors.New("Fetching gift card failed")
+	}
+	if card == nil {
+		return nil, errors.New("Gift card not found")
+	}
+	txns, err := gu.giftCardRepo.GetTxns(card.ID)
+	if err != nil {
+		return nil, errors.New("Fetching gift card history failed")
+	}
+	return &entity.GiftCardDetail{Card: *card, Transactions: txns}, nil
+}
+
+// notifyRecipient emails the code to the recipient and tells them about the
+// card in the app when they have an account with the email or phone it was
+// addressed to.
+func (gu *GiftCardUsecase) notifyRecipient(card *entity.GiftCard) error {
+	if card.RecipientEmail != "" {
+		err := gu.mailer.Send(utils.GiftCardMail(card))
+		if err != nil {
+			return ErrMailFailed
+		}
+	}
+	var recipient *entity.User
+	if card.RecipientEmail != "" {
+		recipient, _ = gu.userRepo.GetByEmail(card.RecipientEmail)
+	}
+	if recipient == nil && card.RecipientPhone != "" {
+		recipient, _ = gu.userRepo.GetByPhone(card.RecipientPhone)
+	}
+	if recipient == nil {
+		return nil
+	}
+	notification := &entity.Notification{UserId: recipient.ID, Title: "Gift card received", Message: utils.GiftCardNotice(card)}
+	if err := gu.userRepo.CreateNotification(notification); err != nil {
+		log.Println("notification failed:", err)
+	}
+	return nil
+}
+
+// ExecuteMigrateCodes runs at startup and hashes the codes of cards issued
+// before codes stopped being stored.
+func (gu *GiftCardUsecase) ExecuteMigrateCodes() (int, error) {
+	return gu.giftCardRepo.MigrateCodes()
+}
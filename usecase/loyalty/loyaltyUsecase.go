@@ -0,0 +1,64 @@
+package loyalty
+
+import (
+	"errors"
+	"time"
+	"zog/domain/entity"
+	repository "zog/repository/loyalty"
+)
+
+type LoyaltyUsecase struct {
+	loyaltyRepo *repository.LoyaltyRepository
+}
+
+func NewLoyalty(loyaltyRepo *repository.LoyaltyRepository) *LoyaltyUsecase {
+	return &LoyaltyUsecase{loyaltyRepo: loyaltyRepo}
+}
+
+func (lu *LoyaltyUsecase) ExecuteBalance(userId int) (*entity.LoyaltyBalance, error) {
+	setting, err := lu.loyaltyRepo.GetSetting()
+	if err != nil {
+		return nil, errors.New("Fetching loyalty settings failed")
+	}
+	err = lu.loyaltyRepo.Settle(userId, time.Now())
+	if err != nil {
+		return nil, errors.New("Updating loyalty points failed")
+	}
+	available, pending, err := lu.loyaltyRepo.Balance(userId)
+	if err != nil {
+		return nil, errors.New("Fetching loyalty points failed")
+	}
+	history, err := lu.loyaltyRepo.GetHistory(userId)
+	if err != nil {
+		return nil, errors.New("Fetching loyalty history failed")
+	}
+	return &entity.LoyaltyBalance{Available: available, Pending: pending, Value: available * setting.PointValue, History: history}, nil
+}
+
+func (lu *LoyaltyUsecase) ExecuteSetting() (*entity.LoyaltySetting, error) {
+	setting, err := lu.loyaltyRepo.GetSetting()
+	if err != nil {
+		return nil, errors.New("Fetching loyalty settings failed")
+	}
+	return setting, nil
+}
+
+func (lu *LoyaltyUsecase) ExecuteUpdateSetting(setting entity.LoyaltySetting) (*entity.LoyaltySetting, error) {
+	if setting.TicketRate < 0 || setting.ApparelRate < 0 || setting.ReturnWindowDays < 0 {
+		return nil, errors.New("Loyalty rates and return window cannot be negative")
+	}
+	if setting.PointValue < 1 {
+		return nil, errors.New("A point must be worth at least 1")
+	}
+	if setting.ExpiryDays < 1 {
+		return nil, errors.New("Points must be valid for at least a day")
+	}
+	if setting.MaxRedeemPercent < 0 || setting.MaxRedeemPercent > 100 {
+		return nil, errors.New("Maximum redeem percent must be between 0 and 100")
+	}
+	err := lu.loyaltyRepo.SaveSetting(&setting)
+	if err != nil {
+		return nil, errors.New("Saving loyalty settings failed")
+	}
+	return &setting, nil
+}
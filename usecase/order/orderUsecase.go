@@ -2,31 +2,56 @@ package order
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
+	"zog/config"
 	"zog/domain/entity"
 	"zog/domain/utils"
 	cartrepository "zog/repository/cart"
+	giftcardrepository "zog/repository/giftcard"
+	loyaltyrepository "zog/repository/loyalty"
+	"zog/repository/mailer"
 	repository "zog/repository/order"
 	productrepository "zog/repository/product"
+	referralrepository "zog/repository/referral"
+	seatrepository "zog/repository/seat"
 	userrepository "zog/repository/user"
+	waitlistrepository "zog/repository/waitlist"
 
 	"github.com/gin-gonic/gin"
 	"github.com/razorpay/razorpay-go"
 )
 
+// ErrGiftCardsFailed is returned with the invoice when an order was paid but
+// issuing its gift cards or emailing their codes failed.
+var ErrGiftCardsFailed = errors.New("Order placed but issuing gift cards failed")
+
+// ErrRewardsFailed is returned when an order was updated but crediting its
+// loyalty points or referral reward failed.
+var ErrRewardsFailed = errors.New("Order updated but rewards failed")
+
 type OrderUsecase struct {
-	orderRepo   *repository.OrderRepository
-	cartRepo    *cartrepository.CartRepository
-	userRepo    *userrepository.UserRepository
-	productRepo *productrepository.ProductRepository
+	orderRepo     *repository.OrderRepository
+	cartRepo      *cartrepository.CartRepository
+	userRepo      *userrepository.UserRepository
+	productRepo   *productrepository.ProductRepository
+	waitlistRepo  *waitlistrepository.WaitlistRepository
+	seatRepo      *seatrepository.SeatRepository
+	loyaltyRepo   *loyaltyrepository.LoyaltyRepository
+	giftCardRepo  *giftcardrepository.GiftCardRepository
+	referralRepo  *referralrepository.ReferralRepository
+	mailer        mailer.Mailer
+	razorpay      config.Razorpay
+	paypal        config.PayPal
+	paymentWindow time.Duration
 }
 
-func NewOrder(orderRepo *repository.OrderRepository, cartRepo *cartrepository.CartRepository, userRepo *userrepository.UserRepository, productRepo *productrepository.ProductRepository) *OrderUsecase {
-	return &OrderUsecase{orderRepo: orderRepo, cartRepo: cartRepo, userRepo: userRepo, productRepo: productRepo}
+func NewOrder(orderRepo *repository.OrderRepository, cartRepo *cartrepository.CartRepository, userRepo *userrepository.UserRepository, productRepo *productrepository.ProductRepository, waitlistRepo *waitlistrepository.WaitlistRepository, seatRepo *seatrepository.SeatRepository, loyaltyRepo *loyaltyrepository.LoyaltyRepository, giftCardRepo *giftcardrepository.GiftCardRepository, referralRepo *referralrepository.ReferralRepository, mailer mailer.Mailer, razorpayConfig config.Razorpay, paypalConfig config.PayPal, paymentWindow time.Duration) *OrderUsecase {
+	return &OrderUsecase{orderRepo: orderRepo, cartRepo: cartRepo, userRepo: userRepo, productRepo: productRepo, waitlistRepo: waitlistRepo, seatRepo: seatRepo, loyaltyRepo: loyaltyRepo, giftCardRepo: giftCardRepo, referralRepo: referralRepo, mailer: mailer, razorpay: razorpayConfig, paypal: paypalConfig, paymentWindow: paymentWindow}
 }
 
 func (ou *OrderUsecase) ExecutePurchaseCod(userId int, address int) (*entity.Invoice, error) {
-	var orderItems []entity.OrderItem
 	cart, err := ou.cartRepo.GetCartById(userId)
 	if err != nil {
 		return nil, errors.New("Cart  not found")
@@ -39,6 +64,24 @@ func (ou *OrderUsecase) ExecutePurchaseCod(userId int, address int) (*entity.Inv
 	if err != nil {
 		return nil, errors.New("User address  not found")
 	}
+	for _, cartItem := range cartItems {
+		if cartItem.Category == "giftcard" {
+			return nil, errors.New("Gift cards cannot be paid cash on delivery")
+		}
+	}
+	claim, err := ou.redeemDiscount(userId, cart)
+	if err != nil {
+		return nil, err
+	}
+	err = ou.claimSeats(userId, cartItems)
+	if err != nil {
+		return nil, errors.Join(err, ou.releaseDiscount(claim))
+	}
+	orderItems := newOrderItems(cartItems)
+	err = ou.reserveStock(orderItems)
+	if err != nil {
+		return nil, errors.Join(err, ou.releaseSeats(orderItems), ou.releaseDiscount(claim))
+	}
 	Total := cart.TotalPrice - float64(cart.OfferPrice)
 	order := &entity.Order{
 		UserID:        cart.UserId,
@@ -46,12 +89,19 @@ func (ou *OrderUsecase) ExecutePurchaseCod(userId int, address int) (*entity.Inv
 		Total:         Total,
 		Status:        "pending",
 		PaymentMethod: "Cod",
+		CouponCode:    cart.CouponCode,
+		Discount:      cart.OfferPrice,
+		GiftCardPaid:  claim.giftCardPaid(),
 		PaymentStatus: "pending",
 	}
 
 	OrderID, err2 := ou.orderRepo.Create(order)
 	if err2 != nil {
-		return nil, errors.New("Order placing failed")
+		return nil, errors.Join(errors.New("Order placing failed"), ou.releaseCheckout(claim, orderItems))
+	}
+	err = ou.attachDiscount(claim, OrderID)
+	if err != nil {
+		return nil, err
 	}
 	invoiceData := &entity.Invoice{
 		OrderId:     OrderID,
@@ -62,33 +112,27 @@ func (ou *OrderUsecase) ExecutePurchaseCod(userId int, address int) (*entity.Inv
 		Payment:     order.PaymentMethod,
 		Status:      order.PaymentStatus,
 		PaymentId:   "nil",
+		Discount:    order.Discount,
+		GiftCard:    order.GiftCardPaid,
 		Remark:      "Zog_Festiv",
 	}
 	invoice, err := ou.orderRepo.CreateInvoice(invoiceData)
 	if err != nil {
 		return nil, errors.New("Invoice Creating failed")
 	}
-	for _, cartItem := range cartItems {
-		orderItem := entity.OrderItem{
-			OrderID:   OrderID,
-			ProductID: cartItem.ProductId,
-			Category:  cartItem.Category,
-			Quantity:  cartItem.Quantity,
-			Price:     cartItem.Price,
-		}
-		orderItems = append(orderItems, orderItem)
-		inventory := entity.Inventory{
-			ProductId:       cartItem.ProductId,
-			ProductCategory: cartItem.Category,
-			Quantity:        cartItem.Quantity,
-		}
-		err = ou.productRepo.DecreaseProductQuantity(&inventory)
+	invoice.Promotions = claim.promotions
+	for i := range orderItems {
+		orderItems[i].OrderID = OrderID
 	}
 
 	err = ou.orderRepo.CreateOrderItems(orderItems)
 	if err != nil {
 		return nil, errors.New("User cart is empty")
 	}
+	err = ou.completePurchase(userId, orderItems)
+	if err != nil {
+		return nil, err
+	}
 
 	err = ou.cartRepo.RemoveCartItems(int(cart.ID))
 	if err != nil {
@@ -98,16 +142,21 @@ func (ou *OrderUsecase) ExecutePurchaseCod(userId int, address int) (*entity.Inv
 	cart.TicketQuantity = 0
 	cart.TotalPrice = 0
 	cart.OfferPrice = 0
+	cart.CouponCode = ""
+	cart.LoyaltyPoints = 0
+	cart.GiftCardId = 0
+	cart.OfferNote = ""
 	err = ou.cartRepo.UpdateCart(cart)
 	if err != nil {
 		return nil, errors.New("Updating cart failed")
 	}
+	ou.cartRepo.ReplaceCartPromotions(int(cart.ID), nil)
 	return invoice, nil
 }
 
 func (ou *OrderUsecase) ExecutePurchasePaypal(userId int, address int) (string, error) {
 
-	token, err := utils.GeneratePayPalAccessToken()
+	token, err := utils.GeneratePayPalAccessToken(ou.paypal)
 	if err != nil {
 		return "", errors.New("Access token not created")
 	}
@@ -116,7 +165,17 @@ func (ou *OrderUsecase) ExecutePurchasePaypal(userId int, address int) (string,
 }
 
 func (ou *OrderUsecase) ExecutePurchaseRazorPay(userId int, address int, c *gin.Context) (string, int, error) {
-	var orderItems []entity.OrderItem
+	return ou.purchaseOnline(userId, address, false)
+}
+
+// ExecutePurchaseSplit pays for the order with the whole wallet balance and
+// the rest through razorpay. The wallet share is held until the gateway
+// payment is verified.
+func (ou *OrderUsecase) ExecutePurchaseSplit(userId int, address int) (string, int, error) {
+	return ou.purchaseOnline(userId, address, true)
+}
+
+func (ou *OrderUsecase) purchaseOnline(userId int, address int, useWallet bool) (string, int, error) {
 	cart, err := ou.cartRepo.GetCartById(userId)
 	if err != nil {
 		return "", 0, errors.New("Cart  not found")
@@ -129,70 +188,141 @@ func (ou *OrderUsecase) ExecutePurchaseRazorPay(userId int, address int, c *gin.
 	if err != nil {
 		return "", 0, errors.New("User address  not found")
 	}
-	client := razorpay.NewClient("rzp_test_O6q2DXJHecJBHI", "MU9PWzkhTBSCkPnxEUOAZdYW")
+	claim, err := ou.redeemDiscount(userId, cart)
+	if err != nil {
+		return "", 0, err
+	}
+	due := int(cart.TotalPrice-float64(cart.OfferPrice)) - claim.giftCardPaid()
+	if due <= 0 {
+		return "", 0, errors.Join(errors.New("Gift card covers the whole order - pay with wallet instead"), ou.releaseDiscount(claim))
+	}
+	paymentMethod := "razorpay"
+	var hold *entity.WalletHold
+	if useWallet {
+		user, err := ou.userRepo.GetByID(userId)
+		if err != nil || user == nil {
+			return "", 0, errors.Join(errors.New("User not found"), ou.releaseDiscount(claim))
+		}
+		if user.Wallet <= 0 {
+			return "", 0, errors.Join(errors.New("Wallet is empty - pay with razorpay instead"), ou.releaseDiscount(claim))
+		}
+		if user.Wallet >= due {
+			return "", 0, errors.Join(errors.New("Wallet covers the whole order - pay with wallet instead"), ou.releaseDiscount(claim))
+		}
+		hold, err = ou.orderRepo.HoldWallet(userId, user.Wallet, time.Now().Add(ou.paymentWindow))
+		if err != nil {
+			return "", 0, errors.Join(err, ou.releaseDiscount(claim))
+		}
+		due -= hold.Amount
+		paymentMethod = "split"
+	}
+	client := razorpay.NewClient(ou.razorpay.KeyId, ou.razorpay.KeySecret)
 
 	data := map[string]interface{}{
-		"amount":   int(cart.TotalPrice) * 100,
+		"amount":   due * 100,
 		"currency": "INR",
 		"receipt":  "101",
 	}
 	body, err := client.Order.Create(data, nil)
 	if err != nil {
-		return "", 0, errors.New("Payment not initiated")
+		return "", 0, errors.Join(errors.New("Payment not initiated"), ou.releasePayment(claim, hold))
 	}
 	razorId, _ := body["id"].(string)
+	err = ou.claimSeats(userId, cartItems)
+	if err != nil {
+		return "", 0, errors.Join(err, ou.releasePayment(claim, hold))
+	}
+	orderItems := newOrderItems(cartItems)
+	err = ou.reserveStock(orderItems)
+	if err != nil {
+		return "", 0, errors.Join(err, ou.releaseSeats(orderItems), ou.releasePayment(claim, hold))
+	}
 	Total := cart.TotalPrice - float64(cart.OfferPrice)
 	order := &entity.Order{
 		UserID:        cart.UserId,
 		AddressId:     userAddress.ID,
 		Total:         Total,
 		Status:        "pending",
-		PaymentMethod: "razorpay",
+		PaymentMethod: paymentMethod,
 		PaymentStatus: "pending",
 		PaymentId:     razorId,
+		CouponCode:    cart.CouponCode,
+		Discount:      cart.OfferPrice,
+		GiftCardPaid:  claim.giftCardPaid(),
+	}
+	if hold != nil {
+		order.WalletPaid = hold.Amount
 	}
 	OrderId, err2 := ou.orderRepo.Create(order)
 	if err2 != nil {
-		return "", 0, errors.New("Order placing failed")
+		return "", 0, errors.Join(errors.New("Order placing failed"), ou.releaseCheckout(claim, orderItems), ou.releaseHold(hold))
 	}
-	for _, cartItem := range cartItems {
-		orderItem := entity.OrderItem{
-			OrderID:   OrderId,
-			ProductID: cartItem.ProductId,
-			Category:  cartItem.Category,
-			Quantity:  cartItem.Quantity,
-			Price:     cartItem.Price,
+	err = ou.attachDiscount(claim, OrderId)
+	if err != nil {
+		return "", 0, errors.Join(err, ou.abandonOrder(OrderId, claim, orderItems), ou.releaseHold(hold))
+	}
+	if hold != nil {
+		err = ou.orderRepo.SetHoldOrder(hold, OrderId)
+		if err != nil {
+			return "", 0, errors.Join(errors.New("Holding wallet payment failed"), ou.abandonOrder(OrderId, claim, orderItems), ou.releaseHold(hold))
 		}
-		orderItems = append(orderItems, orderItem)
+	}
+	for i := range orderItems {
+		orderItems[i].OrderID = OrderId
 	}
 
 	err3 := ou.orderRepo.CreateOrderItems(orderItems)
 	if err3 != nil {
-		return "", 0, errors.New("User cart is empty")
+		return "", 0, errors.Join(errors.New("User cart is empty"), ou.abandonOrder(OrderId, claim, orderItems), ou.releaseHold(hold))
 	}
 	return razorId, OrderId, nil
 }
 
-func (ou *OrderUsecase) ExecuteRazorPaymentVerification(Signature, razorId, paymentId string) (*entity.Invoice, error) {
+func (ou *OrderUsecase) ExecuteRazorPaymentVerification(Signature, razorId, paymentId string, userId int) (*entity.Invoice, error) {
 
 	result, err := ou.orderRepo.GetByRazorId(razorId)
-	if err != nil {
+	if err != nil || result.UserID != userId {
 		return nil, errors.New("Order not found")
 	}
-	err1 := utils.RazorPaymentVerification(Signature, razorId, paymentId)
+	err1 := utils.RazorPaymentVerification(ou.razorpay, Signature, razorId, paymentId)
 	if err1 != nil {
-		result.PaymentStatus = "failed"
-		err2 := ou.orderRepo.Update(result)
+		claimed, err2 := ou.orderRepo.ClaimPayment(result.ID, "failed", "pending")
 		if err2 != nil {
 			return nil, errors.New("payment updation failed")
 		}
+		if claimed {
+			err2 = ou.releaseUnpaid(result)
+			if err2 != nil {
+				return nil, err2
+			}
+		}
 		return nil, err1
 	}
+	claimed, err := ou.orderRepo.ClaimPayment(result.ID, "successful", "pending")
+	if err != nil {
+		return nil, errors.New("payment updation failed")
+	}
+	if !claimed {
+		return nil, ou.refundLatePayment(result, paymentId)
+	}
 	result.PaymentStatus = "successful"
+	result.GatewayPayId = paymentId
 	err3 := ou.orderRepo.Update(result)
 	if err3 != nil {
 		return nil, errors.New("payment updation failed")
 	}
+	err = ou.orderRepo.CaptureWalletHold(result.ID)
+	if err != nil {
+		return nil, errors.New("Capturing wallet payment failed")
+	}
+	orderItems, err := ou.orderRepo.GetOrderItems(result.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = ou.completePurchase(result.UserID, orderItems)
+	if err != nil {
+		return nil, err
+	}
 	userCart, err := ou.cartRepo.GetByUserID(result.UserID)
 	if err != nil {
 		return nil, errors.New("User cart not found")
@@ -201,52 +331,150 @@ func (ou *OrderUsecase) ExecuteRazorPaymentVerification(Signature, razorId, paym
 	if err != nil {
 		return nil, errors.New("User address  not found")
 	}
-	Total := userCart.TotalPrice - float64(userCart.OfferPrice)
+	promotions, err := ou.orderRepo.GetOrderPromotions(result.ID)
+	if err != nil {
+		return nil, errors.New("Order promotions not found")
+	}
 	invoiceData := &entity.Invoice{
 		OrderId:     result.ID,
 		UserId:      result.UserID,
 		AddressType: userAddress.Type,
 		Quantity:    userCart.TicketQuantity + userCart.ApparelQuantity,
-		Price:       Total,
-		Payment:     "razorpay",
+		Price:       result.Total,
+		Payment:     result.PaymentMethod,
 		Status:      "succesful",
 		PaymentId:   "nil",
+		Discount:    result.Discount,
+		GiftCard:    result.GiftCardPaid,
+		Wallet:      result.WalletPaid,
 		Remark:      "Zog_Festiv",
 	}
 	invoice, err := ou.orderRepo.CreateInvoice(invoiceData)
 	if err != nil {
 		return nil, errors.New("Invoice Creating failed")
 	}
+	invoice.Promotions = promotions
 	err4 := ou.cartRepo.RemoveCartItems(int(userCart.ID))
 	if err4 != nil {
 		return nil, errors.New("Delete cart items failed")
 	}
-	userCart = &entity.Cart{
-		OfferPrice:      0,
-		TotalPrice:      0,
-		TicketQuantity:  0,
-		ApparelQuantity: 0,
-	}
+	userCart.OfferPrice = 0
+	userCart.CouponCode = ""
+	userCart.LoyaltyPoints = 0
+	userCart.GiftCardId = 0
+	userCart.OfferNote = ""
+	userCart.TotalPrice = 0
+	userCart.TicketQuantity = 0
+	userCart.ApparelQuantity = 0
 	err5 := ou.cartRepo.UpdateCart(userCart)
 	if err5 != nil {
 		return nil, errors.New("Updating cart failed")
 	}
+	ou.cartRepo.ReplaceCartPromotions(int(userCart.ID), nil)
+	invoice.GiftCards, err = ou.activateGiftCards(result.ID, orderItems)
+	if err != nil {
+		return invoice, err
+	}
 	return invoice, nil
 }
 
-func (ou *OrderUsecase) ExecutePurchaseWallet(userId int, address int) (*entity.Invoice, error) {
-	var orderItems []entity.OrderItem
-	user, err := ou.userRepo.GetByID(userId)
+// releaseUnpaid gives back the stock, seats, discounts and wallet hold an
+// online order took at checkout once its gateway payment has failed or
+// expired.
+func (ou *OrderUsecase) releaseUnpaid(order *entity.Order) error {
+	err := ou.restoreStock(order.ID)
+	if err != nil {
+		return err
+	}
+	err = ou.freeSeats(order.ID)
+	if err != nil {
+		return err
+	}
+	err = ou.releaseOrderDiscount(order)
+	if err != nil {
+		return err
+	}
+	return ou.releaseWalletHold(order.ID)
+}
+
+// refundLatePayment handles a gateway payment for an order that is no longer
+// waiting for one. A payment arriving after the order expired, was
+// cancelled or failed verification is refunded in full; a repeated
+// verification of a paid order is refused.
+func (ou *OrderUsecase) refundLatePayment(order *entity.Order, paymentId string) error {
+	current, err := ou.orderRepo.GetByID(order.ID)
+	if err != nil {
+		return errors.New("Order not found")
+	}
+	if current.PaymentStatus != "expired" && current.PaymentStatus != "canceled" && current.PaymentStatus != "failed" {
+		return errors.New("Payment already processed")
+	}
+	err = utils.RazorRefund(ou.razorpay, paymentId, int(current.Total)-current.GiftCardPaid-current.WalletPaid)
+	if err != nil {
+		return err
+	}
+	if current.PaymentStatus == "canceled" {
+		return errors.New("Order was cancelled - the payment has been refunded")
+	}
+	if current.PaymentStatus == "failed" {
+		return errors.New("Payment failed verification - the payment has been refunded")
+	}
+	return errors.New("Payment window expired - the payment has been refunded")
+}
+
+// ExecuteExpirePayments cancels online orders whose payment was not verified
+// within the payment window, so their seats, discounts and wallet holds go
+// back instead of staying locked by an abandoned checkout. Wallet holds that
+// never reached an order are released once they expire.
+func (ou *OrderUsecase) ExecuteExpirePayments() error {
+	holds, err := ou.orderRepo.GetExpiredHolds(time.Now())
+	if err != nil {
+		return errors.New("Fetching wallet holds failed")
+	}
+	for i := range holds {
+		err = ou.orderRepo.ReleaseWalletHold(&holds[i])
+		if err != nil {
+			return errors.New("Releasing wallet payment failed")
+		}
+	}
+	orders, err := ou.orderRepo.GetUnpaidOnline(time.Now().Add(-ou.paymentWindow))
 	if err != nil {
-		return nil, errors.New("User not found")
+		return errors.New("Fetching unpaid orders failed")
+	}
+	for i := range orders {
+		claimed, err := ou.orderRepo.ClaimPayment(orders[i].ID, "expired", "canceled")
+		if err != nil {
+			return errors.New("Expiring order failed")
+		}
+		if !claimed {
+			continue
+		}
+		err = ou.releaseUnpaid(&orders[i])
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// StartSweeper periodically expires unpaid online orders.
+func (ou *OrderUsecase) StartSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if err := ou.ExecuteExpirePayments(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// ExecutePurchaseWallet pays for the order from the wallet. The wallet is
+// debited before the order is placed, and everything the checkout took is
+// given back if placing it fails.
+func (ou *OrderUsecase) ExecutePurchaseWallet(userId int, address int) (*entity.Invoice, error) {
 	cart, err := ou.cartRepo.GetCartById(userId)
 	if err != nil {
 		return nil, errors.New("Cart  not found")
 	}
-	if user.Wallet < int(cart.TotalPrice) {
-		return nil, errors.New("Wallet have not enough money-add money or choose another method")
-	}
 	cartItems, err1 := ou.cartRepo.GetAllCartItems(int(cart.ID))
 	if err1 != nil {
 		return nil, errors.New("Cart Items  not found")
@@ -255,24 +483,52 @@ func (ou *OrderUsecase) ExecutePurchaseWallet(userId int, address int) (*entity.
 	if err != nil {
 		return nil, errors.New("User address  not found")
 	}
+	claim, err := ou.redeemDiscount(userId, cart)
+	if err != nil {
+		return nil, err
+	}
 	Total := cart.TotalPrice - float64(cart.OfferPrice)
+	err = ou.claimSeats(userId, cartItems)
+	if err != nil {
+		return nil, errors.Join(err, ou.releaseDiscount(claim))
+	}
+	orderItems := newOrderItems(cartItems)
+	err = ou.reserveStock(orderItems)
+	if err != nil {
+		return nil, errors.Join(err, ou.releaseSeats(orderItems), ou.releaseDiscount(claim))
+	}
+	walletPaid := int(Total) - claim.giftCardPaid()
+	err = ou.orderRepo.DebitWallet(userId, walletPaid)
+	if err != nil {
+		return nil, errors.Join(err, ou.releaseCheckout(claim, orderItems))
+	}
 	order := &entity.Order{
 		UserID:        cart.UserId,
 		AddressId:     userAddress.ID,
 		Total:         Total,
 		Status:        "pending",
 		PaymentMethod: "wallet",
-		PaymentStatus: "succesful",
+		CouponCode:    cart.CouponCode,
+		Discount:      cart.OfferPrice,
+		GiftCardPaid:  claim.giftCardPaid(),
+		WalletPaid:    walletPaid,
+		PaymentStatus: "successful",
 	}
 
 	OrderID, err2 := ou.orderRepo.Create(order)
 	if err2 != nil {
-		return nil, errors.New("Order placing failed")
+		return nil, errors.Join(errors.New("Order placing failed"), ou.refundDebit(userId, walletPaid), ou.releaseCheckout(claim, orderItems))
+	}
+	err = ou.attachDiscount(claim, OrderID)
+	if err != nil {
+		return nil, errors.Join(err, ou.abandonOrder(OrderID, claim, orderItems), ou.refundDebit(userId, walletPaid))
 	}
-	user.Wallet -= int(order.Total)
-	err = ou.orderRepo.UpdateUserWallet(user)
+	for i := range orderItems {
+		orderItems[i].OrderID = OrderID
+	}
+	err = ou.orderRepo.CreateOrderItems(orderItems)
 	if err != nil {
-		return nil, errors.New("Wallet updation failed")
+		return nil, errors.Join(errors.New("User cart is empty"), ou.abandonOrder(OrderID, claim, orderItems), ou.refundDebit(userId, walletPaid))
 	}
 	invoiceData := &entity.Invoice{
 		OrderId:     OrderID,
@@ -283,188 +539,981 @@ func (ou *OrderUsecase) ExecutePurchaseWallet(userId int, address int) (*entity.
 		Payment:     order.PaymentMethod,
 		Status:      order.PaymentStatus,
 		PaymentId:   "nil",
+		Discount:    order.Discount,
+		GiftCard:    order.GiftCardPaid,
+		Wallet:      order.WalletPaid,
 		Remark:      "Zog_Festiv",
 	}
 	invoice, err := ou.orderRepo.CreateInvoice(invoiceData)
 	if err != nil {
 		return nil, errors.New("Invoice Creating failed")
 	}
-	for _, cartItem := range cartItems {
-		orderItem := entity.OrderItem{
-			OrderID:   OrderID,
-			ProductID: cartItem.ProductId,
-			Category:  cartItem.Category,
-			Quantity:  cartItem.Quantity,
-			Price:     cartItem.Price,
-		}
-		orderItems = append(orderItems, orderItem)
-	}
-
-	err = ou.orderRepo.CreateOrderItems(orderItems)
+	invoice.Promotions = claim.promotions
+	err = ou.completePurchase(userId, orderItems)
 	if err != nil {
-		return nil, errors.New("User cart is empty")
+		return nil, err
 	}
 
 	err = ou.cartRepo.RemoveCartItems(int(cart.ID))
 	if err != nil {
 		return nil, errors.New("Delete cart items failed")
 	}
-	cart = &entity.Cart{
-		TotalPrice:      0,
-		TicketQuantity:  0,
-		ApparelQuantity: 0,
-	}
+	cart.OfferPrice = 0
+	cart.CouponCode = ""
+	cart.LoyaltyPoints = 0
+	cart.GiftCardId = 0
+	cart.OfferNote = ""
+	cart.TotalPrice = 0
+	cart.TicketQuantity = 0
+	cart.ApparelQuantity = 0
 	err = ou.cartRepo.UpdateCart(cart)
 	if err != nil {
 		return nil, errors.New("Updating cart failed")
 	}
+	ou.cartRepo.ReplaceCartPromotions(int(cart.ID), nil)
+	invoice.GiftCards, err = ou.activateGiftCards(OrderID, orderItems)
+	if err != nil {
+		return invoice, err
+	}
 	return invoice, nil
 }
 
-func (ou *OrderUsecase) ExecuteCancelOrder(orderId int) error {
+func (ou *OrderUsecase) ExecuteCancelOrder(userId, orderId int) error {
 	result, err := ou.orderRepo.GetByID(orderId)
-	if err != nil {
+	if err != nil || result.UserID != userId {
 		return errors.New("Order not found")
 	}
-	user, err := ou.userRepo.GetByID(result.UserID)
+	return ou.cancelOrder(result)
+}
+
+// cancelOrder cancels an order that has not shipped and gives back everything
+// it took. The order is claimed as canceled before anything is undone, so it
+// cannot race payment verification, the payment sweeper or a second cancel.
+func (ou *OrderUsecase) cancelOrder(order *entity.Order) error {
+	if order.Status != "pending" && order.Status != "confirmed" {
+		return errors.New("order cancelation failed- cancel time exceeded")
+	}
+	err := ou.checkPassesHeld(order)
 	if err != nil {
-		return errors.New("User not found")
+		return err
 	}
-	if result.Status != "pending" && result.Status != "confirmed" {
-		return errors.New("order cancelation failed- cancel time exceeded")
+	spent, err := ou.giftCardRepo.OrderCardsSpent(order.ID)
+	if err != nil {
+		return errors.New("Checking gift cards failed")
 	}
-	if result.PaymentStatus == "successful" {
-		result.PaymentStatus = "refund"
-		user.Wallet = int(result.Total)
-		err = ou.orderRepo.UpdateUserWallet(user)
-		if err != nil {
-			return errors.New("User wallet updation failed")
-		}
+	if spent {
+		return errors.New("Gift cards from this order have already been used")
 	}
-	result.Status = "canceled"
-	err1 := ou.orderRepo.Update(result)
-	if err1 != nil {
-		return errors.New("order cancelation failed")
+	var claimed bool
+	if order.PaymentMethod != "Cod" && order.PaymentStatus == "pending" {
+		claimed, err = ou.orderRepo.ClaimPayment(order.ID, "canceled", "canceled")
+	} else {
+		claimed, err = ou.orderRepo.UpdateStatus(order.ID, order.Status, map[string]interface{}{"status": "canceled"})
 	}
-	return nil
-}
-
-func (ou *OrderUsecase) ExecuteOrderHistory(userId, page, limit int) ([]entity.Order, error) {
-	offset := (page - 1) * limit
-	orderList, err := ou.orderRepo.GetAllOrders(userId, offset, limit)
 	if err != nil {
-		return nil, err
+		return errors.New("order cancelation failed")
 	}
-	return orderList, nil
-}
-
-func (ou *OrderUsecase) ExecuteReturnOrder(returnData entity.Return) error {
-	order, err := ou.orderRepo.GetByID(returnData.OrderId)
+	if !claimed {
+		return errors.New("Order was updated by someone else - try again")
+	}
+	err = ou.giftCardRepo.VoidOrder(order.ID, time.Now())
 	if err != nil {
-		return errors.New("Order not found")
+		return err
 	}
-	order.Status = "return"
-	order.PaymentStatus = "refund"
-	err = ou.orderRepo.Update(order)
+	err = ou.restoreStock(order.ID)
 	if err != nil {
-		return errors.New("order updation failed")
+		return err
 	}
-	err = ou.orderRepo.CreateReturn(&returnData)
+	err = ou.orderRepo.CancelTicketPasses(order.ID, order.UserID)
 	if err != nil {
-		return errors.New("return creation failed")
+		return errors.New("Cancelling tickets failed")
 	}
-	return nil
-}
-
-func (ou *OrderUsecase) ExecuteReturnUpdate(status string, returnId int) error {
-	result, err := ou.orderRepo.GetReturnByID(returnId)
+	err = ou.freeSeats(order.ID)
 	if err != nil {
-		return errors.New("Order not found")
+		return err
 	}
-	order, err := ou.orderRepo.GetByID(result.OrderId)
+	err = ou.releaseOrderDiscount(order)
 	if err != nil {
-		return errors.New("Order not found")
+		return err
 	}
-	result.Status = status
-	result.Refund = "wallet"
-	result.TotalPrice = int(order.Total)
-	err = ou.orderRepo.UpdateReturn(result)
+	err = ou.releaseWalletHold(order.ID)
 	if err != nil {
-		return errors.New("return updation failed")
+		return err
 	}
-	order.Status = "return"
-	err = ou.orderRepo.Update(order)
+	err = ou.clawbackRewards(order.ID, "order cancelled")
 	if err != nil {
-		return errors.New("order updation failed")
+		return err
+	}
+	if order.PaymentStatus == "successful" {
+		return ou.refundOrder(order)
 	}
 	return nil
 }
 
-func (ou *OrderUsecase) ExecuteRefund(orderId int) error {
-	order, err := ou.orderRepo.GetByID(orderId)
-	if err != nil {
-		return errors.New("Order not found")
+func newOrderItems(cartItems []entity.CartItem) []entity.OrderItem {
+	var orderItems []entity.OrderItem
+	for _, cartItem := range cartItems {
+		orderItems = append(orderItems, entity.OrderItem{
+			ProductID: cartItem.ProductId,
+			Category:  cartItem.Category,
+			Quantity:  cartItem.Quantity,
+			Price:     cartItem.Price,
+			SeatId:    cartItem.SeatId,
+		})
 	}
+	return orderItems
+}
 
-	if order.Status == "return" {
-		result, err := ou.orderRepo.GetReturnByOrderID(order.ID)
+// reserveStock takes the items from stock before the order is placed, so an
+// order, paid or not, never holds more than there is. When an item has run
+// out, what was already taken goes back and the checkout fails.
+func (ou *OrderUsecase) reserveStock(orderItems []entity.OrderItem) error {
+	for i, orderItem := range orderItems {
+		if orderItem.Category == "giftcard" {
+			continue
+		}
+		inventory := entity.Inventory{
+			ProductId:       orderItem.ProductID,
+			ProductCategory: orderItem.Category,
+			Quantity:        orderItem.Quantity,
+		}
+		err := ou.productRepo.DecreaseProductQuantity(&inventory)
 		if err != nil {
-			return errors.New("Return not found")
+			return errors.Join(err, ou.returnStock(orderItems[:i]))
+		}
+	}
+	return nil
+}
+
+// abandonOrder cancels an order whose checkout failed after it was placed,
+// and gives back the stock, seats and discount the checkout took. If the
+// order can no longer be claimed, whoever moved it on gives them back.
+func (ou *OrderUsecase) abandonOrder(orderId int, claim *discountClaim, orderItems []entity.OrderItem) error {
+	claimed, err := ou.orderRepo.UpdateStatus(orderId, "pending", map[string]interface{}{"status": "canceled", "payment_status": "canceled"})
+	if err != nil {
+		return errors.New("Cancelling order failed")
+	}
+	if !claimed {
+		return nil
+	}
+	return ou.releaseCheckout(claim, orderItems)
+}
+
+// refundDebit puts a wallet checkout's debit back when its order could not
+// be placed.
+func (ou *OrderUsecase) refundDebit(userId, amount int) error {
+	err := ou.orderRepo.CreditWallet(userId, amount)
+	if err != nil {
+		return errors.New("User wallet updation failed")
+	}
+	return nil
+}
+
+// releaseCheckout gives back what a checkout took when its order could not
+// be placed: the reserved stock, the seats and the discount.
+func (ou *OrderUsecase) releaseCheckout(claim *discountClaim, orderItems []entity.OrderItem) error {
+	return errors.Join(ou.returnStock(orderItems), ou.releaseSeats(orderItems), ou.releaseDiscount(claim))
+}
+
+// completePurchase finishes an order once it is paid, or placed cash on
+// delivery; its stock was reserved at checkout. The user's waitlist
+// reservations are used up and the tickets issued.
+func (ou *OrderUsecase) completePurchase(userId int, orderItems []entity.OrderItem) error {
+	for _, orderItem := range orderItems {
+		if orderItem.Category == "giftcard" {
+			continue
 		}
-		user, err := ou.userRepo.GetByID(result.UserId)
+		err := ou.waitlistRepo.MarkPurchased(userId, orderItem.Category, orderItem.ProductID)
 		if err != nil {
-			return errors.New("Order not found")
+			return errors.New("Updating waitlist failed")
 		}
-		if result.Refund == "wallet" {
-			user.Wallet = result.TotalPrice
-			err = ou.orderRepo.UpdateUserWallet(user)
-			if err != nil {
-				return errors.New("User wallet updation failed")
-			}
+	}
+	return ou.issuePasses(userId, orderItems)
+}
+
+// activateGiftCards issues the gift cards bought with a paid order and emails
+// their codes to the recipients. The cards are returned with their codes,
+// which the invoice shows the purchaser once.
+func (ou *OrderUsecase) activateGiftCards(orderId int, orderItems []entity.OrderItem) ([]entity.GiftCard, error) {
+	var cards []entity.GiftCard
+	var errs []error
+	now := time.Now()
+	for _, orderItem := range orderItems {
+		if orderItem.Category != "giftcard" {
+			continue
 		}
-		result.Status = "completed"
-		err = ou.orderRepo.UpdateReturn(result)
+		code, err := ou.giftCardRepo.NewCode()
 		if err != nil {
-			return errors.New("return updation failed")
-		} else {
-			return nil
+			errs = append(errs, err)
+			continue
 		}
-	} else {
-		user, err := ou.userRepo.GetByID(order.UserID)
+		card, err := ou.giftCardRepo.Activate(orderItem.ProductID, orderId, code, now.AddDate(0, 0, utils.GiftCardValidityDays), now)
 		if err != nil {
-			return errors.New("Order not found")
+			errs = append(errs, errors.New("Issuing gift card failed"))
+			continue
 		}
-		if order.PaymentStatus == "successful" {
-			order.PaymentStatus = "refund"
-			user.Wallet = int(order.Total)
-			err = ou.orderRepo.UpdateUserWallet(user)
-			if err != nil {
-				return errors.New("User wallet updation failed")
-			}
+		if card == nil {
+			continue
 		}
-		order.Status = "canceled"
-		err1 := ou.orderRepo.Update(order)
-		if err1 != nil {
-			return errors.New("order cancelation failed")
+		cards = append(cards, *card)
+		err = ou.notifyRecipient(card)
+		if err != nil {
+			errs = append(errs, err)
 		}
-		return nil
 	}
+	if len(errs) > 0 {
+		return cards, errors.Join(ErrGiftCardsFailed, errors.Join(errs...))
+	}
+	return cards, nil
+}
 
+// notifyRecipient emails a new card's code to its recipient and tells them
+// about the card in the app when they have an account.
+func (ou *OrderUsecase) notifyRecipient(card *entity.GiftCard) error {
+	if card.RecipientEmail != "" {
+		err := ou.mailer.Send(utils.GiftCardMail(card))
+		if err != nil {
+			return errors.New("Emailing gift card failed")
+		}
+	}
+	var recipient *entity.User
+	if card.RecipientEmail != "" {
+		recipient, _ = ou.userRepo.GetByEmail(card.RecipientEmail)
+	}
+	if recipient == nil && card.RecipientPhone != "" {
+		recipient, _ = ou.userRepo.GetByPhone(card.RecipientPhone)
+	}
+	if recipient != nil {
+		ou.notify(recipient.ID, "Gift card received", utils.GiftCardNotice(card))
+	}
+	return nil
 }
 
-func (ou *OrderUsecase) ExecuteOrderUpdate(orderId int, status string) error {
-	result, err := ou.orderRepo.GetByID(orderId)
+// checkPassesHeld refuses to unwind an order once any of its tickets has been
+// transferred, since the buyer no longer holds what they would be refunded for.
+func (ou *OrderUsecase) checkPassesHeld(order *entity.Order) error {
+	transferred, err := ou.orderRepo.CountTransferredPasses(order.ID, order.UserID)
 	if err != nil {
-		return errors.New("Order not found")
+		return errors.New("Checking tickets failed")
 	}
-	result.Status = status
-	err = ou.orderRepo.Update(result)
+	if transferred > 0 {
+		return errors.New("Tickets from this order have been transferred")
+	}
+	return nil
+}
+
+// issuePasses gives every purchased ticket its own code so single tickets can
+// be checked in and transferred.
+func (ou *OrderUsecase) issuePasses(userId int, orderItems []entity.OrderItem) error {
+	var passes []entity.TicketPass
+	for _, orderItem := range orderItems {
+		if orderItem.Category != "ticket" {
+			continue
+		}
+		for i := 0; i < orderItem.Quantity; i++ {
+			code, err := utils.GenerateCode(12, utils.CodeAlphabet)
+			if err != nil {
+				return errors.New("Issuing tickets failed")
+			}
+			passes = append(passes, entity.TicketPass{
+				OrderId:  orderItem.OrderID,
+				TicketId: orderItem.ProductID,
+				SeatId:   orderItem.SeatId,
+				OwnerId:  userId,
+				Code:     code,
+				Status:   "active",
+			})
+		}
+	}
+	if len(passes) == 0 {
+		return nil
+	}
+	err := ou.orderRepo.CreateTicketPasses(passes)
 	if err != nil {
-		return errors.New("order updation failed")
+		return errors.New("Issuing tickets failed")
 	}
 	return nil
 }
 
+// discountClaim is what checkout took for the cart's discount: coupon and
+// offer uses and loyalty points, plus the gift card payment. It is attached to
+// the order once placed, or given back when the order fails.
+type discountClaim struct {
+	usedCoupon *entity.UsedCoupon
+	promotions []entity.AppliedPromotion
+	points     *entity.LoyaltyEntry
+	giftCard   *entity.GiftCardTxn
+}
+
+func (claim *discountClaim) giftCardPaid() int {
+	if claim.giftCard == nil {
+		return 0
+	}
+	return -claim.giftCard.Amount
+}
+
+// redeemDiscount takes one use of every promotion in the cart's breakdown,
+// spends the loyalty points applied to the cart and charges the cart's gift
+// card for what it can cover, for the order being placed.
+func (ou *OrderUsecase) redeemDiscount(userId int, cart *entity.Cart) (*discountClaim, error) {
+	promotions, err := ou.cartRepo.GetCartPromotions(int(cart.ID))
+	if err != nil {
+		return nil, errors.New("Cart promotions not found")
+	}
+	claim := &discountClaim{}
+	for _, promotion := range promotions {
+		if promotion.Kind != "offer" {
+			continue
+		}
+		err := ou.productRepo.RedeemOffer(promotion.PromotionId)
+		if err != nil {
+			return nil, errors.Join(err, ou.releaseDiscount(claim))
+		}
+		claim.promotions = append(claim.promotions, promotion)
+	}
+	if cart.CouponCode != "" {
+		claim.usedCoupon, err = ou.productRepo.RedeemCoupon(cart.CouponCode, userId)
+		if err != nil {
+			return nil, errors.Join(err, ou.releaseDiscount(claim))
+		}
+	}
+	if cart.LoyaltyPoints > 0 {
+		claim.points, err = ou.loyaltyRepo.Redeem(userId, cart.LoyaltyPoints, time.Now())
+		if err != nil {
+			return nil, errors.Join(err, ou.releaseDiscount(claim))
+		}
+	}
+	if cart.GiftCardId != 0 {
+		due := int(cart.TotalPrice) - cart.OfferPrice
+		claim.giftCard, err = ou.giftCardRepo.Charge(cart.GiftCardId, userId, due, time.Now())
+		if err != nil {
+			return nil, errors.Join(err, ou.releaseDiscount(claim))
+		}
+	}
+	claim.promotions = promotions
+	return claim, nil
+}
+
+func (ou *OrderUsecase) attachDiscount(claim *discountClaim, orderId int) error {
+	if claim.usedCoupon != nil {
+		err := ou.productRepo.SetCouponOrder(claim.usedCoupon, orderId)
+		if err != nil {
+			return errors.New("Attaching coupon failed")
+		}
+	}
+	if claim.points != nil {
+		err := ou.loyaltyRepo.SetRedeemOrder(claim.points, orderId)
+		if err != nil {
+			return errors.New("Attaching loyalty points failed")
+		}
+	}
+	if claim.giftCard != nil {
+		err := ou.giftCardRepo.SetTxnOrder(claim.giftCard, orderId)
+		if err != nil {
+			return errors.New("Attaching gift card payment failed")
+		}
+	}
+	err := ou.orderRepo.CreateOrderPromotions(orderId, claim.promotions)
+	if err != nil {
+		return errors.New("Saving order promotions failed")
+	}
+	return nil
+}
+
+// releaseDiscount gives back everything in the claim, carrying on past a
+// failed release so one bad row does not keep the rest locked.
+func (ou *OrderUsecase) releaseDiscount(claim *discountClaim) error {
+	var errs []error
+	if claim.usedCoupon != nil {
+		err := ou.productRepo.ReleaseCoupon(claim.usedCoupon)
+		if err != nil {
+			errs = append(errs, errors.New("Releasing coupon failed"))
+		}
+	}
+	for _, promotion := range claim.promotions {
+		if promotion.Kind == "offer" {
+			err := ou.productRepo.ReleaseOffer(promotion.PromotionId)
+			if err != nil {
+				errs = append(errs, errors.New("Releasing offer failed"))
+			}
+		}
+	}
+	if claim.points != nil {
+		err := ou.restorePoints(claim.points)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if claim.giftCard != nil {
+		err := ou.giftCardRepo.Refund(claim.giftCard, time.Now())
+		if err != nil {
+			errs = append(errs, errors.New("Refunding gift card failed"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// releasePayment gives back what an online checkout took before the order
+// could be placed.
+func (ou *OrderUsecase) releasePayment(claim *discountClaim, hold *entity.WalletHold) error {
+	err := ou.releaseDiscount(claim)
+	if hold != nil {
+		err = errors.Join(err, ou.orderRepo.ReleaseWalletHold(hold))
+	}
+	return err
+}
+
+func (ou *OrderUsecase) releaseHold(hold *entity.WalletHold) error {
+	if hold == nil {
+		return nil
+	}
+	err := ou.orderRepo.ReleaseWalletHold(hold)
+	if err != nil {
+		return errors.New("Releasing wallet payment failed")
+	}
+	return nil
+}
+
+func (ou *OrderUsecase) releaseWalletHold(orderId int) error {
+	hold, err := ou.orderRepo.GetWalletHold(orderId)
+	if err != nil {
+		return errors.New("Releasing wallet payment failed")
+	}
+	if hold == nil {
+		return nil
+	}
+	err = ou.orderRepo.ReleaseWalletHold(hold)
+	if err != nil {
+		return errors.New("Releasing wallet payment failed")
+	}
+	return nil
+}
+
+// refundPayment pays amount back split between the wallet and the gateway in
+// proportion to what each paid for the order. Without a gateway payment to
+// refund against, the whole amount goes to the wallet.
+func (ou *OrderUsecase) refundPayment(order *entity.Order, amount int) error {
+	gatewayPaid := 0
+	if order.GatewayPayId != "" {
+		gatewayPaid = int(order.Total) - order.GiftCardPaid - order.WalletPaid
+	}
+	wallet, gateway := utils.SplitRefund(amount, order.WalletPaid, gatewayPaid)
+	if gateway > 0 {
+		err := utils.RazorRefund(ou.razorpay, order.GatewayPayId, gateway)
+		if err != nil {
+			return err
+		}
+	}
+	if wallet > 0 {
+		err := ou.orderRepo.CreditWallet(order.UserID, wallet)
+		if err != nil {
+			return errors.New("User wallet updation failed")
+		}
+	}
+	return nil
+}
+
+func (ou *OrderUsecase) restorePoints(entry *entity.LoyaltyEntry) error {
+	setting, err := ou.loyaltyRepo.GetSetting()
+	if err != nil {
+		return errors.New("Restoring loyalty points failed")
+	}
+	err = ou.loyaltyRepo.Restore(entry, setting.ExpiryDays, time.Now())
+	if err != nil {
+		return errors.New("Restoring loyalty points failed")
+	}
+	return nil
+}
+
+func (ou *OrderUsecase) releaseOrderDiscount(order *entity.Order) error {
+	promotions, err := ou.orderRepo.GetOrderPromotions(order.ID)
+	if err != nil {
+		return errors.New("Releasing offer failed")
+	}
+	for _, promotion := range promotions {
+		if promotion.Kind != "offer" {
+			continue
+		}
+		err := ou.productRepo.ReleaseOffer(promotion.PromotionId)
+		if err != nil {
+			return errors.New("Releasing offer failed")
+		}
+	}
+	points, err := ou.loyaltyRepo.GetRedeemByOrder(order.ID)
+	if err != nil {
+		return errors.New("Restoring loyalty points failed")
+	}
+	if points != nil {
+		err = ou.restorePoints(points)
+		if err != nil {
+			return err
+		}
+	}
+	err = ou.giftCardRepo.RefundOrder(order.ID, time.Now())
+	if err != nil {
+		return errors.New("Refunding gift card failed")
+	}
+	usedCoupon, err := ou.productRepo.GetCouponUsageByOrder(order.ID)
+	if err != nil {
+		return errors.New("Releasing coupon failed")
+	}
+	if usedCoupon == nil {
+		return nil
+	}
+	err = ou.productRepo.ReleaseCoupon(usedCoupon)
+	if err != nil {
+		return errors.New("Releasing coupon failed")
+	}
+	return nil
+}
+
+// claimSeats marks the held seats in the cart as sold before the order is
+// placed, failing the checkout if any hold was lost to another user. Seats of
+// an online order that is never paid are freed again when the order expires.
+func (ou *OrderUsecase) claimSeats(userId int, cartItems []entity.CartItem) error {
+	var seatIds []int
+	for _, cartItem := range cartItems {
+		if cartItem.SeatId != 0 {
+			seatIds = append(seatIds, cartItem.SeatId)
+		}
+	}
+	if len(seatIds) == 0 {
+		return nil
+	}
+	return ou.seatRepo.ClaimSeats(seatIds, userId)
+}
+
+func (ou *OrderUsecase) freeSeats(orderId int) error {
+	orderItems, err := ou.orderRepo.GetOrderItems(orderId)
+	if err != nil {
+		return err
+	}
+	return ou.releaseSeats(orderItems)
+}
+
+func (ou *OrderUsecase) releaseSeats(orderItems []entity.OrderItem) error {
+	var seatIds []int
+	for _, orderItem := range orderItems {
+		if orderItem.SeatId != 0 {
+			seatIds = append(seatIds, orderItem.SeatId)
+		}
+	}
+	if len(seatIds) == 0 {
+		return nil
+	}
+	err := ou.seatRepo.FreeSeats(seatIds)
+	if err != nil {
+		return errors.New("Releasing seats failed")
+	}
+	return nil
+}
+
+func (ou *OrderUsecase) restoreStock(orderId int) error {
+	orderItems, err := ou.orderRepo.GetOrderItems(orderId)
+	if err != nil {
+		return err
+	}
+	return ou.returnStock(orderItems)
+}
+
+func (ou *OrderUsecase) returnStock(orderItems []entity.OrderItem) error {
+	for _, orderItem := range orderItems {
+		if orderItem.Category == "giftcard" {
+			continue
+		}
+		inventory := entity.Inventory{
+			ProductId:       orderItem.ProductID,
+			ProductCategory: orderItem.Category,
+			Quantity:        orderItem.Quantity,
+		}
+		err := ou.productRepo.RestoreProductQuantity(&inventory)
+		if err != nil {
+			return errors.New("Restoring stock failed")
+		}
+	}
+	return nil
+}
+
+// ExecuteMigratePromotions runs at startup and fills in the discount
+// breakdown of orders placed before it was stored.
+func (ou *OrderUsecase) ExecuteMigratePromotions() (int, error) {
+	return ou.orderRepo.MigrateLegacyPromotions()
+}
+
+func (ou *OrderUsecase) ExecuteOrderHistory(userId, page, limit int) ([]entity.Order, error) {
+	offset := (page - 1) * limit
+	orderList, err := ou.orderRepo.GetAllOrders(userId, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orderList {
+		orderList[i].Promotions, err = ou.orderRepo.GetOrderPromotions(orderList[i].ID)
+		if err != nil {
+			return nil, errors.New("Order promotions not found")
+		}
+	}
+	return orderList, nil
+}
+
+// ExecuteReturnOrder requests a return of a fulfilled order within the return
+// window. Loyalty points earned on the order stay pending until the return is
+// decided.
+func (ou *OrderUsecase) ExecuteReturnOrder(returnData entity.Return) error {
+	order, err := ou.orderRepo.GetByID(returnData.OrderId)
+	if err != nil || order.UserID != returnData.UserId {
+		return errors.New("Order not found")
+	}
+	setting, err := ou.loyaltyRepo.GetSetting()
+	if err != nil {
+		return errors.New("Fetching return window failed")
+	}
+	if !utils.ReturnOpen(order, setting.ReturnWindowDays, time.Now()) {
+		return errors.New("Order is not delivered or its return window has closed")
+	}
+	err = ou.checkPassesHeld(order)
+	if err != nil {
+		return err
+	}
+	claimed, err := ou.orderRepo.UpdateStatus(order.ID, order.Status, map[string]interface{}{"status": "return"})
+	if err != nil {
+		return errors.New("order updation failed")
+	}
+	if !claimed {
+		return errors.New("Order was updated by someone else - try again")
+	}
+	returnData.Status = "Initiated"
+	returnData.OrderStatus = order.Status
+	err = ou.orderRepo.CreateReturn(&returnData)
+	if err != nil {
+		_, err = ou.orderRepo.UpdateStatus(order.ID, "return", map[string]interface{}{"status": order.Status})
+		return errors.Join(errors.New("return creation failed"), err)
+	}
+	return nil
+}
+
+// ExecuteReturnUpdate approves or rejects a return that has not been decided
+// yet. Approval voids the gift cards the order bought, cancels its tickets and
+// reverses its loyalty points; the money goes back with ExecuteRefund.
+// Rejection puts the order back the way it was.
+func (ou *OrderUsecase) ExecuteReturnUpdate(status string, returnId int) error {
+	if status != "approved" && status != "rejected" {
+		return errors.New("Return status must be approved or rejected")
+	}
+	result, err := ou.orderRepo.GetReturnByID(returnId)
+	if err != nil {
+		return errors.New("Order not found")
+	}
+	order, err := ou.orderRepo.GetByID(result.OrderId)
+	if err != nil {
+		return errors.New("Order not found")
+	}
+	if result.Status != "Initiated" {
+		return errors.New("Return has already been decided")
+	}
+	if status == "approved" {
+		err = ou.checkPassesHeld(order)
+		if err != nil {
+			return err
+		}
+		spent, err := ou.giftCardRepo.OrderCardsSpent(order.ID)
+		if err != nil {
+			return errors.New("Checking gift cards failed")
+		}
+		if spent {
+			return errors.New("Gift cards from this order have already been used")
+		}
+	}
+	claimed, err := ou.orderRepo.ClaimReturn(int(result.ID), "Initiated", status)
+	if err != nil {
+		return errors.New("return updation failed")
+	}
+	if !claimed {
+		return errors.New("Return has already been decided")
+	}
+	if status == "rejected" {
+		_, err = ou.orderRepo.UpdateStatus(order.ID, "return", map[string]interface{}{"status": result.OrderStatus})
+		if err != nil {
+			return errors.New("order updation failed")
+		}
+		return nil
+	}
+	result.Status = status
+	result.Refund = "wallet"
+	result.TotalPrice = int(order.Total) - order.GiftCardPaid
+	err = ou.orderRepo.UpdateReturn(result)
+	if err != nil {
+		return errors.New("return updation failed")
+	}
+	err = ou.giftCardRepo.VoidOrder(order.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	err = ou.orderRepo.CancelTicketPasses(order.ID, order.UserID)
+	if err != nil {
+		return errors.New("Cancelling tickets failed")
+	}
+	err = ou.freeSeats(order.ID)
+	if err != nil {
+		return err
+	}
+	return ou.reversePoints(order)
+}
+
+// reversePoints takes back what an approved return earned and gives back the
+// points spent on it.
+func (ou *OrderUsecase) reversePoints(order *entity.Order) error {
+	err := ou.clawbackRewards(order.ID, "order returned")
+	if err != nil {
+		return err
+	}
+	points, err := ou.loyaltyRepo.GetRedeemByOrder(order.ID)
+	if err != nil {
+		return errors.New("Restoring loyalty points failed")
+	}
+	if points == nil {
+		return nil
+	}
+	return ou.restorePoints(points)
+}
+
+// ExecuteRefund pays back an approved return, or cancels an order that has
+// not shipped and refunds what was paid for it. Each return and each paid
+// order is claimed before any money moves, so repeating the request cannot
+// pay twice; a failed payout releases the claim so it can be retried.
+func (ou *OrderUsecase) ExecuteRefund(orderId int) error {
+	order, err := ou.orderRepo.GetByID(orderId)
+	if err != nil {
+		return errors.New("Order not found")
+	}
+	if order.Status == "canceled" {
+		return ou.refundOrder(order)
+	}
+	if order.Status != "return" {
+		return ou.cancelOrder(order)
+	}
+	result, err := ou.orderRepo.GetReturnByOrderID(order.ID)
+	if err != nil {
+		return errors.New("Return not found")
+	}
+	switch result.Status {
+	case "completed":
+		return errors.New("Return has already been refunded")
+	case "approved":
+	default:
+		return errors.New("Only approved returns can be refunded")
+	}
+	claimed, err := ou.orderRepo.ClaimReturn(int(result.ID), "approved", "completed")
+	if err != nil {
+		return errors.New("return updation failed")
+	}
+	if !claimed {
+		return errors.New("Return has already been refunded")
+	}
+	err = ou.giftCardRepo.RefundOrder(order.ID, time.Now())
+	if err != nil {
+		err = errors.New("Refunding gift card failed")
+	} else {
+		err = ou.refundPayment(order, result.TotalPrice)
+	}
+	if err != nil {
+		_, releaseErr := ou.orderRepo.ClaimReturn(int(result.ID), "completed", "approved")
+		return errors.Join(err, releaseErr)
+	}
+	_, err = ou.orderRepo.UpdateStatus(order.ID, "return", map[string]interface{}{"payment_status": "refund"})
+	if err != nil {
+		return errors.New("order updation failed")
+	}
+	return nil
+}
+
+// refundOrder pays back a cancelled order that had been paid for.
+func (ou *OrderUsecase) refundOrder(order *entity.Order) error {
+	claimed, err := ou.orderRepo.ClaimRefund(order.ID)
+	if err != nil {
+		return errors.New("payment updation failed")
+	}
+	if !claimed {
+		return errors.New("Order has nothing left to refund")
+	}
+	err = ou.refundPayment(order, int(order.Total)-order.GiftCardPaid)
+	if err != nil {
+		return errors.Join(err, ou.orderRepo.ReleaseRefund(order.ID))
+	}
+	return nil
+}
+
+// ExecuteOrderUpdate moves an order forward to status. When the order is
+// fulfilled it earns loyalty points and settles the user's referral; both are
+// credited once per order however often the order is updated. A failure to
+// reward is reported with ErrRewardsFailed after the status has been saved.
+func (ou *OrderUsecase) ExecuteOrderUpdate(orderId int, status string) error {
+	order, err := ou.orderRepo.GetByID(orderId)
+	if err != nil {
+		return errors.New("Order not found")
+	}
+	if !utils.OrderTransitionAllowed(order, status) {
+		return fmt.Errorf("Order cannot move from %s to %s", order.Status, status)
+	}
+	from := order.Status
+	order.Status = status
+	fulfilled := utils.OrderFulfilled(order)
+	updates := map[string]interface{}{"status": status}
+	if fulfilled && order.DeliveredAt.IsZero() {
+		order.DeliveredAt = time.Now()
+		updates["delivered_at"] = order.DeliveredAt
+	}
+	claimed, err := ou.orderRepo.UpdateStatus(order.ID, from, updates)
+	if err != nil {
+		return errors.New("order updation failed")
+	}
+	if !claimed {
+		return errors.New("Order was updated by someone else - try again")
+	}
+	if !fulfilled {
+		return nil
+	}
+	err = errors.Join(ou.earnPoints(order), ou.rewardReferral(order))
+	if err != nil {
+		return errors.Join(ErrRewardsFailed, err)
+	}
+	return nil
+}
+
+// earnPoints credits points for a fulfilled order. They stay pending until
+// the return window closes.
+func (ou *OrderUsecase) earnPoints(order *entity.Order) error {
+	setting, err := ou.loyaltyRepo.GetSetting()
+	if err != nil {
+		return errors.New("Fetching loyalty settings failed")
+	}
+	if !setting.Active {
+		return nil
+	}
+	orderItems, err := ou.orderRepo.GetOrderItems(order.ID)
+	if err != nil {
+		return errors.New("Order items not found")
+	}
+	entries := utils.EarnedPoints(setting, order, orderItems, time.Now())
+	earned, err := ou.loyaltyRepo.Earn(order.ID, entries)
+	if err != nil {
+		return errors.New("Crediting loyalty points failed")
+	}
+	if !earned {
+		return nil
+	}
+	total := 0
+	for _, entry := range entries {
+		total += entry.Points
+	}
+	if total > 0 {
+		ou.notify(order.UserID, "Loyalty points earned", fmt.Sprintf("You earned %d points on order %d, usable after %d days", total, order.ID, setting.ReturnWindowDays))
+	}
+	return nil
+}
+
+// rewardReferral settles the referral of the order's user when this is their
+// first fulfilled order. Both users get wallet credit, unless the referral
+// looks like abuse, in which case it is recorded as rejected.
+func (ou *OrderUsecase) rewardReferral(order *entity.Order) error {
+	referee, err := ou.userRepo.GetByID(order.UserID)
+	if err != nil {
+		return errors.New("User not found")
+	}
+	if referee.ReferredBy == 0 {
+		return nil
+	}
+	existing, err := ou.referralRepo.GetByReferee(referee.ID)
+	if err != nil {
+		return errors.New("Fetching referral failed")
+	}
+	if existing != nil {
+		return nil
+	}
+	setting, err := ou.referralRepo.GetSetting()
+	if err != nil {
+		return errors.New("Fetching referral settings failed")
+	}
+	if !setting.Active {
+		return nil
+	}
+	referral := &entity.Referral{
+		ReferrerId:  referee.ReferredBy,
+		RefereeId:   referee.ID,
+		OrderId:     order.ID,
+		ProcessedAt: time.Now(),
+	}
+	referrer, err := ou.userRepo.GetByID(referee.ReferredBy)
+	if err != nil {
+		referrer = nil
+	}
+	reason, err := ou.referralAbuse(referrer, referee)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		referral.Status = "rejected"
+		referral.Reason = reason
+		err = ou.referralRepo.Create(referral)
+		if err != nil {
+			return errors.New("Saving referral failed")
+		}
+		return nil
+	}
+	referral.Status = "rewarded"
+	referral.ReferrerCredit = setting.ReferrerCredit
+	referral.RefereeCredit = setting.RefereeCredit
+	err = ou.referralRepo.Reward(referral)
+	if err != nil {
+		return errors.New("Crediting referral reward failed")
+	}
+	ou.notify(referrer.ID, "Referral reward", fmt.Sprintf("%s placed their first order, %d was added to your wallet", referee.FirstName, referral.ReferrerCredit))
+	ou.notify(referee.ID, "Referral reward", fmt.Sprintf("Thanks for joining through a referral, %d was added to your wallet", referral.RefereeCredit))
+	return nil
+}
+
+func (ou *OrderUsecase) referralAbuse(referrer, referee *entity.User) (string, error) {
+	if referrer == nil {
+		return utils.ReferralAbuseReason(nil, referee, nil, nil), nil
+	}
+	referrerAddresses, err := ou.userRepo.GetAddressByUserId(referrer.ID)
+	if err != nil {
+		return "", errors.New("Fetching addresses failed")
+	}
+	refereeAddresses, err := ou.userRepo.GetAddressByUserId(referee.ID)
+	if err != nil {
+		return "", errors.New("Fetching addresses failed")
+	}
+	var theirs, ours []entity.Address
+	if referrerAddresses != nil {
+		theirs = *referrerAddresses
+	}
+	if refereeAddresses != nil {
+		ours = *refereeAddresses
+	}
+	return utils.ReferralAbuseReason(referrer, referee, theirs, ours), nil
+}
+
+// clawbackRewards takes back the loyalty points and referral credit an order
+// earned before it was cancelled or returned.
+func (ou *OrderUsecase) clawbackRewards(orderId int, reason string) error {
+	err := ou.loyaltyRepo.Clawback(orderId, time.Now())
+	if err != nil {
+		return errors.New("Clawing back loyalty points failed")
+	}
+	err = ou.referralRepo.Clawback(orderId, reason)
+	if err != nil {
+		return errors.New("Clawing back referral reward failed")
+	}
+	return nil
+}
+
+func (ou *OrderUsecase) notify(userId int, title, message string) {
+	notification := &entity.Notification{UserId: userId, Title: title, Message: message}
+	if err := ou.userRepo.CreateNotification(notification); err != nil {
+		log.Println("notification failed:", err)
+	}
+}
+
 func (ou *OrderUsecase) ExecuteSalesReportByDate(startDate, endDate time.Time) (*entity.SalesReport, error) {
 	orders, err := ou.orderRepo.GetByDate(startDate, endDate)
 	if err != nil {
@@ -0,0 +1,140 @@
+package order
+
+import (
+	"errors"
+	"testing"
+	giftcardrepository "zog/repository/giftcard"
+	repository "zog/repository/order"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-playground/assert/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var orderColumns = []string{"id", "user_id", "total", "status", "payment_method", "payment_status", "gift_card_paid", "wallet_paid", "gateway_pay_id"}
+
+var returnColumns = []string{"id", "order_id", "user_id", "status", "refund", "total_price", "order_status"}
+
+func newRefundUsecase(t *testing.T) (*OrderUsecase, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &OrderUsecase{
+		orderRepo:    repository.NewOrderRepository(db),
+		giftCardRepo: giftcardrepository.NewGiftCardRepository(db),
+	}, mock
+}
+
+func expectOrder(mock sqlmock.Sqlmock, status, paymentStatus string) {
+	mock.ExpectQuery(`SELECT \* FROM "orders"`).
+		WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(7, 3, 500, status, "wallet", paymentStatus, 0, 500, ""))
+}
+
+func expectReturn(mock sqlmock.Sqlmock, status string) {
+	mock.ExpectQuery(`SELECT \* FROM "returns"`).
+		WillReturnRows(sqlmock.NewRows(returnColumns).AddRow(11, 7, 3, status, "wallet", 500, "delivered"))
+}
+
+func TestRefundApprovedReturn(t *testing.T) {
+	ou, mock := newRefundUsecase(t)
+	expectOrder(mock, "return", "successful")
+	expectReturn(mock, "approved")
+	mock.ExpectExec(`UPDATE "returns" SET "status"=.* WHERE \(id = \$3 AND status = \$4\)`).
+		WithArgs("completed", sqlmock.AnyArg(), 11, "approved").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "gift_card_txns"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(`UPDATE "users" SET "wallet"=wallet \+ \$1`).
+		WithArgs(500, sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "orders" SET "payment_status"=.* WHERE \(id = \$3 AND status = \$4\)`).
+		WithArgs("refund", sqlmock.AnyArg(), 7, "return").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := ou.ExecuteRefund(7)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
+
+func TestRefundCompletedReturnPaysNothing(t *testing.T) {
+	ou, mock := newRefundUsecase(t)
+	expectOrder(mock, "return", "refund")
+	expectReturn(mock, "completed")
+
+	err := ou.ExecuteRefund(7)
+	assert.Equal(t, "Return has already been refunded", err.Error())
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
+
+func TestRefundLosesRaceForReturn(t *testing.T) {
+	ou, mock := newRefundUsecase(t)
+	expectOrder(mock, "return", "successful")
+	expectReturn(mock, "approved")
+	mock.ExpectExec(`UPDATE "returns" SET "status"=`).
+		WithArgs("completed", sqlmock.AnyArg(), 11, "approved").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := ou.ExecuteRefund(7)
+	assert.Equal(t, "Return has already been refunded", err.Error())
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
+
+func TestRefundUndecidedOrRejectedReturn(t *testing.T) {
+	for _, status := range []string{"Initiated", "rejected"} {
+		ou, mock := newRefundUsecase(t)
+		expectOrder(mock, "return", "successful")
+		expectReturn(mock, status)
+
+		err := ou.ExecuteRefund(7)
+		assert.Equal(t, "Only approved returns can be refunded", err.Error())
+		assert.Equal(t, nil, mock.ExpectationsWereMet())
+	}
+}
+
+func TestRefundFailedPayoutReleasesClaim(t *testing.T) {
+	ou, mock := newRefundUsecase(t)
+	expectOrder(mock, "return", "successful")
+	expectReturn(mock, "approved")
+	mock.ExpectExec(`UPDATE "returns" SET "status"=`).
+		WithArgs("completed", sqlmock.AnyArg(), 11, "approved").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "gift_card_txns"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(`UPDATE "users" SET "wallet"=`).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec(`UPDATE "returns" SET "status"=`).
+		WithArgs("approved", sqlmock.AnyArg(), 11, "completed").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := ou.ExecuteRefund(7)
+	assert.Equal(t, "User wallet updation failed", err.Error())
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
+
+func TestRefundCancelledOrderOnce(t *testing.T) {
+	ou, mock := newRefundUsecase(t)
+	expectOrder(mock, "canceled", "successful")
+	mock.ExpectExec(`UPDATE "orders" SET "payment_status"=.* WHERE \(id = \$3 AND payment_status = \$4\)`).
+		WithArgs("refund", sqlmock.AnyArg(), 7, "successful").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "users" SET "wallet"=wallet \+ \$1`).
+		WithArgs(500, sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	err := ou.ExecuteRefund(7)
+	assert.Equal(t, nil, err)
+
+	expectOrder(mock, "canceled", "refund")
+	mock.ExpectExec(`UPDATE "orders" SET "payment_status"=`).
+		WithArgs("refund", sqlmock.AnyArg(), 7, "successful").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	err = ou.ExecuteRefund(7)
+	assert.Equal(t, "Order has nothing left to refund", err.Error())
+	assert.Equal(t, nil, mock.ExpectationsWereMet())
+}
@@ -2,36 +2,37 @@ package usecase
 
 import (
 	"errors"
+	"math"
+	"strings"
+	"time"
 	"zog/domain/entity"
+	"zog/domain/utils"
 	repository "zog/repository/product"
+	segmentrepository "zog/repository/segment"
 )
 
 type ProductUsecase struct {
 	productRepo *repository.ProductRepository
+	segmentRepo *segmentrepository.SegmentRepository
 }
 
-func NewProduct(productRepo *repository.ProductRepository) *ProductUsecase {
-	return &ProductUsecase{productRepo: productRepo}
+func NewProduct(productRepo *repository.ProductRepository, segmentRepo *segmentrepository.SegmentRepository) *ProductUsecase {
+	return &ProductUsecase{productRepo: productRepo, segmentRepo: segmentRepo}
 }
 
 func (pu ProductUsecase) ExecuteTicketList(page, limit int, location string) ([]entity.Ticket, error) {
 	offset := (page - 1) * limit
+	var ticketlist []entity.Ticket
+	var err error
 	if location == "" {
-		ticketlist, err := pu.productRepo.GetAllTickets(offset, limit)
-		if err != nil {
-			return nil, err
-		} else {
-			return ticketlist, nil
-		}
+		ticketlist, err = pu.productRepo.GetAllTickets(offset, limit)
 	} else {
-		ticketlist, err := pu.productRepo.GetAllTicketsByLocation(offset, limit, location)
-		if err != nil {
-			return nil, err
-		} else {
-			return ticketlist, nil
-		}
+		ticketlist, err = pu.productRepo.GetAllTicketsByLocation(offset, limit, location)
 	}
-
+	if err != nil {
+		return nil, err
+	}
+	return pu.currentPrices(ticketlist)
 }
 
 func (p *ProductUsecase) ExecuteTicketSearch(page, limit int, search string) ([]entity.Ticket, error) {
@@ -39,9 +40,42 @@ func (p *ProductUsecase) ExecuteTicketSearch(page, limit int, search string) ([]
 	ticketList, err := p.productRepo.GetAllTicketsBySearch(offset, limit, search)
 	if err != nil {
 		return nil, err
+	}
+	return p.currentPrices(ticketList)
+}
+
+// currentPrices replaces the base price of each listed ticket with what it
+// sells for right now.
+func (pu ProductUsecase) currentPrices(tickets []entity.Ticket) ([]entity.Ticket, error) {
+	for i := range tickets {
+		price, err := pu.ticketPrice(&tickets[i])
+		if err != nil {
+			return nil, errors.New("Fetching ticket price failed")
+		}
+		tickets[i].Price = price
+	}
+	return tickets, nil
+}
+
+// ticketPrice resolves the current price of a ticket from its schedules,
+// demand rules and remaining stock.
+func (pu ProductUsecase) ticketPrice(ticket *entity.Ticket) (int, error) {
+	schedules, err := pu.productRepo.GetPriceSchedules(ticket.ID)
+	if err != nil {
+		return 0, err
+	}
+	rules, err := pu.productRepo.GetDemandRules(ticket.ID)
+	if err != nil {
+		return 0, err
+	}
+	remaining := 0
+	inventory, err := pu.productRepo.GetByProductId(ticket.ID, "ticket")
+	if err != nil {
+		rules = nil
 	} else {
-		return ticketList, nil
+		remaining = inventory.Quantity
 	}
+	return utils.EffectiveTicketPrice(ticket.Price, schedules, rules, remaining, time.Now()), nil
 }
 
 func (pu ProductUsecase) ExecuteTicketDetails(id int) (*entity.Ticket, *entity.TicketDetails, error) {
@@ -54,6 +88,10 @@ func (pu ProductUsecase) ExecuteTicketDetails(id int) (*entity.Ticket, *entity.T
 	if err != nil {
 		return nil, nil, err
 	}
+	price, err := pu.ticketPrice(ticket)
+	if err == nil {
+		ticket.Price = price
+	}
 	return ticket, ticketDetails, nil
 }
 
@@ -129,6 +167,114 @@ func (dt ProductUsecase) ExecuteDeleteTicket(id int) error {
 	return nil
 }
 
+func (pu ProductUsecase) ExecuteTransferSettings(id int, disabled bool, cutoff int) error {
+	ticket, err := pu.productRepo.GetTicketByID(id)
+	if err != nil {
+		return errors.New("Ticket not found")
+	}
+	if cutoff < 0 {
+		return errors.New("Invalid transfer cutoff")
+	}
+	ticket.TransferDisabled = disabled
+	ticket.TransferCutoff = cutoff
+	err = pu.productRepo.UpdateTicket(ticket)
+	if err != nil {
+		return errors.New("Updating transfer settings failed")
+	}
+	return nil
+}
+
+func (pu ProductUsecase) ExecuteTicketPricing(ticketId int) (*entity.TicketPricing, error) {
+	ticket, err := pu.productRepo.GetTicketByID(ticketId)
+	if err != nil {
+		return nil, errors.New("Ticket not found")
+	}
+	schedules, err := pu.productRepo.GetPriceSchedules(ticketId)
+	if err != nil {
+		return nil, errors.New("Fetching price schedules failed")
+	}
+	rules, err := pu.productRepo.GetDemandRules(ticketId)
+	if err != nil {
+		return nil, errors.New("Fetching demand rules failed")
+	}
+	price, err := pu.ticketPrice(ticket)
+	if err != nil {
+		return nil, errors.New("Fetching ticket price failed")
+	}
+	return &entity.TicketPricing{
+		TicketId:     ticket.ID,
+		BasePrice:    ticket.Price,
+		CurrentPrice: price,
+		Schedules:    schedules,
+		DemandRules:  rules,
+	}, nil
+}
+
+func (pu ProductUsecase) ExecuteAddPriceSchedule(ticketId int, schedule entity.PriceSchedule) error {
+	ticket, err := pu.productRepo.GetTicketByID(ticketId)
+	if err != nil {
+		return errors.New("Ticket not found")
+	}
+	if schedule.Name == "" || schedule.Price <= 0 {
+		return errors.New("Price schedule needs a name and a price")
+	}
+	if !schedule.StartsAt.Before(schedule.EndsAt) {
+		return errors.New("Price schedule must start before it ends")
+	}
+	if schedule.StartsAt.After(ticket.Date) {
+		return errors.New("Price schedule must start before the event")
+	}
+	schedules, err := pu.productRepo.GetPriceSchedules(ticketId)
+	if err != nil {
+		return errors.New("Fetching price schedules failed")
+	}
+	for _, existing := range schedules {
+		if schedule.StartsAt.Before(existing.EndsAt) && existing.StartsAt.Before(schedule.EndsAt) {
+			return errors.New("Price schedule overlaps " + existing.Name)
+		}
+	}
+	schedule.ID = 0
+	schedule.TicketId = ticketId
+	err = pu.productRepo.CreatePriceSchedule(&schedule)
+	if err != nil {
+		return errors.New("Adding price schedule failed")
+	}
+	return nil
+}
+
+func (pu ProductUsecase) ExecuteAddDemandRule(ticketId int, rule entity.DemandRule) error {
+	_, err := pu.productRepo.GetTicketByID(ticketId)
+	if err != nil {
+		return errors.New("Ticket not found")
+	}
+	if rule.Remaining < 0 || rule.Increase <= 0 {
+		return errors.New("Demand rule needs a stock threshold and a positive increase")
+	}
+	rule.ID = 0
+	rule.TicketId = ticketId
+	err = pu.productRepo.CreateDemandRule(&rule)
+	if err != nil {
+		return errors.New("Adding demand rule failed")
+	}
+	return nil
+}
+
+func (pu ProductUsecase) ExecuteDeletePriceSchedule(id int) error {
+	err := pu.productRepo.DeletePriceSchedule(id)
+	if err != nil {
+		return errors.New("Price schedule not found")
+	}
+	return nil
+}
+
+func (pu ProductUsecase) ExecuteDeleteDemandRule(id int) error {
+	err := pu.productRepo.DeleteDemandRule(id)
+	if err != nil {
+		return errors.New("Demand rule not found")
+	}
+	return nil
+}
+
 func (pu *ProductUsecase) ExecuteApperalList(page, limit int, category string) ([]entity.Apparel, error) {
 	offset := (page - 1) * limit
 	if category == "" {
@@ -276,7 +422,27 @@ func (pu *ProductUsecase) ExecuteQuantityUpdate(inventory entity.Inventory, meth
 }
 
 func (p *ProductUsecase) ExecuteAddCoupon(coupon *entity.Coupon) error {
-	err := p.productRepo.CreateCoupon(coupon)
+	if coupon.Code == "" {
+		return errors.New("Coupon code is required")
+	}
+	if _, err := p.productRepo.GetCouponByCode(coupon.Code); err == nil {
+		return errors.New("Coupon code already exists")
+	}
+	if coupon.ValidFrom.IsZero() {
+		coupon.ValidFrom = time.Now()
+	}
+	err := validateCoupon(coupon)
+	if err != nil {
+		return err
+	}
+	err = p.checkSegment(coupon.SegmentId)
+	if err != nil {
+		return err
+	}
+	coupon.Id = 0
+	coupon.UsedCount = 0
+	coupon.Active = true
+	err = p.productRepo.CreateCoupon(coupon)
 	if err != nil {
 		return errors.New("Creating Coupon failed")
 	} else {
@@ -284,8 +450,237 @@ func (p *ProductUsecase) ExecuteAddCoupon(coupon *entity.Coupon) error {
 	}
 }
 
+func validateCoupon(coupon *entity.Coupon) error {
+	if coupon.Type != "percentage" && coupon.Type != "flat" {
+		return errors.New("Coupon type must be percentage or flat")
+	}
+	if coupon.Amount <= 0 || (coupon.Type == "percentage" && coupon.Amount > 100) {
+		return errors.New("Invalid coupon amount")
+	}
+	if coupon.UsageLimit < 0 || coupon.PerUserLimit < 0 || coupon.MinOrder < 0 || coupon.MaxDiscount < 0 {
+		return errors.New("Coupon limits cannot be negative")
+	}
+	if !coupon.ValidFrom.Before(coupon.ValidUntil) {
+		return errors.New("Coupon must be valid from a date before its expiry")
+	}
+	return nil
+}
+
+func (p *ProductUsecase) ExecuteCouponList(page, limit int, status, category, search string) ([]entity.Coupon, error) {
+	offset := (page - 1) * limit
+	coupons, err := p.productRepo.GetCoupons(offset, limit, status, category, search)
+	if err != nil {
+		return nil, errors.New("Fetching coupons failed")
+	}
+	return coupons, nil
+}
+
+// ExecuteEditCoupon updates the terms of a coupon. The code cannot change
+// since carts and usage records refer to it.
+func (p *ProductUsecase) ExecuteEditCoupon(id int, input entity.Coupon) (*entity.Coupon, error) {
+	coupon, err := p.productRepo.GetCouponByID(id)
+	if err != nil {
+		return nil, errors.New("Coupon not found")
+	}
+	if input.Code != "" && input.Code != coupon.Code {
+		return nil, errors.New("Coupon code cannot be changed")
+	}
+	if input.UsageLimit > 0 && input.UsageLimit < coupon.UsedCount {
+		return nil, errors.New("Usage limit is below the times the coupon was already used")
+	}
+	if input.ValidFrom.IsZero() {
+		input.ValidFrom = coupon.ValidFrom
+	}
+	input.Id = coupon.Id
+	input.Code = coupon.Code
+	input.UsedCount = coupon.UsedCount
+	input.Active = coupon.Active
+	input.AdminId = coupon.AdminId
+	err = validateCoupon(&input)
+	if err != nil {
+		return nil, err
+	}
+	err = p.checkSegment(input.SegmentId)
+	if err != nil {
+		return nil, err
+	}
+	err = p.productRepo.UpdateCoupon(&input)
+	if err != nil {
+		return nil, errors.New("Updating coupon failed")
+	}
+	return &input, nil
+}
+
+func (p *ProductUsecase) ExecuteToggleCoupon(id int) (*entity.Coupon, error) {
+	coupon, err := p.productRepo.GetCouponByID(id)
+	if err != nil {
+		return nil, errors.New("Coupon not found")
+	}
+	coupon.Active = !coupon.Active
+	err = p.productRepo.SetCouponActive(coupon.Id, coupon.Active)
+	if err != nil {
+		return nil, errors.New("Updating coupon failed")
+	}
+	return coupon, nil
+}
+
+func (p *ProductUsecase) ExecuteDeleteCoupon(id int) (*entity.Coupon, error) {
+	coupon, err := p.productRepo.GetCouponByID(id)
+	if err != nil {
+		return nil, errors.New("Coupon not found")
+	}
+	if coupon.UsedCount > 0 {
+		return nil, errors.New("Coupon has been used, deactivate it instead")
+	}
+	err = p.productRepo.DeleteCoupon(coupon)
+	if err != nil {
+		return nil, errors.New("Deleting coupon failed")
+	}
+	return coupon, nil
+}
+
+func (p *ProductUsecase) ExecuteCouponUsage(id int) (*entity.CouponUsage, error) {
+	coupon, err := p.productRepo.GetCouponByID(id)
+	if err != nil {
+		return nil, errors.New("Coupon not found")
+	}
+	uses, err := p.productRepo.GetCouponUsages(coupon.Code)
+	if err != nil {
+		return nil, errors.New("Fetching coupon usage failed")
+	}
+	return &entity.CouponUsage{Coupon: *coupon, Uses: uses}, nil
+}
+
+const maxCampaignCodes = 50000
+
+// ExecuteCreateCampaign saves the campaign rules and generates its codes. Codes
+// are drawn at random from the alphabet; any that clash with an existing code
+// are drawn again. Either the campaign is stored with all its codes or not at
+// all.
+func (p *ProductUsecase) ExecuteCreateCampaign(campaign *entity.CouponCampaign) error {
+	if campaign.Name == "" {
+		return errors.New("Campaign name is required")
+	}
+	if campaign.Count < 1 || campaign.Count > maxCampaignCodes {
+		return errors.New("Campaigns can have between 1 and 50000 codes")
+	}
+	if campaign.Length == 0 {
+		campaign.Length = 8
+	}
+	if campaign.Length < 4 || campaign.Length > 32 {
+		return errors.New("Code length must be between 4 and 32")
+	}
+	if campaign.Alphabet == "" {
+		campaign.Alphabet = utils.CodeAlphabet
+	}
+	campaign.Prefix = strings.TrimSpace(campaign.Prefix)
+	if len(campaign.Alphabet) < 2 {
+		return errors.New("Alphabet needs at least two characters")
+	}
+	if math.Pow(float64(len(campaign.Alphabet)), float64(campaign.Length)) < float64(campaign.Count)*100 {
+		return errors.New("Code length and alphabet are too small for this many unique codes")
+	}
+	if campaign.ValidFrom.IsZero() {
+		campaign.ValidFrom = time.Now()
+	}
+	err := validateCoupon(utils.CampaignCoupon(campaign, campaign.Prefix, false))
+	if err != nil {
+		return err
+	}
+	err = p.checkSegment(campaign.SegmentId)
+	if err != nil {
+		return err
+	}
+	campaign.ID = 0
+	campaign.Active = true
+	err = p.productRepo.CreateCampaign(campaign, func(count int) ([]string, error) {
+		batch := map[string]bool{}
+		for len(batch) < count {
+			code, err := utils.GenerateCode(campaign.Length, campaign.Alphabet)
+			if err != nil {
+				return nil, err
+			}
+			batch[campaign.Prefix+code] = true
+		}
+		candidates := make([]string, 0, len(batch))
+		for code := range batch {
+			candidates = append(candidates, code)
+		}
+		return candidates, nil
+	})
+	if err != nil {
+		return errors.New("Creating campaign failed: " + err.Error())
+	}
+	return nil
+}
+
+func (p *ProductUsecase) ExecuteCampaignList(page, limit int, status string) ([]entity.CouponCampaign, error) {
+	offset := (page - 1) * limit
+	campaigns, err := p.productRepo.GetCampaigns(offset, limit, status)
+	if err != nil {
+		return nil, errors.New("Fetching campaigns failed")
+	}
+	return campaigns, nil
+}
+
+// ExecuteDeactivateCampaign stops every code of the campaign from being
+// redeemed. Codes already used keep their orders.
+func (p *ProductUsecase) ExecuteDeactivateCampaign(id int) (*entity.CouponCampaign, error) {
+	campaign, err := p.productRepo.GetCampaignByID(id)
+	if err != nil {
+		return nil, errors.New("Campaign not found")
+	}
+	if !campaign.Active {
+		return nil, errors.New("Campaign is already inactive")
+	}
+	err = p.productRepo.SetCampaignActive(campaign.ID, false)
+	if err != nil {
+		return nil, errors.New("Deactivating campaign failed")
+	}
+	campaign.Active = false
+	return campaign, nil
+}
+
+func (p *ProductUsecase) ExecuteCampaignCodes(id int) (*entity.CouponCampaign, []entity.CampaignCode, error) {
+	campaign, err := p.productRepo.GetCampaignByID(id)
+	if err != nil {
+		return nil, nil, errors.New("Campaign not found")
+	}
+	codes, err := p.productRepo.GetCampaignCodes(campaign.ID)
+	if err != nil {
+		return nil, nil, errors.New("Fetching campaign codes failed")
+	}
+	return campaign, codes, nil
+}
+
+func (p *ProductUsecase) ExecuteCampaignStats(id int) (*entity.CampaignStats, error) {
+	campaign, err := p.productRepo.GetCampaignByID(id)
+	if err != nil {
+		return nil, errors.New("Campaign not found")
+	}
+	stats, err := p.productRepo.GetCampaignStats(campaign)
+	if err != nil {
+		return nil, errors.New("Fetching campaign stats failed")
+	}
+	return stats, nil
+}
+
 func (p *ProductUsecase) ExecuteAddOffer(offer *entity.Offer) error {
-	err := p.productRepo.CreateOffer(offer)
+	if offer.ValidFrom.IsZero() {
+		offer.ValidFrom = time.Now()
+	}
+	err := validateOffer(offer)
+	if err != nil {
+		return err
+	}
+	err = p.checkSegment(offer.SegmentId)
+	if err != nil {
+		return err
+	}
+	offer.Id = 0
+	offer.UsedCount = 0
+	offer.Active = true
+	err = p.productRepo.CreateOffer(offer)
 	if err != nil {
 		return errors.New("Creating Offer failed")
 	} else {
@@ -293,16 +688,159 @@ func (p *ProductUsecase) ExecuteAddOffer(offer *entity.Offer) error {
 	}
 }
 
-func (p *ProductUsecase) ExecuteAvailableCoupons() (*[]entity.Coupon, error) {
+// checkSegment makes sure a promotion is not limited to a segment that does
+// not exist. A segment ID of 0 means everyone.
+func (p *ProductUsecase) checkSegment(segmentId int) error {
+	if segmentId == 0 {
+		return nil
+	}
+	if _, err := p.segmentRepo.GetByID(segmentId); err != nil {
+		return errors.New("Segment not found")
+	}
+	return nil
+}
+
+func validateOffer(offer *entity.Offer) error {
+	if offer.Name == "" {
+		return errors.New("Offer name is required")
+	}
+	if offer.Type != "percentage" && offer.Type != "flat" {
+		return errors.New("Offer type must be percentage or flat")
+	}
+	if offer.Amount <= 0 || (offer.Type == "percentage" && offer.Amount > 100) {
+		return errors.New("Invalid offer amount")
+	}
+	if offer.MinPrice < 0 || offer.UsageLimit < 0 {
+		return errors.New("Offer limits cannot be negative")
+	}
+	if !offer.ValidFrom.Before(offer.ValidUntil) {
+		return errors.New("Offer must be valid from a date before its expiry")
+	}
+	return nil
+}
+
+func (p *ProductUsecase) ExecuteOfferList(page, limit int, status, category string) ([]entity.Offer, error) {
+	offset := (page - 1) * limit
+	offers, err := p.productRepo.GetOffers(offset, limit, status, category)
+	if err != nil {
+		return nil, errors.New("Fetching offers failed")
+	}
+	return offers, nil
+}
+
+func (p *ProductUsecase) ExecuteEditOffer(id int, input entity.Offer) (*entity.Offer, error) {
+	offer, err := p.productRepo.GetOfferByID(id)
+	if err != nil {
+		return nil, errors.New("Offer not found")
+	}
+	if input.ValidFrom.IsZero() {
+		input.ValidFrom = offer.ValidFrom
+	}
+	input.Id = offer.Id
+	input.UsedCount = offer.UsedCount
+	input.Active = offer.Active
+	input.AdminId = offer.AdminId
+	err = validateOffer(&input)
+	if err != nil {
+		return nil, err
+	}
+	err = p.checkSegment(input.SegmentId)
+	if err != nil {
+		return nil, err
+	}
+	err = p.productRepo.UpdateOffer(&input)
+	if err != nil {
+		return nil, errors.New("Updating offer failed")
+	}
+	return &input, nil
+}
+
+func (p *ProductUsecase) ExecuteToggleOffer(id int) (*entity.Offer, error) {
+	offer, err := p.productRepo.GetOfferByID(id)
+	if err != nil {
+		return nil, errors.New("Offer not found")
+	}
+	offer.Active = !offer.Active
+	err = p.productRepo.SetOfferActive(offer.Id, offer.Active)
+	if err != nil {
+		return nil, errors.New("Updating offer failed")
+	}
+	return offer, nil
+}
+
+func (p *ProductUsecase) ExecuteDeleteOffer(id int) error {
+	offer, err := p.productRepo.GetOfferByID(id)
+	if err != nil {
+		return errors.New("Offer not found")
+	}
+	err = p.productRepo.DeleteOffer(offer)
+	if err != nil {
+		return errors.New("Deleting offer failed")
+	}
+	return nil
+}
+
+func (p *ProductUsecase) ExecutePromotionRule() (*entity.PromotionRule, error) {
+	rule, err := p.productRepo.GetPromotionRule()
+	if err != nil {
+		return nil, errors.New("Fetching promotion rules failed")
+	}
+	return rule, nil
+}
+
+func (p *ProductUsecase) ExecuteUpdatePromotionRule(rule entity.PromotionRule) (*entity.PromotionRule, error) {
+	if rule.MaxDiscount < 0 {
+		return nil, errors.New("Maximum discount cannot be negative")
+	}
+	if rule.MaxDiscountPercent < 0 || rule.MaxDiscountPercent > 100 {
+		return nil, errors.New("Maximum discount percent must be between 0 and 100")
+	}
+	err := p.productRepo.SavePromotionRule(&rule)
+	if err != nil {
+		return nil, errors.New("Saving promotion rules failed")
+	}
+	return &rule, nil
+}
+
+// ExecuteAvailableCoupons lists the running coupons the user can still use,
+// leaving out those limited to a segment the user is not in.
+func (p *ProductUsecase) ExecuteAvailableCoupons(userId int) (*[]entity.Coupon, error) {
 	coupons, err := p.productRepo.GetAllCoupons()
 	if err != nil {
 		return nil, errors.New(err.Error())
 	}
+	segments, err := p.userSegments(userId)
+	if err != nil {
+		return nil, errors.New("Checking coupon eligibility failed")
+	}
 	availableCoupons := []entity.Coupon{}
+	now := time.Now()
 	for _, coupon := range *coupons {
-		if coupon.UsageLimit != coupon.UsedCount {
+		if coupon.ValidFrom.After(now) {
+			continue
+		}
+		if coupon.SegmentId != 0 && !segments[coupon.SegmentId] {
+			continue
+		}
+		if coupon.UsageLimit == 0 || coupon.UsedCount < coupon.UsageLimit {
 			availableCoupons = append(availableCoupons, coupon)
 		}
 	}
 	return &availableCoupons, nil
 }
+
+// userSegments returns the IDs of the segments the user currently belongs to.
+func (p *ProductUsecase) userSegments(userId int) (map[int]bool, error) {
+	segments, err := p.segmentRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return map[int]bool{}, nil
+	}
+	profile, err := p.segmentRepo.GetCustomerProfile(userId)
+	if err != nil {
+		return nil, err
+	}
+	return utils.MatchSegments(segments, profile, time.Now()), nil
+}
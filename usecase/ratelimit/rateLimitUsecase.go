@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"log"
+	"strconv"
+	"time"
+	"zog/config"
+	repository "zog/repository/ratelimit"
+)
+
+// maxLockoutSteps bounds the doubling so the shift cannot overflow.
+const maxLockoutSteps = 20
+
+// LimitError is returned when a limit is hit. RetryAfter says how long until
+// the caller may try again.
+type LimitError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (le *LimitError) Error() string {
+	return le.Message + ", retry after " + strconv.Itoa(le.Seconds()) + "s"
+}
+
+// Seconds is RetryAfter rounded up to whole seconds, as the Retry-After
+// header wants it.
+func (le *LimitError) Seconds() int {
+	seconds := int((le.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+type RateLimitUsecase struct {
+	store repository.Store
+	cfg   config.RateLimit
+}
+
+func NewRateLimit(store repository.Store, cfg config.RateLimit) *RateLimitUsecase {
+	return &RateLimitUsecase{store: store, cfg: cfg}
+}
+
+func limited(message string, resetAt, now time.Time) error {
+	return &LimitError{Message: message, RetryAfter: resetAt.Sub(now)}
+}
+
+// ExecuteAllow counts one request for key in scope and refuses it once more
+// than limit have been made within window.
+func (ru *RateLimitUsecase) ExecuteAllow(scope, key string, limit int, window time.Duration) error {
+	now := time.Now()
+	count, resetAt, err := ru.store.Hit("ip:"+scope+":"+key, window, now)
+	if err != nil {
+		// A broken store must not lock everyone out.
+		log.Println(err)
+		return nil
+	}
+	if count > limit {
+		return limited("Too many requests", resetAt, now)
+	}
+	return nil
+}
+
+// ExecuteAllowIP applies the configured per IP limit.
+func (ru *RateLimitUsecase) ExecuteAllowIP(scope, ip string) error {
+	return ru.ExecuteAllow(scope, ip, ru.cfg.IPLimit, ru.cfg.IPWindow)
+}
+
+// ExecuteOtpSend is called before an OTP is sent to phone. It enforces the
+// cooldown between sends and the daily cap. Both are decided on the count
+// the hit itself returns, so parallel requests cannot all get through; a
+// send refused by the cooldown is not counted against the day.
+func (ru *RateLimitUsecase) ExecuteOtpSend(phone string) error {
+	now := time.Now()
+	count, resetAt, err := ru.store.Hit("otpcool:"+phone, ru.cfg.OtpCooldown, now)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if count > 1 {
+		return limited("Otp sent recently", resetAt, now)
+	}
+	count, resetAt, err = ru.store.Hit("otpday:"+phone, 24*time.Hour, now)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if count > ru.cfg.OtpDailyCap {
+		return limited("Daily otp limit reached", resetAt, now)
+	}
+	return nil
+}
+
+// ExecuteCheckLock refuses an account that is locked out after failed
+// attempts.
+func (ru *RateLimitUsecase) ExecuteCheckLock(account string) error {
+	now := time.Now()
+	count, resetAt, err := ru.store.Get("lock:"+account, now)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if count > 0 {
+		return limited("Too many failed attempts", resetAt, now)
+	}
+	return nil
+}
+
+// ExecuteFailed records a failed attempt on account. From MaxFailures on,
+// every failure locks the account for Lockout, doubled for each failure past
+// MaxFailures and capped at MaxLockout. The returned error reports the lock.
+func (ru *RateLimitUsecase) ExecuteFailed(account string) error {
+	now := time.Now()
+	failures, _, err := ru.store.Hit("fail:"+account, ru.cfg.MaxLockout, now)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if failures < ru.cfg.MaxFailures {
+		return nil
+	}
+	steps := failures - ru.cfg.MaxFailures
+	if steps > maxLockoutSteps {
+		steps = maxLockoutSteps
+	}
+	lockout := ru.cfg.Lockout << steps
+	if lockout > ru.cfg.MaxLockout {
+		lockout = ru.cfg.MaxLockout
+	}
+	lockKey := "lock:" + account
+	if err := ru.store.Clear(lockKey); err != nil {
+		log.Println(err)
+		return nil
+	}
+	_, resetAt, err := ru.store.Hit(lockKey, lockout, now)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return limited("Too many failed attempts", resetAt, now)
+}
+
+// ExecuteSucceeded forgets the failures of account after a good attempt.
+func (ru *RateLimitUsecase) ExecuteSucceeded(account string) {
+	if err := ru.store.Clear("fail:" + account); err != nil {
+		log.Println(err)
+	}
+	if err := ru.store.Clear("lock:" + account); err != nil {
+		log.Println(err)
+	}
+}
+
+// StartSweeper periodically drops counters that have reset.
+func (ru *RateLimitUsecase) StartSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if err := ru.store.Sweep(time.Now()); err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+	"zog/config"
+	repository "zog/repository/ratelimit"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func newRateLimit() *RateLimitUsecase {
+	return NewRateLimit(repository.NewMemoryStore(), config.RateLimit{
+		OtpCooldown: time.Minute,
+		OtpDailyCap: 2,
+		MaxFailures: 3,
+		Lockout:     time.Minute,
+		MaxLockout:  10 * time.Minute,
+	})
+}
+
+func lockout(t *testing.T, err error) time.Duration {
+	limit, ok := err.(*LimitError)
+	if !ok {
+		t.Fatalf("expected a lockout, got %v", err)
+	}
+	return limit.RetryAfter
+}
+
+func TestFailedLockoutDoubles(t *testing.T) {
+	ru := newRateLimit()
+	account := "otp:phone:9000000000"
+
+	assert.Equal(t, nil, ru.ExecuteFailed(account))
+	assert.Equal(t, nil, ru.ExecuteFailed(account))
+	assert.Equal(t, nil, ru.ExecuteCheckLock(account))
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute} {
+		assert.Equal(t, want, lockout(t, ru.ExecuteFailed(account)))
+		assert.NotEqual(t, nil, ru.ExecuteCheckLock(account))
+	}
+}
+
+func TestSucceededClearsLockout(t *testing.T) {
+	ru := newRateLimit()
+	account := "otp:admin:9000000000"
+	for i := 0; i < 4; i++ {
+		ru.ExecuteFailed(account)
+	}
+	assert.NotEqual(t, nil, ru.ExecuteCheckLock(account))
+
+	ru.ExecuteSucceeded(account)
+	assert.Equal(t, nil, ru.ExecuteCheckLock(account))
+	assert.Equal(t, nil, ru.ExecuteFailed(account))
+}
+
+func TestOtpSendCooldown(t *testing.T) {
+	ru := newRateLimit()
+	assert.Equal(t, nil, ru.ExecuteOtpSend("9000000000"))
+	assert.Equal(t, "Otp sent recently", ru.ExecuteOtpSend("9000000000").(*LimitError).Message)
+	assert.Equal(t, nil, ru.ExecuteOtpSend("9000000001"))
+}
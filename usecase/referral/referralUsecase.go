@@ -0,0 +1,79 @@
+package referral
+
+import (
+	"errors"
+	"zog/domain/entity"
+	repository "zog/repository/referral"
+	userrepository "zog/repository/user"
+)
+
+type ReferralUsecase struct {
+	referralRepo *repository.ReferralRepository
+	userRepo     *userrepository.UserRepository
+}
+
+func NewReferral(referralRepo *repository.ReferralRepository, userRepo *userrepository.UserRepository) *ReferralUsecase {
+	return &ReferralUsecase{referralRepo: referralRepo, userRepo: userRepo}
+}
+
+// ExecuteMyReferrals shows the user's referral code and what it has earned.
+// Users who signed up before referrals existed get a code on first visit.
+func (ru *ReferralUsecase) ExecuteMyReferrals(userId int) (*entity.ReferralSummary, error) {
+	user, err := ru.userRepo.GetByID(userId)
+	if err != nil {
+		return nil, errors.New("User not found")
+	}
+	if user.ReferralCode == "" {
+		user.ReferralCode, err = ru.userRepo.NewReferralCode()
+		if err != nil {
+			return nil, err
+		}
+		err = ru.userRepo.Update(user)
+		if err != nil {
+			return nil, errors.New("Saving referral code failed")
+		}
+	}
+	referrals, err := ru.referralRepo.GetByReferrer(userId)
+	if err != nil {
+		return nil, errors.New("Fetching referrals failed")
+	}
+	invited, err := ru.referralRepo.CountInvited(userId)
+	if err != nil {
+		return nil, errors.New("Fetching referrals failed")
+	}
+	summary := &entity.ReferralSummary{Code: user.ReferralCode, Invited: invited, Referrals: referrals}
+	for _, referral := range referrals {
+		if referral.Status == "rewarded" {
+			summary.Earned += referral.ReferrerCredit
+		}
+	}
+	return summary, nil
+}
+
+func (ru *ReferralUsecase) ExecuteReferralReport(page, limit int, status string) (*entity.ReferralReport, error) {
+	offset := (page - 1) * limit
+	report, err := ru.referralRepo.GetReport(offset, limit, status)
+	if err != nil {
+		return nil, errors.New("Fetching referral report failed")
+	}
+	return report, nil
+}
+
+func (ru *ReferralUsecase) ExecuteSetting() (*entity.ReferralSetting, error) {
+	setting, err := ru.referralRepo.GetSetting()
+	if err != nil {
+		return nil, errors.New("Fetching referral settings failed")
+	}
+	return setting, nil
+}
+
+func (ru *ReferralUsecase) ExecuteUpdateSetting(setting entity.ReferralSetting) (*entity.ReferralSetting, error) {
+	if setting.ReferrerCredit < 0 || setting.RefereeCredit < 0 {
+		return nil, errors.New("Referral credit cannot be negative")
+	}
+	err := ru.referralRepo.SaveSetting(&setting)
+	if err != nil {
+		return nil, errors.New("Saving referral settings failed")
+	}
+	return &setting, nil
+}
@@ -0,0 +1,192 @@
+package seat
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"zog/domain/entity"
+	cartrepository "zog/repository/cart"
+	productrepository "zog/repository/product"
+	repository "zog/repository/seat"
+)
+
+type SeatUsecase struct {
+	seatRepo    *repository.SeatRepository
+	cartRepo    *cartrepository.CartRepository
+	productRepo *productrepository.ProductRepository
+	holdWindow  time.Duration
+}
+
+func NewSeat(seatRepo *repository.SeatRepository, cartRepo *cartrepository.CartRepository, productRepo *productrepository.ProductRepository, holdWindow time.Duration) *SeatUsecase {
+	return &SeatUsecase{seatRepo: seatRepo, cartRepo: cartRepo, productRepo: productRepo, holdWindow: holdWindow}
+}
+
+func (su *SeatUsecase) ExecuteCreateSeatMap(ticketId int, input entity.SeatMapInput) (int, error) {
+	_, err := su.productRepo.GetTicketByID(ticketId)
+	if err != nil {
+		return 0, errors.New("Ticket not found")
+	}
+	exists, err := su.seatRepo.HasSeatMap(ticketId)
+	if err != nil {
+		return 0, errors.New("error with server")
+	}
+	if exists {
+		return 0, errors.New("Seat map already exists for this event")
+	}
+	if len(input.Sections) == 0 {
+		return 0, errors.New("Seat map needs at least one section")
+	}
+	for _, section := range input.Sections {
+		if section.Name == "" || section.Price <= 0 {
+			return 0, errors.New("Every section needs a name and a price")
+		}
+		for _, row := range section.Rows {
+			if row.Row == "" || row.Seats < 1 {
+				return 0, errors.New("Every row needs a label and at least one seat")
+			}
+		}
+	}
+	total, err := su.seatRepo.CreateSeatMap(ticketId, input)
+	if err != nil {
+		return 0, errors.New("Creating seat map failed")
+	}
+	inventory, err := su.productRepo.GetByProductId(ticketId, "ticket")
+	if err == nil {
+		inventory.Quantity = total
+		su.productRepo.UpdateInventory(inventory)
+	}
+	return total, nil
+}
+
+func (su *SeatUsecase) ExecuteSeatMap(ticketId, userId int) ([]entity.SectionAvailability, error) {
+	sections, err := su.seatRepo.GetSections(ticketId)
+	if err != nil {
+		return nil, errors.New("Fetching seat map failed")
+	}
+	if len(sections) == 0 {
+		return nil, errors.New("This event has no reserved seating")
+	}
+	seats, err := su.seatRepo.GetSeats(ticketId)
+	if err != nil {
+		return nil, errors.New("Fetching seat map failed")
+	}
+	now := time.Now()
+	seatMap := make([]entity.SectionAvailability, len(sections))
+	index := make(map[int]int)
+	for i, section := range sections {
+		seatMap[i] = entity.SectionAvailability{
+			SectionId: section.ID,
+			Name:      section.Name,
+			Price:     section.Price,
+		}
+		index[section.ID] = i
+	}
+	for _, seat := range seats {
+		status := "available"
+		if seat.Status == "sold" {
+			status = "sold"
+		} else if seat.HeldBy != 0 && seat.HeldUntil.After(now) {
+			status = "held"
+			if seat.HeldBy == userId {
+				status = "yours"
+			}
+		}
+		i := index[seat.SectionId]
+		if status == "available" {
+			seatMap[i].Available++
+		}
+		seatMap[i].Seats = append(seatMap[i].Seats, entity.SeatStatus{
+			SeatId: seat.ID,
+			Row:    seat.RowLabel,
+			Number: seat.Number,
+			Status: status,
+		})
+	}
+	return seatMap, nil
+}
+
+func (su *SeatUsecase) ExecuteAddSeatToCart(userId, seatId int) error {
+	seat, err := su.seatRepo.GetSeatByID(seatId)
+	if err != nil {
+		return err
+	}
+	ticket, err := su.productRepo.GetTicketByID(seat.TicketId)
+	if err != nil || ticket.Removed {
+		return errors.New("Ticket not found")
+	}
+	section, err := su.seatRepo.GetSectionByID(seat.SectionId)
+	if err != nil {
+		return err
+	}
+	held, err := su.seatRepo.HoldSeat(seat.ID, userId, time.Now().Add(su.holdWindow))
+	if err != nil {
+		return errors.New("Holding seat failed")
+	}
+	if !held {
+		return errors.New("Seat is not available")
+	}
+	userCart, err := su.cartRepo.GetByUserID(userId)
+	if err != nil {
+		userCart, err = su.cartRepo.Create(userId)
+		if err != nil {
+			return errors.New("Failed to create user cart")
+		}
+	}
+	existing, err := su.cartRepo.GetBySeat(seat.ID, int(userCart.ID))
+	if err != nil {
+		return errors.New("error with server")
+	}
+	if existing != nil {
+		return nil
+	}
+	cartItem := &entity.CartItem{
+		CartId:      int(userCart.ID),
+		ProductId:   ticket.ID,
+		Category:    "ticket",
+		Quantity:    1,
+		ProductName: fmt.Sprintf("%s - %s %s%d", ticket.Name, section.Name, seat.RowLabel, seat.Number),
+		Price:       float64(section.Price),
+		SeatId:      seat.ID,
+	}
+	err = su.cartRepo.CreateCartItem(cartItem)
+	if err != nil {
+		su.seatRepo.ReleaseSeat(seat.ID, userId)
+		return errors.New("Adding seat to cart failed")
+	}
+	userCart.TotalPrice += cartItem.Price
+	userCart.TicketQuantity += 1
+	err = su.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return errors.New("Cart price updation failed")
+	}
+	return nil
+}
+
+func (su *SeatUsecase) ExecuteRemoveSeatFromCart(userId, seatId int) error {
+	userCart, err := su.cartRepo.GetByUserID(userId)
+	if err != nil {
+		return errors.New("Failed to find user cart")
+	}
+	cartItem, err := su.cartRepo.GetBySeat(seatId, int(userCart.ID))
+	if err != nil {
+		return errors.New("error with server")
+	}
+	if cartItem == nil {
+		return errors.New("Seat not found in cart")
+	}
+	err = su.cartRepo.RemoveCartItem(cartItem)
+	if err != nil {
+		return errors.New("Removing seat from cart failed")
+	}
+	err = su.seatRepo.ReleaseSeat(seatId, userId)
+	if err != nil {
+		return errors.New("Releasing seat failed")
+	}
+	userCart.TotalPrice -= cartItem.Price
+	userCart.TicketQuantity -= 1
+	err = su.cartRepo.UpdateCart(userCart)
+	if err != nil {
+		return errors.New("Remove from cart failed")
+	}
+	return nil
+}
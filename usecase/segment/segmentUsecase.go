@@ -0,0 +1,77 @@
+package segment
+
+import (
+	"errors"
+	"zog/domain/entity"
+	productrepository "zog/repository/product"
+	repository "zog/repository/segment"
+)
+
+type SegmentUsecase struct {
+	segmentRepo *repository.SegmentRepository
+	productRepo *productrepository.ProductRepository
+}
+
+func NewSegment(segmentRepo *repository.SegmentRepository, productRepo *productrepository.ProductRepository) *SegmentUsecase {
+	return &SegmentUsecase{segmentRepo: segmentRepo, productRepo: productRepo}
+}
+
+func (su *SegmentUsecase) ExecuteCreateSegment(segment *entity.Segment) error {
+	if segment.Name == "" {
+		return errors.New("Segment name is required")
+	}
+	if segment.Days < 0 {
+		return errors.New("Days cannot be negative")
+	}
+	switch segment.Rule {
+	case "new", "lapsed":
+	case "event":
+		ticket, err := su.productRepo.GetTicketByID(segment.TicketId)
+		if err != nil || ticket.Removed {
+			return errors.New("Ticket not found")
+		}
+	case "wallet":
+		if segment.WalletMin < 0 || segment.WalletMax < 0 {
+			return errors.New("Wallet range cannot be negative")
+		}
+		if segment.WalletMax != 0 && segment.WalletMax < segment.WalletMin {
+			return errors.New("Wallet maximum is below the minimum")
+		}
+	default:
+		return errors.New("Segment rule must be new, lapsed, event or wallet")
+	}
+	segment.ID = 0
+	err := su.segmentRepo.Create(segment)
+	if err != nil {
+		return errors.New("Creating segment failed")
+	}
+	return nil
+}
+
+func (su *SegmentUsecase) ExecuteSegmentList() ([]entity.Segment, error) {
+	segments, err := su.segmentRepo.GetAll()
+	if err != nil {
+		return nil, errors.New("Fetching segments failed")
+	}
+	return segments, nil
+}
+
+// ExecuteDeleteSegment removes a segment that no promotion is limited to.
+func (su *SegmentUsecase) ExecuteDeleteSegment(id int) error {
+	segment, err := su.segmentRepo.GetByID(id)
+	if err != nil {
+		return errors.New("Segment not found")
+	}
+	count, err := su.segmentRepo.CountPromotions(segment.ID)
+	if err != nil {
+		return errors.New("Checking segment usage failed")
+	}
+	if count > 0 {
+		return errors.New("Segment is used by coupons or offers")
+	}
+	err = su.segmentRepo.Delete(segment)
+	if err != nil {
+		return errors.New("Deleting segment failed")
+	}
+	return nil
+}
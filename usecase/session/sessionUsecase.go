@@ -0,0 +1,123 @@
+package session
+
+import (
+	"errors"
+	"log"
+	"time"
+	"zog/config"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	repository "zog/repository/session"
+)
+
+const refreshTokenSize = 32
+
+type SessionUsecase struct {
+	sessionRepo *repository.SessionRepository
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+func NewSession(sessionRepo *repository.SessionRepository, cfg config.JWT) *SessionUsecase {
+	return &SessionUsecase{sessionRepo: sessionRepo, accessTTL: cfg.AccessTTL, refreshTTL: cfg.RefreshTTL}
+}
+
+// ExecuteIssueRefresh starts a new session family and returns its first
+// refresh token.
+func (su *SessionUsecase) ExecuteIssueRefresh(subjectId int, role, phone string) (string, error) {
+	token, err := utils.RandomToken(refreshTokenSize)
+	if err != nil {
+		return "", err
+	}
+	family, err := utils.RandomToken(refreshTokenSize / 2)
+	if err != nil {
+		return "", err
+	}
+	err = su.sessionRepo.CreateRefresh(&entity.RefreshToken{
+		TokenHash: utils.HashToken(token),
+		Family:    family,
+		SubjectId: subjectId,
+		Role:      role,
+		Phone:     phone,
+		ExpiresAt: time.Now().Add(su.refreshTTL),
+	})
+	if err != nil {
+		return "", errors.New("Saving refresh token failed")
+	}
+	return token, nil
+}
+
+// ExecuteRotate exchanges a refresh token for a new one and returns whose
+// session it is.
+func (su *SessionUsecase) ExecuteRotate(token, role string) (*entity.RefreshToken, string, error) {
+	if token == "" {
+		return nil, "", errors.New("Refresh token not found")
+	}
+	next, err := utils.RandomToken(refreshTokenSize)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	current, err := su.sessionRepo.Rotate(utils.HashToken(token), role, &entity.RefreshToken{
+		TokenHash: utils.HashToken(next),
+		ExpiresAt: now.Add(su.refreshTTL),
+	}, now)
+	if err != nil {
+		return nil, "", err
+	}
+	return current, next, nil
+}
+
+// ExecuteLogout revokes the access token with the given jti until it expires,
+// and the refresh token if there is one.
+func (su *SessionUsecase) ExecuteLogout(jti string, subjectId int, role string, expiresAt time.Time, refreshToken string) error {
+	if jti != "" {
+		err := su.sessionRepo.RevokeAccess(&entity.TokenRevocation{
+			Jti:       jti,
+			SubjectId: subjectId,
+			Role:      role,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return errors.New("Revoking access token failed")
+		}
+	}
+	if refreshToken != "" {
+		err := su.sessionRepo.RevokeRefresh(utils.HashToken(refreshToken))
+		if err != nil {
+			return errors.New("Revoking refresh token failed")
+		}
+	}
+	return nil
+}
+
+// ExecuteLogoutEverywhere ends every session of the subject. Access tokens
+// carry their issue time in whole seconds, so the cutoff also covers tokens
+// issued in the same second.
+func (su *SessionUsecase) ExecuteLogoutEverywhere(subjectId int, role string) error {
+	now := time.Now().Truncate(time.Second)
+	err := su.sessionRepo.RevokeSubject(&entity.TokenRevocation{
+		SubjectId:    subjectId,
+		Role:         role,
+		IssuedBefore: now,
+		ExpiresAt:    now.Add(su.accessTTL + time.Second),
+	})
+	if err != nil {
+		return errors.New("Ending sessions failed")
+	}
+	return nil
+}
+
+func (su *SessionUsecase) ExecuteIsRevoked(jti string, subjectId int, role string, issuedAt time.Time) (bool, error) {
+	return su.sessionRepo.IsRevoked(jti, subjectId, role, issuedAt, time.Now())
+}
+
+// StartSweeper periodically drops expired refresh tokens and revocations.
+func (su *SessionUsecase) StartSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if err := su.sessionRepo.DeleteExpired(time.Now()); err != nil {
+			log.Println(err)
+		}
+	}
+}
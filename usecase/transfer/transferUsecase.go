@@ -0,0 +1,168 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	productrepository "zog/repository/product"
+	repository "zog/repository/transfer"
+	userrepository "zog/repository/user"
+)
+
+type TransferUsecase struct {
+	transferRepo *repository.TransferRepository
+	productRepo  *productrepository.ProductRepository
+	userRepo     *userrepository.UserRepository
+}
+
+func NewTransfer(transferRepo *repository.TransferRepository, productRepo *productrepository.ProductRepository, userRepo *userrepository.UserRepository) *TransferUsecase {
+	return &TransferUsecase{transferRepo: transferRepo, productRepo: productRepo, userRepo: userRepo}
+}
+
+func (tu *TransferUsecase) ExecuteMyTickets(userId int) ([]entity.TicketPass, error) {
+	passes, err := tu.transferRepo.GetPassesByOwner(userId)
+	if err != nil {
+		return nil, errors.New("Fetching tickets failed")
+	}
+	return passes, nil
+}
+
+func (tu *TransferUsecase) ExecuteTransferTicket(userId, passId int, recipient string) (*entity.TicketTransfer, error) {
+	pass, err := tu.transferRepo.GetPassByID(passId)
+	if err != nil {
+		return nil, err
+	}
+	if pass.OwnerId != userId || pass.Status != "active" {
+		return nil, errors.New("Ticket not found")
+	}
+	err = tu.checkTransferable(pass.TicketId)
+	if err != nil {
+		return nil, err
+	}
+	var toUser *entity.User
+	if strings.Contains(recipient, "@") {
+		toUser, err = tu.userRepo.GetByEmail(recipient)
+	} else {
+		toUser, err = tu.userRepo.GetByPhone(recipient)
+	}
+	if err != nil {
+		return nil, errors.New("error with server")
+	}
+	if toUser == nil {
+		return nil, errors.New("Recipient is not a registered user")
+	}
+	if toUser.ID == userId {
+		return nil, errors.New("Cannot transfer a ticket to yourself")
+	}
+	pending, err := tu.transferRepo.GetPendingTransfer(pass.ID)
+	if err != nil {
+		return nil, errors.New("error with server")
+	}
+	if pending != nil {
+		return nil, errors.New("Ticket already has a pending transfer")
+	}
+	transfer := &entity.TicketTransfer{
+		TicketPassId: pass.ID,
+		TicketId:     pass.TicketId,
+		FromUserId:   userId,
+		ToUserId:     toUser.ID,
+		Status:       "pending",
+		OldCode:      pass.Code,
+		RequestedAt:  time.Now(),
+	}
+	err = tu.transferRepo.CreateTransfer(transfer)
+	if err != nil {
+		return nil, errors.New("Creating transfer failed")
+	}
+	tu.notify(toUser.ID, "Ticket transfer", fmt.Sprintf("A ticket for event %d has been sent to you, accept transfer %d to receive it", pass.TicketId, transfer.ID))
+	return transfer, nil
+}
+
+func (tu *TransferUsecase) ExecuteAcceptTransfer(userId, transferId int) (*entity.TicketPass, error) {
+	transfer, err := tu.transferRepo.GetTransferByID(transferId)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.ToUserId != userId || transfer.Status != "pending" {
+		return nil, errors.New("Transfer not found")
+	}
+	err = tu.checkTransferable(transfer.TicketId)
+	if err != nil {
+		return nil, err
+	}
+	newCode, err := utils.GenerateCode(12, utils.CodeAlphabet)
+	if err != nil {
+		return nil, err
+	}
+	transfer.Status = "accepted"
+	transfer.NewCode = newCode
+	transfer.CompletedAt = time.Now()
+	err = tu.transferRepo.CompleteTransfer(transfer)
+	if err != nil {
+		return nil, err
+	}
+	tu.notify(transfer.FromUserId, "Ticket transferred", fmt.Sprintf("Your ticket for event %d was accepted by the recipient, the old code is no longer valid", transfer.TicketId))
+	return tu.transferRepo.GetPassByID(transfer.TicketPassId)
+}
+
+func (tu *TransferUsecase) ExecuteRejectTransfer(userId, transferId int) error {
+	transfer, err := tu.transferRepo.GetTransferByID(transferId)
+	if err != nil {
+		return err
+	}
+	if transfer.Status != "pending" {
+		return errors.New("Transfer not found")
+	}
+	if transfer.ToUserId == userId {
+		transfer.Status = "rejected"
+		tu.notify(transfer.FromUserId, "Ticket transfer rejected", fmt.Sprintf("Your ticket for event %d was not accepted", transfer.TicketId))
+	} else if transfer.FromUserId == userId {
+		transfer.Status = "cancelled"
+	} else {
+		return errors.New("Transfer not found")
+	}
+	transfer.CompletedAt = time.Now()
+	err = tu.transferRepo.UpdateTransfer(transfer)
+	if err != nil {
+		return errors.New("Updating transfer failed")
+	}
+	return nil
+}
+
+func (tu *TransferUsecase) ExecuteTransferHistory(userId int) ([]entity.TicketTransfer, error) {
+	transfers, err := tu.transferRepo.GetTransfersByUser(userId)
+	if err != nil {
+		return nil, errors.New("Fetching transfers failed")
+	}
+	return transfers, nil
+}
+
+func (tu *TransferUsecase) checkTransferable(ticketId int) error {
+	ticket, err := tu.productRepo.GetTicketByID(ticketId)
+	if err != nil {
+		return errors.New("Ticket not found")
+	}
+	if ticket.TransferDisabled {
+		return errors.New("Transfers are disabled for this event")
+	}
+	cutoff := ticket.Date.Add(-time.Duration(ticket.TransferCutoff) * time.Hour)
+	if time.Now().After(cutoff) {
+		return errors.New("Transfer window for this event has closed")
+	}
+	return nil
+}
+
+func (tu *TransferUsecase) notify(userId int, title, message string) {
+	notification := &entity.Notification{
+		UserId:  userId,
+		Title:   title,
+		Message: message,
+	}
+	if err := tu.userRepo.CreateNotification(notification); err != nil {
+		log.Println("notification failed:", err)
+	}
+}
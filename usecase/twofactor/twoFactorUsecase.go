@@ -0,0 +1,355 @@
+package twofactor
+
+import (
+	"errors"
+	"log"
+	"time"
+	"zog/config"
+	"zog/domain/entity"
+	"zog/domain/utils"
+	adminrepository "zog/repository/admin"
+	repository "zog/repository/twofactor"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	challengeTTL      = 5 * time.Minute
+	challengeSize     = 32
+	challengeAttempts = 5
+	recoveryCodeCount = 10
+	qrSize            = 256
+)
+
+type TwoFactorUsecase struct {
+	twoFactorRepo *repository.TwoFactorRepository
+	adminRepo     *adminrepository.AdminRepository
+	issuer        string
+	required      map[string]bool
+}
+
+func NewTwoFactor(twoFactorRepo *repository.TwoFactorRepository, adminRepo *adminrepository.AdminRepository, cfg config.TwoFactor) *TwoFactorUsecase {
+	required := map[string]bool{}
+	for _, role := range cfg.Required {
+		required[role] = true
+	}
+	return &TwoFactorUsecase{twoFactorRepo: twoFactorRepo, adminRepo: adminRepo, issuer: cfg.Issuer, required: required}
+}
+
+// ExecuteRequired reports whether the admin must give a TOTP code to log in:
+// they turned it on, or their role demands it.
+func (tu *TwoFactorUsecase) ExecuteRequired(admin *entity.Admin) bool {
+	return admin.TotpEnabled || tu.required[admin.Role]
+}
+
+// ExecuteChallenge is called once the admin's password or OTP was right. The
+// challenge it returns is exchanged for a session with a TOTP code; an admin
+// who has yet to enrol gets the enrolment along with it and confirms it with
+// their first code.
+func (tu *TwoFactorUsecase) ExecuteChallenge(admin *entity.Admin) (*entity.TwoFactorLogin, error) {
+	login := &entity.TwoFactorLogin{ExpiresIn: int(challengeTTL / time.Second)}
+	if !admin.TotpEnabled {
+		enrolment, err := tu.enrol(admin)
+		if err != nil {
+			return nil, err
+		}
+		login.Enrolment = enrolment
+	}
+	token, err := utils.RandomToken(challengeSize)
+	if err != nil {
+		return nil, err
+	}
+	err = tu.twoFactorRepo.CreateChallenge(&entity.TwoFactorChallenge{
+		TokenHash: utils.HashToken(token),
+		AdminId:   admin.ID,
+		ExpiresAt: time.Now().Add(challengeTTL),
+	})
+	if err != nil {
+		return nil, errors.New("Creating two-factor challenge failed")
+	}
+	login.Challenge = token
+	return login, nil
+}
+
+// ExecuteChallengeAdmin returns the admin an open challenge belongs to.
+func (tu *TwoFactorUsecase) ExecuteChallengeAdmin(token string) (*entity.Admin, *entity.TwoFactorChallenge, error) {
+	challenge, err := tu.twoFactorRepo.GetChallenge(utils.HashToken(token), time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge == nil {
+		return nil, nil, errors.New("Invalid or expired two-factor challenge")
+	}
+	admin, err := tu.admin(challenge.AdminId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !admin.Active {
+		return nil, nil, errors.New("admin account is deactivated")
+	}
+	return admin, challenge, nil
+}
+
+// ExecuteVerify finishes a login with the TOTP code or a recovery code. For
+// an admin who was enrolling it also turns TOTP on and returns their new
+// recovery codes.
+func (tu *TwoFactorUsecase) ExecuteVerify(token, code, recoveryCode string) (*entity.Admin, []string, error) {
+	admin, challenge, err := tu.ExecuteChallengeAdmin(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	var recoveryCodes []string
+	switch {
+	case !admin.TotpEnabled:
+		recoveryCodes, err = tu.confirm(admin, code)
+	case code != "":
+		err = tu.checkCode(admin, code)
+	case recoveryCode != "":
+		err = tu.useRecoveryCode(admin, recoveryCode)
+	default:
+		err = errors.New("Totp code or recovery code required")
+	}
+	if err != nil {
+		if failErr := tu.twoFactorRepo.FailChallenge(challenge.ID, challengeAttempts); failErr != nil {
+			log.Println(failErr)
+		}
+		return nil, nil, err
+	}
+	ok, err := tu.twoFactorRepo.UseChallenge(challenge.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, errors.New("Invalid or expired two-factor challenge")
+	}
+	return admin, recoveryCodes, nil
+}
+
+// ExecuteEnrol starts enrolment for a logged in admin who opts in.
+func (tu *TwoFactorUsecase) ExecuteEnrol(adminId int) (*entity.TotpEnrolment, error) {
+	admin, err := tu.admin(adminId)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TotpEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	return tu.enrol(admin)
+}
+
+// ExecuteQR renders the provisioning URI of a pending enrolment as a PNG. It
+// is not available once enrolment is confirmed, so the secret cannot be read
+// back later.
+func (tu *TwoFactorUsecase) ExecuteQR(adminId int) ([]byte, error) {
+	admin, err := tu.admin(adminId)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TotpEnabled || admin.TotpSecret == "" {
+		return nil, errors.New("no two-factor enrolment pending")
+	}
+	png, err := qrcode.Encode(utils.TotpURI(tu.issuer, admin.Phone, admin.TotpSecret), qrcode.Medium, qrSize)
+	if err != nil {
+		return nil, errors.New("Rendering qr code failed")
+	}
+	return png, nil
+}
+
+// ExecuteConfirm turns TOTP on with the first code from the authenticator and
+// returns the recovery codes, shown this once.
+func (tu *TwoFactorUsecase) ExecuteConfirm(adminId int, code string) ([]string, error) {
+	admin, err := tu.admin(adminId)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TotpEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	return tu.confirm(admin, code)
+}
+
+// ExecuteRecoveryCodes replaces the admin's recovery codes, given a current
+// TOTP code.
+func (tu *TwoFactorUsecase) ExecuteRecoveryCodes(adminId int, code string) ([]string, error) {
+	admin, err := tu.enabledAdmin(adminId)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.checkCode(admin, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = tu.twoFactorRepo.ReplaceRecoveryCodes(admin.ID, hashes)
+	if err != nil {
+		return nil, errors.New("Saving recovery codes failed")
+	}
+	return codes, nil
+}
+
+// ExecuteRecoveryCodesLeft counts the admin's unused recovery codes.
+func (tu *TwoFactorUsecase) ExecuteRecoveryCodesLeft(adminId int) (int, error) {
+	left, err := tu.twoFactorRepo.CountRecoveryCodes(adminId)
+	if err != nil {
+		return 0, errors.New("Counting recovery codes failed")
+	}
+	return left, nil
+}
+
+// ExecuteDisable turns TOTP off, given a current code. Admins whose role
+// requires it cannot.
+func (tu *TwoFactorUsecase) ExecuteDisable(adminId int, code string) error {
+	admin, err := tu.enabledAdmin(adminId)
+	if err != nil {
+		return err
+	}
+	if tu.required[admin.Role] {
+		return errors.New("two-factor authentication is required for the " + admin.Role + " role")
+	}
+	if err := tu.checkCode(admin, code); err != nil {
+		return err
+	}
+	return tu.twoFactorRepo.Reset(admin.ID)
+}
+
+// ExecuteReset clears an admin's TOTP so they can enrol again, for when they
+// lost both their authenticator and their recovery codes.
+func (tu *TwoFactorUsecase) ExecuteReset(adminId int) (*entity.Admin, error) {
+	admin, err := tu.admin(adminId)
+	if err != nil {
+		return nil, err
+	}
+	err = tu.twoFactorRepo.Reset(admin.ID)
+	if err != nil {
+		return nil, errors.New("Resetting two-factor authentication failed")
+	}
+	admin.TotpSecret = ""
+	admin.TotpEnabled = false
+	return admin, nil
+}
+
+// ExecuteResetByPhone is ExecuteReset for the admin CLI.
+func (tu *TwoFactorUsecase) ExecuteResetByPhone(phone string) (*entity.Admin, error) {
+	admin, err := tu.adminRepo.GetByPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return nil, errors.New("admin with this phone not found")
+	}
+	return tu.ExecuteReset(admin.ID)
+}
+
+// StartSweeper periodically drops expired challenges.
+func (tu *TwoFactorUsecase) StartSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if err := tu.twoFactorRepo.DeleteExpired(time.Now()); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (tu *TwoFactorUsecase) admin(adminId int) (*entity.Admin, error) {
+	admin, err := tu.adminRepo.GetAdminByID(adminId)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return nil, errors.New("admin not found")
+	}
+	return admin, nil
+}
+
+func (tu *TwoFactorUsecase) enabledAdmin(adminId int) (*entity.Admin, error) {
+	admin, err := tu.admin(adminId)
+	if err != nil {
+		return nil, err
+	}
+	if !admin.TotpEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	return admin, nil
+}
+
+// enrol keeps a pending secret if there is one, so logging in twice before
+// confirming does not invalidate an authenticator already set up.
+func (tu *TwoFactorUsecase) enrol(admin *entity.Admin) (*entity.TotpEnrolment, error) {
+	if admin.TotpSecret == "" {
+		secret, err := utils.NewTotpSecret()
+		if err != nil {
+			return nil, err
+		}
+		err = tu.twoFactorRepo.SetPendingSecret(admin.ID, secret)
+		if err != nil {
+			return nil, errors.New("Saving totp secret failed")
+		}
+		admin.TotpSecret = secret
+	}
+	return &entity.TotpEnrolment{
+		Secret: admin.TotpSecret,
+		URI:    utils.TotpURI(tu.issuer, admin.Phone, admin.TotpSecret),
+	}, nil
+}
+
+func (tu *TwoFactorUsecase) confirm(admin *entity.Admin, code string) ([]string, error) {
+	if admin.TotpSecret == "" {
+		return nil, errors.New("no two-factor enrolment pending")
+	}
+	step, ok := utils.TotpVerify(admin.TotpSecret, code, time.Now(), admin.TotpLastStep)
+	if !ok {
+		return nil, errors.New("Invalid totp code")
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = tu.twoFactorRepo.Enable(admin.ID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	admin.TotpEnabled = true
+	return codes, nil
+}
+
+func (tu *TwoFactorUsecase) checkCode(admin *entity.Admin, code string) error {
+	step, ok := utils.TotpVerify(admin.TotpSecret, code, time.Now(), admin.TotpLastStep)
+	if !ok {
+		return errors.New("Invalid totp code")
+	}
+	accepted, err := tu.twoFactorRepo.AcceptStep(admin.ID, step)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return errors.New("Totp code already used")
+	}
+	return nil
+}
+
+func (tu *TwoFactorUsecase) useRecoveryCode(admin *entity.Admin, code string) error {
+	ok, err := tu.twoFactorRepo.UseRecoveryCode(admin.ID, utils.HashToken(utils.NormaliseRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Invalid recovery code")
+	}
+	return nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := utils.NewRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = code
+		hashes[i] = utils.HashToken(code)
+	}
+	return codes, hashes, nil
+}
@@ -2,9 +2,10 @@ package usecase
 
 import (
 	"errors"
+	"strings"
 	"zog/delivery/models"
 	"zog/domain/entity"
-	"zog/domain/utils"
+	"zog/repository/otp"
 	repository "zog/repository/user"
 
 	"golang.org/x/crypto/bcrypt"
@@ -12,13 +13,16 @@ import (
 
 type UserUsecase struct {
 	userRepo *repository.UserRepository
+	otp      otp.OTPProvider
 }
 
-func NewUser(userRepo *repository.UserRepository) *UserUsecase {
-	return &UserUsecase{userRepo: userRepo}
+func NewUser(userRepo *repository.UserRepository, otpProvider otp.OTPProvider) *UserUsecase {
+	return &UserUsecase{userRepo: userRepo, otp: otpProvider}
 }
 
-func (us *UserUsecase) ExecuteSignup(user entity.User) (*entity.User, error) {
+// ExecuteSignup creates the user. referralCode is the code of the user who
+// invited them and may be empty.
+func (us *UserUsecase) ExecuteSignup(user entity.User, referralCode string) (*entity.User, error) {
 	email, err := us.userRepo.GetByEmail(user.Email)
 	if err != nil {
 		return nil, errors.New("error with server")
@@ -34,17 +38,30 @@ func (us *UserUsecase) ExecuteSignup(user entity.User) (*entity.User, error) {
 		return nil, errors.New("user with this phone no already exists")
 	}
 
+	referrer, err := us.referrer(referralCode, user.Phone, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	code, err := us.userRepo.NewReferralCode()
+	if err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
 
 	newUser := &entity.User{
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		Phone:     user.Phone,
-		Password:  string(hashedPassword),
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		Password:     string(hashedPassword),
+		ReferralCode: code,
+	}
+	if referrer != nil {
+		newUser.ReferredBy = referrer.ID
 	}
 
 	err1 := us.userRepo.Create(newUser)
@@ -56,7 +73,6 @@ func (us *UserUsecase) ExecuteSignup(user entity.User) (*entity.User, error) {
 }
 
 func (uu *UserUsecase) ExecuteSignupWithOtp(user models.Signup) (string, error) {
-	var otpKey entity.OtpKey
 	email, err := uu.userRepo.GetByEmail(user.Email)
 	if err != nil {
 		return "", errors.New("error with server")
@@ -71,57 +87,88 @@ func (uu *UserUsecase) ExecuteSignupWithOtp(user models.Signup) (string, error)
 	if phone != nil {
 		return "", errors.New("user with this phone no already exists")
 	}
+	if _, err := uu.referrer(user.ReferredBy, user.Phone, user.Email); err != nil {
+		return "", err
+	}
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return "", err
 	}
 	user.Password = string(hashedPassword)
-	key, err := utils.SendOtp(user.Phone)
+	key, err := uu.otp.Send(user.Phone, entity.OtpSignup)
 	if err != nil {
 		return "", err
-	} else {
-		err = uu.userRepo.CreateSignup(&user)
-		otpKey.Key = key
-		otpKey.Phone = user.Phone
-		err = uu.userRepo.CreateOtpKey(&otpKey)
-		if err != nil {
-			return "", err
-		}
-		return key, nil
 	}
+	err = uu.userRepo.CreateSignup(&user)
+	if err != nil {
+		return "", err
+	}
+	return key, nil
 }
 
-func (uu *UserUsecase) ExecuteSignupOtpValidation(key string, otp string) error {
+func (uu *UserUsecase) ExecuteSignupOtpValidation(key string, otp string) (*entity.User, error) {
 	result, err := uu.userRepo.GetByKey(key)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("invalid otp key")
 	}
 	user, err := uu.userRepo.GetSignupByPhone(result.Phone)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = utils.CheckOtp(result.Phone, otp)
+	err = uu.otp.Check(result.Phone, key, entity.OtpSignup, otp)
 	if err != nil {
-		return err
+		return nil, err
 	} else {
+		code, err := uu.userRepo.NewReferralCode()
+		if err != nil {
+			return nil, err
+		}
 		newUser := &entity.User{
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-			Email:     user.Email,
-			Phone:     user.Phone,
-			Password:  user.Password,
+			FirstName:    user.FirstName,
+			LastName:     user.LastName,
+			Email:        user.Email,
+			Phone:        user.Phone,
+			Password:     user.Password,
+			ReferralCode: code,
+		}
+		// The referrer may have been blocked since the OTP was sent; the
+		// signup still goes through, just without the referral.
+		if referrer, err := uu.referrer(user.ReferredBy, user.Phone, user.Email); err == nil && referrer != nil {
+			newUser.ReferredBy = referrer.ID
 		}
 
 		err1 := uu.userRepo.Create(newUser)
 		if err1 != nil {
-			return err1
+			return nil, err1
 		} else {
-			return nil
+			return newUser, nil
 		}
 	}
 
 }
 
+// referrer finds the user a referral code belongs to. Users cannot refer
+// themselves and blocked users cannot refer anyone.
+func (uu *UserUsecase) referrer(code, phone, email string) (*entity.User, error) {
+	if code == "" {
+		return nil, nil
+	}
+	referrer, err := uu.userRepo.GetByReferralCode(code)
+	if err != nil {
+		return nil, errors.New("error with server")
+	}
+	if referrer == nil || !referrer.Permission {
+		return nil, errors.New("Invalid referral code")
+	}
+	if referrer.Phone == phone || strings.EqualFold(referrer.Email, email) {
+		return nil, errors.New("You cannot use your own referral code")
+	}
+	return referrer, nil
+}
+
 func (ul *UserUsecase) ExecuteLoginWithPassword(phone, password string) (int, error) {
 	user, err := ul.userRepo.GetByPhone(phone)
 	if err != nil {
@@ -142,8 +189,29 @@ func (ul *UserUsecase) ExecuteLoginWithPassword(phone, password string) (int, er
 
 }
 
+// ExecuteLoginWithEmail logs in with a verified email address and password.
+func (uu *UserUsecase) ExecuteLoginWithEmail(email, password string) (*entity.User, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, errors.New("Email is required")
+	}
+	user, err := uu.userRepo.GetByVerifiedEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user with this verified email not found")
+	}
+	if !user.Permission {
+		return nil, errors.New("user permission denied")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, errors.New("Invalid Password")
+	}
+	return user, nil
+}
+
 func (u *UserUsecase) ExecuteLogin(phone string) (string, error) {
-	var otpKey entity.OtpKey
 	result, err := u.userRepo.GetByPhone(phone)
 	if err != nil {
 		return "", err
@@ -155,19 +223,18 @@ func (u *UserUsecase) ExecuteLogin(phone string) (string, error) {
 	if permission == false {
 		return "", errors.New("user permission denied")
 	}
-	key, err := utils.SendOtp(phone)
-	if err != nil {
+	return u.otp.Send(phone, entity.OtpLogin)
+
+}
+
+// ExecuteOtpPhone returns the phone the otp with key was sent to, or "" for
+// an unknown key.
+func (uu *UserUsecase) ExecuteOtpPhone(key string) (string, error) {
+	result, err := uu.userRepo.GetByKey(key)
+	if err != nil || result == nil {
 		return "", err
-	} else {
-		otpKey.Key = key
-		otpKey.Phone = phone
-		err = u.userRepo.CreateOtpKey(&otpKey)
-		if err != nil {
-			return "", err
-		}
-		return key, nil
 	}
-
+	return result.Phone, nil
 }
 
 func (uu *UserUsecase) ExecuteOtpValidation(key, otp string) (*entity.User, error) {
@@ -175,11 +242,14 @@ func (uu *UserUsecase) ExecuteOtpValidation(key, otp string) (*entity.User, erro
 	if err != nil {
 		return nil, err
 	}
+	if result == nil {
+		return nil, errors.New("invalid otp key")
+	}
 	user, err := uu.userRepo.GetByPhone(result.Phone)
 	if err != nil {
 		return nil, err
 	}
-	err1 := utils.CheckOtp(result.Phone, otp)
+	err1 := uu.otp.Check(result.Phone, key, entity.OtpLogin, otp)
 	if err1 != nil {
 		return nil, err1
 	}
@@ -211,42 +281,40 @@ func (uu *UserUsecase) ExecuteShowUserDetails(userId int) (*entity.User, *[]enti
 
 }
 
-func (uu *UserUsecase) ExecuteEditProfile(user entity.User, userid int) error {
+// ExecuteEditProfile updates the user's details and reports whether their
+// email changed, in which case it needs verifying again.
+func (uu *UserUsecase) ExecuteEditProfile(user entity.User, userid int) (bool, error) {
+	current, err := uu.userRepo.GetByID(userid)
+	if err != nil {
+		return false, err
+	}
+	if current == nil {
+		return false, errors.New("user with this id not found")
+	}
 	user.ID = userid
-	err := uu.userRepo.Update(&user)
+	emailChanged := user.Email != "" && user.Email != current.Email
+	err = uu.userRepo.UpdateProfile(&user, emailChanged)
 	if err != nil {
-		return errors.New("User details updation failed")
+		return false, errors.New("User details updation failed")
 	}
-	return nil
+	return emailChanged, nil
 }
 
 func (uu *UserUsecase) ExecuteChangePassword(userId int) (string, error) {
-	var otpKey entity.OtpKey
 	user, err := uu.userRepo.GetByID(userId)
 	if err != nil {
 		return "", err
 	}
-	key, err1 := utils.SendOtp(user.Phone)
-	if err1 != nil {
-		return "", err
-	} else {
-		otpKey.Key = key
-		otpKey.Phone = user.Phone
-		err = uu.userRepo.CreateOtpKey(&otpKey)
-		if err != nil {
-			return "", err
-		}
-		return key, nil
-	}
+	return uu.otp.Send(user.Phone, entity.OtpPassword)
 
 }
 
-func (uu *UserUsecase) ExecuteOtpValidationPassword(password string, otp string, userId int) error {
+func (uu *UserUsecase) ExecuteOtpValidationPassword(password, key, otp string, userId int) error {
 	user, err := uu.userRepo.GetByID(userId)
 	if err != nil {
 		return err
 	}
-	err = utils.CheckOtp(user.Phone, otp)
+	err = uu.otp.Check(user.Phone, key, entity.OtpPassword, otp)
 	if err != nil {
 		return err
 	}
@@ -259,3 +327,11 @@ func (uu *UserUsecase) ExecuteOtpValidationPassword(password string, otp string,
 	return nil
 
 }
+
+func (uu *UserUsecase) ExecuteNotifications(userId int) ([]entity.Notification, error) {
+	notifications, err := uu.userRepo.GetNotifications(userId)
+	if err != nil {
+		return nil, errors.New("Fetching notifications failed")
+	}
+	return notifications, nil
+}
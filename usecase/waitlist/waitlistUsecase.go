@@ -0,0 +1,196 @@
+package waitlist
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"zog/domain/entity"
+	productrepository "zog/repository/product"
+	userrepository "zog/repository/user"
+	repository "zog/repository/waitlist"
+)
+
+type WaitlistUsecase struct {
+	waitlistRepo      *repository.WaitlistRepository
+	productRepo       *productrepository.ProductRepository
+	userRepo          *userrepository.UserRepository
+	reservationWindow time.Duration
+	mu                sync.Mutex
+}
+
+func NewWaitlist(waitlistRepo *repository.WaitlistRepository, productRepo *productrepository.ProductRepository, userRepo *userrepository.UserRepository, reservationWindow time.Duration) *WaitlistUsecase {
+	return &WaitlistUsecase{waitlistRepo: waitlistRepo, productRepo: productRepo, userRepo: userRepo, reservationWindow: reservationWindow}
+}
+
+func (wu *WaitlistUsecase) ExecuteJoinWaitlist(userId int, category string, productId, quantity int) error {
+	if category != "ticket" && category != "apparel" {
+		return errors.New("Invalid product category")
+	}
+	if quantity < 1 {
+		return errors.New("Invalid quantity")
+	}
+	inventory, err := wu.productRepo.GetByProductId(productId, category)
+	if err != nil {
+		return errors.New("Product not found in inventory")
+	}
+	reserved, err := wu.waitlistRepo.ReservedQuantity(category, productId, userId)
+	if err != nil {
+		return errors.New("Checking stock failed")
+	}
+	if inventory.Quantity-reserved >= quantity {
+		return errors.New("Product is in stock - add it to cart")
+	}
+	existing, err := wu.waitlistRepo.GetActiveEntry(userId, category, productId)
+	if err != nil {
+		return errors.New("Finding waitlist entry failed")
+	}
+	if existing != nil {
+		return errors.New("Already in the waitlist for this product")
+	}
+	entry := &entity.WaitlistEntry{
+		UserId:    userId,
+		Category:  category,
+		ProductId: productId,
+		Quantity:  quantity,
+		Status:    "waiting",
+	}
+	err = wu.waitlistRepo.Create(entry)
+	if err != nil {
+		return errors.New("Joining waitlist failed")
+	}
+	return nil
+}
+
+func (wu *WaitlistUsecase) ExecuteLeaveWaitlist(userId int, category string, productId int) error {
+	entry, err := wu.waitlistRepo.GetActiveEntry(userId, category, productId)
+	if err != nil {
+		return errors.New("Finding waitlist entry failed")
+	}
+	if entry == nil {
+		return errors.New("Not in the waitlist for this product")
+	}
+	wasReserved := entry.Status == "reserved"
+	entry.Status = "left"
+	err = wu.waitlistRepo.Update(entry)
+	if err != nil {
+		return errors.New("Leaving waitlist failed")
+	}
+	if wasReserved {
+		wu.mu.Lock()
+		defer wu.mu.Unlock()
+		return wu.promote(category, productId)
+	}
+	return nil
+}
+
+func (wu *WaitlistUsecase) ExecuteUserWaitlist(userId int) ([]entity.WaitlistEntry, error) {
+	entries, err := wu.waitlistRepo.GetByUser(userId)
+	if err != nil {
+		return nil, errors.New("Fetching waitlist failed")
+	}
+	return entries, nil
+}
+
+func (wu *WaitlistUsecase) ExecuteWaitlistReport() ([]entity.WaitlistReport, error) {
+	report, err := wu.waitlistRepo.GetReport()
+	if err != nil {
+		return nil, errors.New("Fetching waitlist report failed")
+	}
+	for i, line := range report {
+		if line.Category == "ticket" {
+			ticket, err := wu.productRepo.GetTicketByID(line.ProductId)
+			if err == nil {
+				report[i].ProductName = ticket.Name
+			}
+		} else {
+			apparel, err := wu.productRepo.GetApparelByID(line.ProductId)
+			if err == nil {
+				report[i].ProductName = apparel.Name
+			}
+		}
+	}
+	return report, nil
+}
+
+// ExecuteProcessWaitlist expires stale reservations and hands the freed or
+// restocked units to the next users in line.
+func (wu *WaitlistUsecase) ExecuteProcessWaitlist() error {
+	wu.mu.Lock()
+	defer wu.mu.Unlock()
+
+	expired, err := wu.waitlistRepo.GetExpiredReservations(time.Now())
+	if err != nil {
+		return errors.New("Fetching expired reservations failed")
+	}
+	for _, entry := range expired {
+		entry.Status = "expired"
+		err = wu.waitlistRepo.Update(&entry)
+		if err != nil {
+			return errors.New("Expiring reservation failed")
+		}
+		wu.notify(entry.UserId, "Reservation expired", fmt.Sprintf("Your reservation for %s %d has expired", entry.Category, entry.ProductId))
+	}
+	products, err := wu.waitlistRepo.GetWaitingProducts()
+	if err != nil {
+		return errors.New("Fetching waitlist failed")
+	}
+	for _, product := range products {
+		err = wu.promote(product.Category, product.ProductId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wu *WaitlistUsecase) StartReservationSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if err := wu.ExecuteProcessWaitlist(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (wu *WaitlistUsecase) promote(category string, productId int) error {
+	inventory, err := wu.productRepo.GetByProductId(productId, category)
+	if err != nil {
+		return nil
+	}
+	reserved, err := wu.waitlistRepo.ReservedQuantity(category, productId, 0)
+	if err != nil {
+		return errors.New("Checking stock failed")
+	}
+	available := inventory.Quantity - reserved
+	waiting, err := wu.waitlistRepo.GetWaiting(category, productId)
+	if err != nil {
+		return errors.New("Fetching waitlist failed")
+	}
+	for _, entry := range waiting {
+		if entry.Quantity > available {
+			break
+		}
+		entry.Status = "reserved"
+		entry.ReservedUntil = time.Now().Add(wu.reservationWindow)
+		err = wu.waitlistRepo.Update(&entry)
+		if err != nil {
+			return errors.New("Reserving stock failed")
+		}
+		available -= entry.Quantity
+		wu.notify(entry.UserId, "Back in stock", fmt.Sprintf("%d x %s %d is reserved for you until %s - complete your purchase before it moves to the next person", entry.Quantity, entry.Category, entry.ProductId, entry.ReservedUntil.Format(time.Kitchen)))
+	}
+	return nil
+}
+
+func (wu *WaitlistUsecase) notify(userId int, title, message string) {
+	notification := &entity.Notification{
+		UserId:  userId,
+		Title:   title,
+		Message: message,
+	}
+	if err := wu.userRepo.CreateNotification(notification); err != nil {
+		log.Println("notification failed:", err)
+	}
+}